@@ -0,0 +1,80 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Commands that have to make their changes as they go, so
+// can't be part of an alias whose changes are sent together
+var immediate = map[string]bool{
+	"sleep":    true,
+	"talkback": true,
+	"fade":     true,
+}
+
+// runAlias runs each of an alias's commands in turn, sharing the config
+// and client like a batch. Unless one of the commands has to make its
+// changes as it goes, they're all sent at the end in a single request.
+func runAlias(cfg *Config, lines []string) error {
+	commands := make([][]string, 0, len(lines))
+	together := true
+	for _, line := range lines {
+		args, err := splitArgs(line)
+		if err != nil {
+			return fmt.Errorf("invalid command %q: %w", line, err)
+		}
+		if len(args) == 0 {
+			continue
+		}
+		if _, ok := cfg.Aliases[args[0]]; ok {
+			return errors.New("aliases can't run other aliases")
+		}
+		if args[0] == "batch" || args[0] == "repl" || unrecorded[args[0]] {
+			return fmt.Errorf("%s can't be run from an alias", args[0])
+		}
+
+		if immediate[args[0]] || (len(args) > 1 && immediate[args[1]]) {
+			together = false
+		}
+		commands = append(commands, args)
+	}
+
+	if batch == nil {
+		batch = &batchState{cfg: cfg}
+		defer func() { batch = nil }()
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if together {
+		m.Defer()
+	}
+
+	for _, args := range commands {
+		if c := findCommand(args[0]); c != nil {
+			err = c.run(args[1:])
+		} else {
+			err = deviceCommand(args)
+		}
+		if err != nil {
+			// Nothing held back is sent, so a failure
+			// part way through changes nothing
+			m.Discard()
+			return fmt.Errorf("%s: %w", strings.Join(args, " "), err)
+		}
+	}
+
+	if together {
+		if err := m.Flush(context.Background()); err != nil {
+			return fmt.Errorf("failed to make changes: %w", err)
+		}
+	}
+
+	return nil
+}
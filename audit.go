@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// auditSourceOther is the source of changes that weren't made by
+// this tool, e.g. ones made in the web UI or by another controller
+const auditSourceOther = "other"
+
+// auditLog appends every change to a file, one JSON object per line
+type auditLog struct {
+	path string
+	mu   sync.Mutex
+}
+
+type auditEntry struct {
+	Time time.Time `json:"time"`
+
+	// The command that made the change, or "other"
+	Source string `json:"source"`
+
+	Path string `json:"path"`
+	Old  any    `json:"old"`
+	New  any    `json:"new"`
+}
+
+// audit is nil if there is no audit log
+var audit *auditLog
+
+// newAuditLog returns an audit log that writes to path. A
+// relative path is relative to the config file's directory.
+func newAuditLog(path string) (*auditLog, error) {
+	if !filepath.IsAbs(path) {
+		cfgPath, err := configPath()
+		if err != nil {
+			return nil, fmt.Errorf("failed to find config: %w", err)
+		}
+		path = filepath.Join(filepath.Dir(cfgPath), path)
+	}
+
+	return &auditLog{path: path}, nil
+}
+
+// add appends the changes to the log
+func (a *auditLog) add(source string, changes ...motu.Change) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(a.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create audit log directory: %w", err)
+	}
+
+	// O_APPEND keeps lines whole even if several
+	// commands write to the log at the same time
+	f, err := os.OpenFile(a.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open audit log: %w", err)
+	}
+	defer f.Close()
+
+	var b []byte
+	now := time.Now()
+	for _, c := range changes {
+		line, err := json.Marshal(&auditEntry{
+			Time:   now,
+			Source: source,
+			Path:   c.Path,
+			Old:    c.Old,
+			New:    c.New,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit entry: %w", err)
+		}
+		b = append(append(b, line...), '\n')
+	}
+
+	if _, err := f.Write(b); err != nil {
+		return fmt.Errorf("failed to write audit log: %w", err)
+	}
+
+	return nil
+}
+
+// record is used as the client's OnChange
+func (a *auditLog) record(c motu.Change) {
+	if err := a.add(auditSource(), c); err != nil {
+		slog.Error("Failed to write audit log", "err", err)
+	}
+}
+
+// watch logs changes made by other controllers until ctx is cancelled
+func (a *auditLog) watch(ctx context.Context, mirror *motu.Mirror) {
+	changes, unsubscribe := mirror.SubscribeChanges()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case batch := <-changes:
+			if err := a.add(auditSourceOther, batch...); err != nil {
+				slog.Error("Failed to write audit log", "err", err)
+			}
+		}
+	}
+}
+
+// auditSource describes the command that's running, e.g. "main inc"
+func auditSource() string {
+	source := strings.Join(os.Args[1:], " ")
+	if target != "" {
+		source = "--target " + target + " " + source
+	}
+	return source
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const avbUsage = "usage: avb list"
+
+// avbCommand shows the AVB devices that the interface can see
+func avbCommand(args []string) error {
+	if len(args) != 1 || args[0] != "list" {
+		return usagef(avbUsage)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	uid, err := m.GetString("datastore/uid")
+	if err != nil {
+		return fmt.Errorf("failed to get UID: %w", err)
+	}
+
+	entities, err := m.Entities()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(entitiesJSON(entities, uid))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tMODEL\tENTITY ID\tINPUT STREAMS\tOUTPUT STREAMS\n")
+	for _, e := range entities {
+		name := e.Name
+		if e.UID == uid {
+			name += " (this interface)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", name, e.Model, e.UID, formatStreams(e.Inputs), formatStreams(e.Outputs))
+	}
+
+	return w.Flush()
+}
+
+// formatStreams describes streams by their
+// number and total channel count, e.g. "2 (16 ch)"
+func formatStreams(streams []*motu.Stream) string {
+	if len(streams) == 0 {
+		return "-"
+	}
+
+	var channels int
+	for _, s := range streams {
+		channels += s.Channels
+	}
+
+	return fmt.Sprintf("%d (%d ch)", len(streams), channels)
+}
+
+// entityJSON is the JSON representation of an AVB device
+type entityJSON struct {
+	Name    string `json:"name"`
+	Model   string `json:"model"`
+	UID     string `json:"uid"`
+	Self    bool   `json:"self"`
+	Inputs  int    `json:"input_streams"`
+	Outputs int    `json:"output_streams"`
+}
+
+func entitiesJSON(entities []*motu.Entity, uid string) []*entityJSON {
+	result := make([]*entityJSON, 0, len(entities))
+	for _, e := range entities {
+		result = append(result, &entityJSON{
+			Name:    e.Name,
+			Model:   e.Model,
+			UID:     e.UID,
+			Self:    e.UID == uid,
+			Inputs:  len(e.Inputs),
+			Outputs: len(e.Outputs),
+		})
+	}
+	return result
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// BackupsConfig makes the daemon save snapshots of the datastore,
+// which "snapshot restore" can put back
+type BackupsConfig struct {
+	// How often to save one. Defaults to an hour.
+	Every time.Duration `yaml:"every"`
+
+	// Also save one once this many properties have changed
+	// since the last, as soon as they stop changing. Zero only
+	// saves them on the schedule.
+	AfterChanges int `yaml:"after_changes"`
+
+	// How many to keep. The oldest are deleted. Defaults to 24.
+	Keep int `yaml:"keep"`
+
+	// Where to keep them. A relative path is relative to the config
+	// file's directory. Defaults to "backups", or "backups/<target>"
+	// for a target.
+	Dir string `yaml:"dir"`
+}
+
+const (
+	defaultBackupEvery = time.Hour
+	defaultBackupKeep  = 24
+
+	// How long properties have to stop changing
+	// before after_changes saves a backup
+	backupSettle = 10 * time.Second
+
+	// Backups are named after when they were saved
+	backupFileLayout = "20060102-150405.json"
+)
+
+func (bc *BackupsConfig) validate() error {
+	if bc.Every < 0 {
+		return fmt.Errorf("every can't be negative")
+	}
+	if bc.Every != 0 && bc.Every < time.Minute {
+		return fmt.Errorf("every must be at least a minute")
+	}
+	if bc.AfterChanges < 0 {
+		return fmt.Errorf("after_changes can't be negative")
+	}
+	if bc.Keep < 0 {
+		return fmt.Errorf("keep can't be negative")
+	}
+
+	if bc.Every == 0 {
+		bc.Every = defaultBackupEvery
+	}
+	if bc.Keep == 0 {
+		bc.Keep = defaultBackupKeep
+	}
+	return nil
+}
+
+// dir returns the directory that backups are kept in
+func (bc *BackupsConfig) dir() (string, error) {
+	dir := bc.Dir
+	if dir == "" {
+		dir = "backups"
+		if target != "" {
+			dir = filepath.Join(dir, target)
+		}
+	}
+	if filepath.IsAbs(dir) {
+		return dir, nil
+	}
+
+	cfgPath, err := configPath()
+	if err != nil {
+		return "", fmt.Errorf("failed to find config: %w", err)
+	}
+	return filepath.Join(filepath.Dir(cfgPath), dir), nil
+}
+
+// run saves backups until ctx is cancelled. The first is saved as soon
+// as the mirror is synced. A backup isn't saved if nothing has changed
+// since the last one, so that rotation doesn't throw away backups of
+// something different.
+func (bc *BackupsConfig) run(ctx context.Context, mirror *motu.Mirror) {
+	dir, err := bc.dir()
+	if err != nil {
+		slog.Error("Not saving backups", "err", err)
+		return
+	}
+
+	// Carry on from the last run of the daemon
+	var last map[string]any
+	if files, err := listBackups(dir); err == nil && len(files) > 0 {
+		if s, err := readSnapshot(files[len(files)-1]); err == nil {
+			last = s.Values
+		}
+	}
+
+	// The properties that differ from the last backup
+	changed := map[string]bool{}
+
+	save := func() {
+		if !mirror.Synced() {
+			return
+		}
+
+		values := mirror.Values()
+		if reflect.DeepEqual(values, last) {
+			return
+		}
+
+		file, err := saveBackup(dir, values, bc.Keep)
+		if err != nil {
+			slog.Error("Failed to save backup", "err", err)
+			return
+		}
+
+		slog.Info("Saved a backup of the datastore", "file", file, "changed", len(changed))
+		last = values
+		clear(changed)
+	}
+
+	synced, unsubscribeSynced := mirror.SubscribeSynced()
+	defer unsubscribeSynced()
+	changes, unsubscribe := mirror.Subscribe()
+	defer unsubscribe()
+
+	ticker := time.NewTicker(bc.Every)
+	defer ticker.Stop()
+
+	var settled <-chan time.Time
+	save()
+	started := mirror.Synced()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case ok := <-synced:
+			if ok && !started {
+				started = true
+				save()
+			}
+
+		case <-ticker.C:
+			save()
+
+		case batch := <-changes:
+			if bc.AfterChanges == 0 || !started {
+				continue
+			}
+			for k, v := range batch {
+				if old, ok := last[k]; !ok || !reflect.DeepEqual(old, v) {
+					changed[k] = true
+				}
+			}
+			if len(changed) >= bc.AfterChanges {
+				settled = time.After(backupSettle)
+			}
+
+		case <-settled:
+			settled = nil
+			save()
+		}
+	}
+}
+
+// saveBackup writes values to a new backup in dir, and deletes the
+// oldest backups so that only keep are left. It returns the new file.
+func saveBackup(dir string, values map[string]any, keep int) (string, error) {
+	s := &Snapshot{Created: time.Now(), Values: values}
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal snapshot: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", fmt.Errorf("failed to create backup directory: %w", err)
+	}
+
+	// Write to a temporary file first so that a
+	// crash doesn't leave a half-written backup
+	file := filepath.Join(dir, s.Created.Format(backupFileLayout))
+	tmp := file + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := os.Rename(tmp, file); err != nil {
+		return "", fmt.Errorf("failed to write backup: %w", err)
+	}
+
+	files, err := listBackups(dir)
+	if err != nil {
+		return "", err
+	}
+	for len(files) > keep {
+		if err := os.Remove(files[0]); err != nil {
+			return "", fmt.Errorf("failed to delete old backup: %w", err)
+		}
+		files = files[1:]
+	}
+
+	return file, nil
+}
+
+// listBackups returns the backups in dir, oldest first.
+// Other files in the directory are left alone.
+func listBackups(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read backup directory: %w", err)
+	}
+
+	var files []string
+	for _, e := range entries {
+		if _, err := time.Parse(backupFileLayout, e.Name()); err == nil && !e.IsDir() {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+
+	// The names sort in the order they were saved
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// batchState is shared by the commands in a batch, so that the config
+// is only read once and the connection to the interface is reused
+type batchState struct {
+	cfg    *Config
+	client *motu.Client
+}
+
+// batch is set while running a batch
+var batch *batchState
+
+// batchCommand runs commands read from a file, or from stdin if the
+// file is "-", one per line. Blank lines and lines starting with #
+// are skipped. It stops at the first command that fails unless
+// --continue is given.
+func batchCommand(args []string) error {
+	flags := flag.NewFlagSet("batch", flag.ContinueOnError)
+	keepGoing := flags.Bool("continue", false, "carry on after a command fails")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: batch <file|-> [--continue]")
+	}
+
+	var in io.Reader = os.Stdin
+	if positional[0] != "-" {
+		f, err := os.Open(positional[0])
+		if err != nil {
+			return fmt.Errorf("failed to open batch file: %w", err)
+		}
+		defer f.Close()
+		in = f
+	}
+
+	batch = &batchState{}
+	defer func() { batch = nil }()
+
+	failed, total := 0, 0
+	scanner := bufio.NewScanner(in)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		total++
+		err := runBatchLine(line)
+		if err == nil {
+			continue
+		}
+		if !*keepGoing {
+			return fmt.Errorf("line %d: %w", n, err)
+		}
+
+		failed++
+		fmt.Fprintf(os.Stderr, "Error on line %d: %v\n", n, err)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read commands: %w", err)
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d commands failed", failed, total)
+	}
+	return nil
+}
+
+// runBatchLine runs a single command from a batch. Each one gets its
+// own entry in the history, as if it had been run on its own.
+func runBatchLine(line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if args[0] == "batch" {
+		return errors.New("batches can't be nested")
+	}
+
+	recording = nil
+	if !unrecorded[args[0]] {
+		recording = &recorder{}
+	}
+	defer func() { recording = nil }()
+
+	if c := findCommand(args[0]); c != nil {
+		err = c.run(args[1:])
+	} else {
+		err = deviceCommand(args)
+	}
+
+	if herr := saveHistory(args); herr != nil && err == nil {
+		err = fmt.Errorf("failed to save history: %w", herr)
+	}
+	return err
+}
+
+// splitArgs splits a line into arguments like a shell would, so that
+// device names with spaces in can be quoted
+func splitArgs(line string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	var quote rune
+	inArg, escaped := false, false
+
+	for _, r := range line {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped, inArg = true, true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if quote != 0 || escaped {
+		return nil, fmt.Errorf("unterminated quote or escape")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
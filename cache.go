@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const (
+	// How long the CLI trusts a cached value by default
+	defaultCacheMaxAge = 10 * time.Second
+
+	// How often the daemon saves the cache
+	cacheSaveInterval = 2 * time.Second
+)
+
+// cache holds the values of the configured devices between runs, so
+// that commands don't have to wait for the interface before doing
+// anything. It's nil if caching is turned off or no client was made.
+var cache *motu.Cache
+
+// cachePath returns the location of the cache file, which sits
+// alongside the config file. Each target has its own cache.
+func cachePath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	name := "cache.json"
+	if target != "" {
+		name = "cache-" + target + ".json"
+	}
+
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// loadCache reads the cache file. If the file doesn't
+// exist or can't be read, an empty cache is returned.
+func loadCache(maxAge time.Duration) *motu.Cache {
+	c := motu.NewCache(maxAge)
+
+	path, err := cachePath()
+	if err != nil {
+		return c
+	}
+
+	// A bad cache is no worse than an empty one
+	if b, err := os.ReadFile(path); err == nil {
+		_ = json.Unmarshal(b, c)
+	}
+
+	return c
+}
+
+// saveCache writes the cache file, if there's a cache
+func saveCache() error {
+	if cache == nil {
+		return nil
+	}
+
+	path, err := cachePath()
+	if err != nil {
+		return fmt.Errorf("failed to find cache file: %w", err)
+	}
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		return fmt.Errorf("failed to marshal cache: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %w", err)
+	}
+
+	// Several commands can run at once, e.g. when a
+	// key repeats, so never leave a half written file
+	tmp := fmt.Sprintf("%s.%d", path, os.Getpid())
+	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+		return fmt.Errorf("failed to write cache file: %w", err)
+	}
+
+	return nil
+}
+
+// refreshCache keeps the cache up to date from the mirror until ctx is
+// cancelled, so that the CLI can trust it while the daemon is running
+func refreshCache(ctx context.Context, mirror *motu.Mirror, devices map[string]*motu.Device) {
+	if cache == nil {
+		return
+	}
+
+	ticker := time.NewTicker(cacheSaveInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if !mirror.Synced() {
+			continue
+		}
+
+		for _, d := range devices {
+			for _, p := range []string{d.Property, d.MuteProperty} {
+				if v, ok := mirror.Value(p); ok {
+					cache.Put(p, v, mirror.ETag())
+				}
+			}
+		}
+
+		if err := saveCache(); err != nil {
+			slog.Error("Failed to save cache", "err", err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const chanUsage = "usage: chan <index> status | comp|gate [<param> [<value>]] | send reverb|aux <aux> [<dB>] | pan [<-100..100>|inc|dec]"
+
+// How far pan inc and dec move the pan, out of 100
+const panStep = 10
+
+// stripParam is a parameter of a section of a mixer channel's strip
+type stripParam struct {
+	name string
+
+	// Path relative to the channel, e.g. "comp/threshold"
+	key string
+
+	// Shown after the value
+	unit string
+
+	// The value is an amplitude ratio, like a fader,
+	// and is shown and set in dB
+	fader bool
+
+	// The value is a switch, shown as on or off
+	toggle bool
+}
+
+var compParams = []stripParam{
+	{name: "enable", key: "comp/enable", toggle: true},
+	{name: "threshold", key: "comp/threshold", unit: "dB"},
+	{name: "ratio", key: "comp/ratio", unit: ":1"},
+	{name: "attack", key: "comp/attack", unit: "ms"},
+	{name: "release", key: "comp/release", unit: "ms"},
+	{name: "makeup", key: "comp/trim", unit: "dB"},
+}
+
+var gateParams = []stripParam{
+	{name: "enable", key: "gate/enable", toggle: true},
+	{name: "threshold", key: "gate/threshold", unit: "dB"},
+	{name: "attack", key: "gate/attack", unit: "ms"},
+	{name: "release", key: "gate/release", unit: "ms"},
+}
+
+// Parameters of the reverb bus, relative to mix/reverb/0
+var reverbParams = []stripParam{
+	{name: "return", key: "matrix/fader", unit: "dB", fader: true},
+	{name: "enable", key: "reverb/enable", toggle: true},
+	{name: "predelay", key: "reverb/predelay", unit: "ms"},
+	{name: "decay", key: "reverb/reverbtime", unit: "ms"},
+}
+
+// chanCommand controls the channel strip of a mixer channel. Channels
+// are given by their index in the datastore, as listed by "channels".
+func chanCommand(args []string) error {
+	if len(args) < 2 {
+		return usagef(chanUsage)
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 0 {
+		return usagef("invalid channel index: %s", args[0])
+	}
+	prefix := fmt.Sprintf("datastore/mix/chan/%d", index)
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch args[1] {
+	case "comp":
+		return stripCommand(m, prefix, compParams, args[2:])
+	case "gate":
+		return stripCommand(m, prefix, gateParams, args[2:])
+	case "send":
+		return sendCommand(m, prefix, args[2:])
+	case "pan":
+		return panCommand(m, prefix, args[2:])
+	case "status":
+		return stripCommand(m, prefix, channelParams, nil)
+	default:
+		return usagef("unrecognised channel command: %s", args[1])
+	}
+}
+
+// The main controls of a mixer channel
+var channelParams = []stripParam{
+	{name: "fader", key: "matrix/fader", unit: "dB", fader: true},
+	{name: "mute", key: "matrix/mute", toggle: true},
+	{name: "solo", key: "matrix/solo", toggle: true},
+	{name: "pan", key: "matrix/pan"},
+}
+
+// Parameters of an aux bus, relative to mix/aux/<index>
+var auxParams = []stripParam{
+	{name: "fader", key: "matrix/fader", unit: "dB", fader: true},
+	{name: "mute", key: "matrix/mute", toggle: true},
+}
+
+// sendCommand prints or sets the level of one of a channel's sends
+func sendCommand(m *motu.Client, prefix string, args []string) error {
+	if len(args) < 1 {
+		return usagef(chanUsage)
+	}
+
+	var param stripParam
+	switch args[0] {
+	case "reverb":
+		param = stripParam{name: "reverb", key: "matrix/reverb/0/send", unit: "dB", fader: true}
+		args = args[1:]
+	case "aux":
+		if len(args) < 2 {
+			return usagef("usage: chan <index> send aux <aux> [<dB>]")
+		}
+		aux, err := strconv.Atoi(args[1])
+		if err != nil || aux < 0 {
+			return usagef("invalid aux index: %s", args[1])
+		}
+		param = stripParam{name: "aux", key: fmt.Sprintf("matrix/aux/%d/send", aux), unit: "dB", fader: true}
+		args = args[2:]
+	default:
+		return usagef("unknown send: %s", args[0])
+	}
+
+	return stripCommand(m, prefix, []stripParam{param}, append([]string{param.name}, args...))
+}
+
+// panCommand prints or sets a channel's pan, from -100 (left) to 100
+// (right). inc and dec move it right and left by panStep.
+func panCommand(m *motu.Client, prefix string, args []string) error {
+	property := prefix + "/matrix/pan"
+
+	current, err := m.Get(property)
+	if err != nil {
+		return fmt.Errorf("failed to get current pan: %w", err)
+	}
+
+	if len(args) == 0 {
+		return printResult(map[string]float64{"pan": math.Round(current * 100)}, formatPan(current))
+	}
+
+	var pan float64
+	switch args[0] {
+	case "inc":
+		pan = current*100 + panStep
+	case "dec":
+		pan = current*100 - panStep
+	default:
+		pan, err = strconv.ParseFloat(args[0], 64)
+		if err != nil {
+			return usagef("invalid pan: %s", args[0])
+		}
+	}
+
+	// The datastore holds pan from -1 to 1
+	v := math.Min(math.Max(pan, -100), 100) / 100
+	if err := m.Set(property, v); err != nil {
+		return err
+	}
+
+	return printResult(map[string]float64{"pan": math.Round(v * 100)}, formatPan(v))
+}
+
+func formatPan(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100), 'f', -1, 64)
+}
+
+// auxCommand controls the master of an aux bus
+func auxCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: aux <index> [<param> [<value>]]")
+	}
+
+	index, err := strconv.Atoi(args[0])
+	if err != nil || index < 0 {
+		return usagef("invalid aux index: %s", args[0])
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return stripCommand(m, fmt.Sprintf("datastore/mix/aux/%d", index), auxParams, args[1:])
+}
+
+// reverbCommand controls the reverb bus
+func reverbCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return stripCommand(m, "datastore/mix/reverb/0", reverbParams, args)
+}
+
+// stripCommand prints every parameter with no arguments, prints one
+// parameter given its name, or sets it given a name and a value
+func stripCommand(m *motu.Client, prefix string, params []stripParam, args []string) error {
+	if len(args) == 0 {
+		values := map[string]any{}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		for _, p := range params {
+			v, err := m.Value(prefix + "/" + p.key)
+			if err != nil {
+				return fmt.Errorf("failed to get %s: %w", p.name, err)
+			}
+			values[p.name] = paramJSON(p, v)
+			fmt.Fprintf(w, "%s\t%s\n", p.name, formatParam(p, v))
+		}
+		if jsonOutput {
+			return printJSON(values)
+		}
+		return w.Flush()
+	}
+
+	var param *stripParam
+	for i := range params {
+		if params[i].name == args[0] {
+			param = &params[i]
+		}
+	}
+	if param == nil {
+		return usagef("unknown parameter: %s", args[0])
+	}
+	property := prefix + "/" + param.key
+
+	if len(args) == 1 {
+		v, err := m.Value(property)
+		if err != nil {
+			return err
+		}
+		return printResult(map[string]any{param.name: paramJSON(*param, v)}, formatParam(*param, v))
+	}
+
+	v, err := parseParam(*param, args[1])
+	if err != nil {
+		return err
+	}
+
+	if err := m.Require(property); err != nil {
+		return err
+	}
+
+	if err := m.Set(property, v); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(map[string]any{param.name: paramJSON(*param, v)})
+	}
+	return nil
+}
+
+func formatParam(p stripParam, v any) string {
+	f, ok := toFloat(v)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+
+	switch {
+	case p.toggle:
+		if f != 0 {
+			return "on"
+		}
+		return "off"
+	case p.fader:
+		return formatDB(motu.AmplitudeToDB(f))
+	case p.name == "pan":
+		return formatPan(f)
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64) + p.unit
+}
+
+// paramJSON converts a parameter's value to how it's given on the
+// command line: switches as booleans, faders in dB (or null at zero
+// volume) and pan from -100 to 100
+func paramJSON(p stripParam, v any) any {
+	f, ok := toFloat(v)
+	if !ok {
+		return v
+	}
+
+	switch {
+	case p.toggle:
+		return f != 0
+	case p.fader:
+		if db := motu.AmplitudeToDB(f); !math.IsInf(db, 0) {
+			return db
+		}
+		return nil
+	case p.name == "pan":
+		return math.Round(f * 100)
+	}
+
+	return f
+}
+
+// parseParam parses a parameter's new value. Switches
+// accept on and off as well as 1 and 0.
+func parseParam(p stripParam, s string) (float64, error) {
+	if p.toggle {
+		switch s {
+		case "on", "true":
+			return 1, nil
+		case "off", "false":
+			return 0, nil
+		}
+	}
+
+	f, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(s), strings.ToLower(p.unit)), 64)
+	if err != nil {
+		return 0, usagef("invalid value for %s: %s", p.name, s)
+	}
+
+	if p.fader {
+		return motu.DBToAmplitude(f), nil
+	}
+
+	return f, nil
+}
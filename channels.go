@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// channelsCommand lists the mixer and output channels with
+// their names and current state, to help work out which
+// index is which when writing the config file
+func channelsCommand() error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	tree, err := m.GetTree("datastore")
+	if err != nil {
+		return fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	channels := motu.ChannelsFromTree(tree)
+	if jsonOutput {
+		return printJSON(channelsJSON(channels, tree))
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "PATH\tNAME\tDEFAULT NAME\tLEVEL\tMUTED\tPAN\n")
+
+	for _, ch := range channels {
+		level := "-"
+		if v, ok := toFloat(tree[motu.Key(ch.Property)]); ok {
+			d := &motu.Device{Scale: ch.Scale}
+			level = formatDB(d.ToDB(v))
+		}
+
+		muted := "-"
+		if v, ok := toFloat(tree[motu.Key(ch.MuteProperty)]); ok && ch.MuteProperty != "" {
+			muted = fmt.Sprint(v == 1)
+		}
+
+		pan := "-"
+		if v, ok := toFloat(tree[ch.Path+"/matrix/pan"]); ok {
+			pan = formatPan(v)
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", ch.Path, ch.Name, ch.DefaultName, level, muted, pan)
+	}
+
+	return w.Flush()
+}
+
+// channelJSON is the JSON representation of a channel. Fields
+// are null where the channel doesn't have the property.
+type channelJSON struct {
+	Path        string   `json:"path"`
+	Name        string   `json:"name"`
+	DefaultName string   `json:"default_name"`
+	LevelDB     *float64 `json:"level_db"`
+	Muted       *bool    `json:"muted"`
+	Pan         *float64 `json:"pan"`
+}
+
+func channelsJSON(channels []*motu.Channel, tree map[string]any) []*channelJSON {
+	result := make([]*channelJSON, 0, len(channels))
+	for _, ch := range channels {
+		c := &channelJSON{Path: ch.Path, Name: ch.Name, DefaultName: ch.DefaultName}
+
+		if v, ok := toFloat(tree[motu.Key(ch.Property)]); ok {
+			d := &motu.Device{Scale: ch.Scale}
+			if db := d.ToDB(v); !math.IsInf(db, 0) {
+				c.LevelDB = &db
+			}
+		}
+		if v, ok := toFloat(tree[motu.Key(ch.MuteProperty)]); ok && ch.MuteProperty != "" {
+			muted := v == 1
+			c.Muted = &muted
+		}
+		if v, ok := toFloat(tree[ch.Path+"/matrix/pan"]); ok {
+			c.Pan = &v
+		}
+
+		result = append(result, c)
+	}
+	return result
+}
+
+// Ranges used for devices made from channels. Faders are logarithmic
+// and go up to unity. Output trims are in dB.
+const (
+	channelFaderMin      = -64
+	channelTrimMin       = -50
+	channelTrimZeroLevel = -127
+)
+
+// addChannelDevices adds a device for every mixer and output channel,
+// named as it is in the web UI, so that channels can be controlled by
+// name without configuring them. Devices in the config file and
+// channels found earlier keep their names.
+func addChannelDevices(m *motu.Client, cfg *Config) error {
+	channels, err := m.Channels()
+	if err != nil {
+		return fmt.Errorf("failed to list channels: %w", err)
+	}
+
+	for _, ch := range channels {
+		name := ch.DisplayName()
+		if _, ok := cfg.Devices[name]; ok || name == "" {
+			continue
+		}
+
+		d := &motu.Device{
+			Property:     ch.Property,
+			MuteProperty: ch.MuteProperty,
+			Scale:        ch.Scale,
+			Max:          0,
+			Steps:        cfg.Steps,
+		}
+
+		if ch.Scale == motu.ScaleLog {
+			d.Min = channelFaderMin
+		} else {
+			d.Min = channelTrimMin
+			d.ZeroVolume = channelTrimZeroLevel
+		}
+
+		cfg.Devices[name] = d
+	}
+
+	return nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// ClipConfig configures what happens when a channel clips
+type ClipConfig struct {
+	// Which meter banks to watch: input, output or mix.
+	// Defaults to all of them.
+	Banks []string `yaml:"banks"`
+
+	// How many consecutive samples at 0 dBFS count as a clip
+	Samples int `yaml:"samples"`
+
+	// How often to read the meters
+	Interval time.Duration `yaml:"interval"`
+
+	// Show a desktop notification
+	Notify bool `yaml:"notify"`
+
+	// Sound file to play
+	Sound string `yaml:"sound"`
+
+	// URL to POST details of the clip to, as JSON
+	Webhook string `yaml:"webhook"`
+}
+
+const (
+	defaultClipSamples  = 3
+	defaultClipInterval = 50 * time.Millisecond
+)
+
+func clipCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	clipCfg := cfg.Clip
+	if clipCfg == nil {
+		// With no config, just print clips as they happen
+		clipCfg = &ClipConfig{}
+	}
+	if clipCfg.Samples == 0 {
+		clipCfg.Samples = defaultClipSamples
+	}
+	if clipCfg.Interval == 0 {
+		clipCfg.Interval = defaultClipInterval
+	}
+
+	var banks []string
+	for _, b := range clipCfg.Banks {
+		name, ok := meterBanks[b]
+		if !ok {
+			return usagef("unknown meter bank: %s", b)
+		}
+		banks = append(banks, name)
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	slog.Info("Watching for clipping")
+
+	return m.WatchClips(ctx, banks, clipCfg.Samples, clipCfg.Interval, func(c motu.Clip) {
+		message := fmt.Sprintf("%s channel %d clipped at %s", c.Bank, c.Channel, formatDB(motu.AmplitudeToDB(c.Level)))
+		slog.Warn("Clipped", "bank", c.Bank, "channel", c.Channel, "level_db", motu.AmplitudeToDB(c.Level))
+
+		// Run the actions in the background so that
+		// the meters keep being read while they happen
+		go clipActions(clipCfg, c, message)
+
+		if cfg.Hooks != nil {
+			cfg.Hooks.clip(c)
+		}
+	})
+}
+
+func clipActions(cfg *ClipConfig, c motu.Clip, message string) {
+	if cfg.Notify {
+		if err := notify("MOTU", message); err != nil {
+			slog.Error("Failed to show notification", "err", err)
+		}
+	}
+
+	if cfg.Sound != "" {
+		if err := playSoundFile(cfg.Sound); err != nil {
+			slog.Error("Failed to play sound", "err", err)
+		}
+	}
+
+	if cfg.Webhook != "" {
+		if err := postClip(cfg.Webhook, c); err != nil {
+			slog.Error("Failed to call webhook", "url", cfg.Webhook, "err", err)
+		}
+	}
+}
+
+func postClip(url string, c motu.Clip) error {
+	body, err := json.Marshal(map[string]any{
+		"bank":     c.Bank,
+		"channel":  c.Channel,
+		"level_db": motu.AmplitudeToDB(c.Level),
+		"time":     c.Time,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal body: %w", err)
+	}
+
+	client := &http.Client{Timeout: 5 * time.Second}
+	rsp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status: %s", rsp.Status)
+	}
+
+	return nil
+}
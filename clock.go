@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const (
+	clockSourceProperty = "datastore/ext/clockSource"
+	sampleRateProperty  = "datastore/ext/sampleRate"
+
+	clockUsage = "usage: clock status | clock set rate <Hz> | clock set source <source> [--yes]"
+)
+
+// clockCommand shows and changes the sample rate and clock source
+func clockCommand(args []string) error {
+	flags := flag.NewFlagSet("clock", flag.ContinueOnError)
+	yes := flags.Bool("yes", false, "don't ask for confirmation")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) < 1 {
+		return usagef(clockUsage)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch positional[0] {
+	case "status":
+		return clockStatus(m)
+
+	case "set":
+		if len(positional) != 3 {
+			return usagef(clockUsage)
+		}
+
+		var property string
+		var value any
+		switch positional[1] {
+		case "rate":
+			rate, err := strconv.Atoi(positional[2])
+			if err != nil || rate <= 0 {
+				return usagef("invalid sample rate: %s", positional[2])
+			}
+			property, value = sampleRateProperty, rate
+		case "source":
+			property, value = clockSourceProperty, parseRawValue(positional[2])
+		default:
+			return usagef(clockUsage)
+		}
+
+		if err := m.Require(property); err != nil {
+			return err
+		}
+
+		if !*yes && !confirm("Changing the clock interrupts audio. Continue?") {
+			return fmt.Errorf("cancelled")
+		}
+
+		if err := m.SetValue(property, value); err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return clockStatus(m)
+		}
+		return nil
+
+	default:
+		return usagef("unrecognised clock command: %s", positional[0])
+	}
+}
+
+// clockStatus prints the sample rate and clock source
+func clockStatus(m *motu.Client) error {
+	rate, err := m.Value(sampleRateProperty)
+	if err != nil {
+		return fmt.Errorf("failed to get sample rate: %w", err)
+	}
+
+	source, err := m.Value(clockSourceProperty)
+	if err != nil {
+		return fmt.Errorf("failed to get clock source: %w", err)
+	}
+
+	if jsonOutput {
+		return printJSON(map[string]any{"sample_rate": rate, "clock_source": source})
+	}
+
+	fmt.Printf("Sample rate:  %v Hz\n", rate)
+	fmt.Printf("Clock source: %v\n", source)
+	return nil
+}
+
+// confirm asks a yes/no question on the terminal
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N] ", question)
+
+	answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(answer)) {
+	case "y", "yes":
+		return true
+	default:
+		return false
+	}
+}
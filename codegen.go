@@ -0,0 +1,397 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"go/format"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// genNode is a key in the datastore, or a segment of some keys
+type genNode struct {
+	children map[string]*genNode
+
+	// The type of a leaf's value, as a motu property type, e.g.
+	// "FloatProperty". Empty for a node with children.
+	kind string
+
+	// The children that are indexes, e.g. channel numbers, merged into
+	// one, and whether the indexes are "int" or "string", e.g. UIDs
+	index string
+	elem  *genNode
+}
+
+// Properties that hold fractions even when a dump
+// happens to have whole numbers for all of them
+var fractionalProperties = map[string]bool{
+	"fader": true, "pan": true, "send": true, "gain": true, "freq": true,
+	"bw": true, "threshold": true, "ratio": true, "attack": true,
+	"release": true, "makeup": true, "width": true,
+}
+
+// Initialisms, which Go names keep in one case
+var initialisms = map[string]string{
+	"uid": "UID", "avb": "AVB", "id": "ID", "ip": "IP", "url": "URL",
+	"eq": "EQ", "midi": "MIDI", "usb": "USB", "adat": "ADAT",
+	"spdif": "SPDIF", "mac": "MAC", "http": "HTTP", "ui": "UI",
+}
+
+// codegenCommand writes Go code with a typed accessor for every
+// property in a datastore dump, e.g. Mix().Chan(10).Matrix().Fader()
+func codegenCommand(args []string) error {
+	flags := flag.NewFlagSet("codegen", flag.ContinueOnError)
+	pkg := flags.String("package", "datastore", "the generated package's name")
+	out := flags.String("out", "", "the file to write, instead of printing the code")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: codegen <dump.json|-> [--package name] [--out file]")
+	}
+
+	var b []byte
+	if positional[0] == "-" {
+		b, err = io.ReadAll(os.Stdin)
+	} else {
+		b, err = os.ReadFile(positional[0])
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read dump: %w", err)
+	}
+
+	values, err := parseDump(b)
+	if err != nil {
+		return err
+	}
+
+	code, err := generate(*pkg, values)
+	if err != nil {
+		return err
+	}
+
+	if *out == "" {
+		_, err = os.Stdout.Write(code)
+		return err
+	}
+	if err := os.WriteFile(*out, code, 0o644); err != nil {
+		return fmt.Errorf("failed to write code: %w", err)
+	}
+	return printResult(map[string]any{"file": *out, "properties": len(values)},
+		fmt.Sprintf("Wrote accessors for %d properties to %s", len(values), *out))
+}
+
+// parseDump reads the output of "dump --json", or a snapshot file
+func parseDump(b []byte) (map[string]any, error) {
+	var values map[string]any
+	if err := json.Unmarshal(b, &values); err != nil {
+		return nil, fmt.Errorf("invalid dump: %w", err)
+	}
+
+	if inner, ok := values["values"].(map[string]any); ok {
+		values = inner
+	}
+	if len(values) == 0 {
+		return nil, fmt.Errorf("the dump has no properties")
+	}
+	return values, nil
+}
+
+// generate returns the formatted source of a package with
+// an accessor for each of the properties
+func generate(pkg string, values map[string]any) ([]byte, error) {
+	root := &genNode{children: map[string]*genNode{}}
+	for key, v := range values {
+		root.insert(strings.Split(motu.Key(key), "/"), v)
+	}
+	root.collapse()
+
+	g := &generator{names: map[string]bool{}}
+	for _, seg := range sortedKeys(root.children) {
+		g.accessor("", "", seg, root.children[seg], nil)
+	}
+	for len(g.pending) > 0 {
+		t := g.pending[0]
+		g.pending = g.pending[1:]
+		g.nodeType(t)
+	}
+
+	var src bytes.Buffer
+	fmt.Fprintf(&src, "// Code generated by motu codegen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "// Package %s has typed accessors for the properties\n// of a MOTU interface's datastore.\n", pkg)
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", pkg)
+	if g.strconv {
+		fmt.Fprintf(&src, "\t\"strconv\"\n\n")
+	}
+	fmt.Fprintf(&src, "\t\"github.com/jakewright/motu-tools/motu\"\n)\n\n")
+	src.Write(g.buf.Bytes())
+
+	code, err := format.Source(src.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return code, nil
+}
+
+// insert adds the key made of segs, with the given value
+func (n *genNode) insert(segs []string, v any) {
+	if len(segs) == 0 {
+		n.kind = mergeKinds(n.kind, valueKind(v))
+		return
+	}
+
+	child, ok := n.children[segs[0]]
+	if !ok {
+		child = &genNode{children: map[string]*genNode{}}
+		n.children[segs[0]] = child
+	}
+	child.insert(segs[1:], v)
+}
+
+// collapse merges the children that are indexes, e.g. channel
+// numbers or UIDs, into one, as they all have the same properties
+func (n *genNode) collapse() {
+	if len(n.children) > 0 {
+		// A key can't have a value and children
+		n.kind = ""
+	}
+
+	numbers := true
+	elem := &genNode{children: map[string]*genNode{}}
+	for _, k := range sortedKeys(n.children) {
+		if _, err := strconv.Atoi(k); err != nil {
+			if !isID(k) {
+				continue
+			}
+			numbers = false
+		}
+		elem.merge(n.children[k])
+		delete(n.children, k)
+		n.elem = elem
+	}
+
+	if n.elem != nil {
+		n.index = "string"
+		if numbers {
+			n.index = "int"
+		}
+		n.elem.collapse()
+	}
+
+	for _, child := range n.children {
+		child.collapse()
+	}
+}
+
+// merge adds everything under o to n
+func (n *genNode) merge(o *genNode) {
+	n.kind = mergeKinds(n.kind, o.kind)
+	for k, oc := range o.children {
+		c, ok := n.children[k]
+		if !ok {
+			c = &genNode{children: map[string]*genNode{}}
+			n.children[k] = c
+		}
+		c.merge(oc)
+	}
+}
+
+// isID returns whether a key segment looks like a UID, which
+// the datastore uses to index AVB devices and streams
+func isID(seg string) bool {
+	if len(seg) < 8 {
+		return false
+	}
+	for _, r := range seg {
+		if !unicode.Is(unicode.ASCII_Hex_Digit, r) {
+			return false
+		}
+	}
+	return true
+}
+
+// valueKind returns the property type for a value
+func valueKind(v any) string {
+	switch v := v.(type) {
+	case string:
+		return "StringProperty"
+	case float64:
+		if v != math.Trunc(v) {
+			return "FloatProperty"
+		}
+		return "IntProperty"
+	default:
+		return "ValueProperty"
+	}
+}
+
+// mergeKinds returns the type that can hold values of both types.
+// A property that's a whole number in one place and a fraction in
+// another is a fraction.
+func mergeKinds(a, b string) string {
+	switch {
+	case a == "" || a == b:
+		return b
+	case b == "":
+		return a
+	case a == "FloatProperty" && b == "IntProperty", a == "IntProperty" && b == "FloatProperty":
+		return "FloatProperty"
+	default:
+		return "ValueProperty"
+	}
+}
+
+// goName turns a key segment into an exported Go name,
+// e.g. "stereoTrim" into "StereoTrim" and "uid" into "UID"
+func goName(seg string) string {
+	parts := strings.FieldsFunc(seg, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, p := range parts {
+		if s, ok := initialisms[strings.ToLower(p)]; ok {
+			sb.WriteString(s)
+			continue
+		}
+		sb.WriteString(strings.ToUpper(p[:1]) + p[1:])
+	}
+
+	name := sb.String()
+	if name == "" || unicode.IsDigit(rune(name[0])) {
+		name = "X" + name
+	}
+	return name
+}
+
+type generator struct {
+	buf bytes.Buffer
+
+	// Type names already used
+	names map[string]bool
+
+	// Node types still to be written
+	pending []*genType
+
+	// Whether the code uses strconv, for int indexes
+	strconv bool
+}
+
+// genType is a struct type for a node with children
+type genType struct {
+	name string
+	node *genNode
+
+	// The key segments that lead to the node, with * for indexes
+	pattern []string
+}
+
+// typeName returns a unique type name for the node at pattern
+func (g *generator) typeName(pattern []string) string {
+	var sb strings.Builder
+	for _, seg := range pattern {
+		if seg != "*" {
+			sb.WriteString(goName(seg))
+		}
+	}
+	base := sb.String() + "Node"
+	if g.names[base] {
+		// The items of a node that also has named children
+		base = strings.TrimSuffix(base, "Node") + "ItemNode"
+	}
+
+	name := base
+	for i := 2; g.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	g.names[name] = true
+	return name
+}
+
+// accessor writes a method of recv, or a function if recv is empty,
+// that returns the child seg of the node at pattern. A child that only
+// has indexes, e.g. "mix/chan", is skipped over by taking the index as
+// an argument.
+func (g *generator) accessor(recv, recvType, seg string, child *genNode, pattern []string) {
+	name := goName(seg)
+	if name == "Path" {
+		name = "PathProperty"
+	}
+
+	pattern = append(append([]string(nil), pattern...), seg)
+	if child.elem != nil && len(child.children) == 0 {
+		g.method(recv, recvType, name, seg, "/"+seg+"/", child.index, child.elem, append(pattern, "*"))
+	} else {
+		g.method(recv, recvType, name, seg, "/"+seg, "", child, pattern)
+	}
+}
+
+// method writes a method or function called name that returns
+// the node at pattern, whose path is the receiver's path followed
+// by suffix and the index, if there is one
+func (g *generator) method(recv, recvType, name, seg, suffix, index string, node *genNode, pattern []string) {
+	path := strconv.Quote("datastore" + suffix)
+	if recv != "" {
+		path = recv + ".path + " + strconv.Quote(suffix)
+	}
+
+	var param string
+	switch index {
+	case "int":
+		param = "i int"
+		path += " + strconv.Itoa(i)"
+		g.strconv = true
+	case "string":
+		param = "id string"
+		path += " + id"
+	}
+
+	var result, body string
+	if node.kind != "" {
+		kind := node.kind
+		if kind == "IntProperty" && fractionalProperties[seg] {
+			kind = "FloatProperty"
+		}
+		result = "motu." + kind
+		body = fmt.Sprintf("%s(%s)", result, path)
+	} else {
+		t := &genType{name: g.typeName(pattern), node: node, pattern: pattern}
+		g.pending = append(g.pending, t)
+		result = t.name
+		body = fmt.Sprintf("%s{path: %s}", result, path)
+	}
+
+	fmt.Fprintf(&g.buf, "// %s is %s\n", name, strings.Join(pattern, "/"))
+	if recv == "" {
+		fmt.Fprintf(&g.buf, "func %s(%s) %s {\n\treturn %s\n}\n\n", name, param, result, body)
+	} else {
+		fmt.Fprintf(&g.buf, "func (%s %s) %s(%s) %s {\n\treturn %s\n}\n\n", recv, recvType, name, param, result, body)
+	}
+}
+
+// nodeType writes the type for a node with children, and its
+// methods. Indexes alongside named children are reached with At.
+func (g *generator) nodeType(t *genType) {
+	fmt.Fprintf(&g.buf, "// %s is %s\n", t.name, strings.Join(t.pattern, "/"))
+	fmt.Fprintf(&g.buf, "type %s struct {\n\tpath string\n}\n\n", t.name)
+	fmt.Fprintf(&g.buf, "// Path returns the path of %s, e.g. for GetTree\n", strings.Join(t.pattern, "/"))
+	fmt.Fprintf(&g.buf, "func (n %s) Path() string {\n\treturn n.path\n}\n\n", t.name)
+
+	for _, seg := range sortedKeys(t.node.children) {
+		g.accessor("n", t.name, seg, t.node.children[seg], t.pattern)
+	}
+
+	if t.node.elem != nil {
+		seg := t.pattern[len(t.pattern)-1]
+		g.method("n", t.name, "At", seg, "/", t.node.index, t.node.elem, append(append([]string(nil), t.pattern...), "*"))
+	}
+}
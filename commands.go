@@ -0,0 +1,190 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// command is something that can be run as "motu <name> [args]"
+type command struct {
+	name string
+
+	// The arguments it takes, for the usage line
+	args string
+
+	summary string
+	run     func(args []string) error
+}
+
+// commands returns every command in the order they're listed in the
+// help. Anything else is treated as the name of a device.
+func commands() []*command {
+	return []*command{
+		{"status", "[--json]", "Print the state of every device", func(args []string) error { return statusCommand("", args) }},
+		{"info", "", "Print the interface's name, model and firmware version", noArgs(infoCommand)},
+		{"ping", "[-c 10] [-i 200ms]", "Time requests to the interface", pingCommand},
+		{"discover", "", "Find interfaces on the network", noArgs(discover)},
+		{"profile", "[--refresh]", "Print the built-in devices for the interface's model", profileCommand},
+		{"scan", "<subnet> [--port 80] [--timeout 1s]", "Probe a subnet for interfaces, where discover can't find them", scanCommand},
+		{"channels", "", "List mixer and output channels with their names", noArgs(channelsCommand)},
+		{"avb", "list", "List the AVB devices the interface can see, with their streams", avbCommand},
+		{"mono", "[on|off|toggle]", "Switch the main mix between mono and stereo", monoCommand},
+		{"speakers", "a|b|toggle", "Switch between speaker sets", speakersCommand},
+		{"talkback", "on|off|push", "Open talkback, or hold it open until Ctrl-C with push", talkbackCommand},
+		{"phones", "source [<name>]", "Print or change what the headphones listen to", phonesCommand},
+		{"mute-all", "[--mix]", "Mute every device, and every mixer channel with --mix", muteAll},
+		{"unmute-all", "", "Put back the mute states from before mute-all", noArgs(unmuteAll)},
+		{"sleep", "<duration> [--fade 5m]", "Fade out and mute when the timer runs out", sleepCommand},
+		{"chan", "<index> status | comp|gate [<param> [<value>]] | send reverb|aux <aux> [<dB>] | pan [<-100..100>|inc|dec]", "Control a mixer channel strip", chanCommand},
+		{"aux", "<index> [<param> [<value>]]", "Control an aux bus", auxCommand},
+		{"reverb", "[<param> [<value>]]", "Control the reverb", reverbCommand},
+		{"gain", "<input> [inc|dec|set <dB>]", "Print or change an input's gain", gainCommand},
+		{"invert", "<input> [on|off|toggle]", "Invert an input's phase", invertCommand},
+		{"link", "input|output <bank>/<channel>", "Link a channel with the next as a stereo pair", func(args []string) error { return linkCommand(true, args) }},
+		{"unlink", "input|output <bank>/<channel>", "Split a stereo pair", func(args []string) error { return linkCommand(false, args) }},
+		{"route", "list | set <input bank/channel|none> <output bank/channel>", "List or change the routing", routeCommand},
+		{"out", "list | <bank>/<channel> trim [inc|dec|set <dB>] [--step 1] [--stereo]", "List outputs or change their trim", outCommand},
+		{"clock", "status | set rate <Hz> | set source <source> [--yes]", "Print or change the sample rate and clock source", clockCommand},
+		{"meters", "[--bank input,output,mix] [--interval 100ms] [--once]", "Show live meter levels", metersCommand},
+		{"clip", "", "Watch the meters and report clipping", clipCommand},
+		{"watch", "[prefix] [--json]", "Print datastore changes as they happen", watchCommand},
+		{"raw", "get <path> [--json] | set <path> <value> [--json|--string] [--force]", "Print or set any datastore property", rawCommand},
+		{"dump", "[prefix] [--json]", "Print every property under a path, e.g. \"mix/chan\"", func(args []string) error { return dumpCommand(false, args) }},
+		{"find", "<regex> [--json]", "Print every property whose path or value matches", func(args []string) error { return dumpCommand(true, args) }},
+		{"snapshot", "save <file> [prefix] | restore <file> [--include glob,...] [--exclude glob,...] | diff <a> <b> | diff --live <file> | log [name] | checkout <rev> [name]", "Save the datastore to a file, restore it, compare or version it", snapshot},
+		{"codegen", "<dump.json|-> [--package name] [--out file]", "Generate typed Go accessors from a datastore dump", codegenCommand},
+		{"scene", "list | recall <name> [--fade 2s] | save <name> <device|property>...", "Save and recall scenes", scene},
+		{"sync", "<src> <dst> [--paths prefix,...]", "Copy one target's settings to another", syncCommand},
+		{"repl", "", "Run commands at a prompt, with the state of every device shown above it", noArgs(replCommand)},
+		{"batch", "<file|-> [--continue]", "Run commands from a file or stdin, one per line, over one connection", batchCommand},
+		{"history", "", "List recent changes made by commands", noArgs(historyCommand)},
+		{"undo", "", "Revert the changes made by the last command", noArgs(func() error { return undoCommand(false) })},
+		{"redo", "", "Make the last undone changes again", noArgs(func() error { return undoCommand(true) })},
+		{"serve", "[--listen 127.0.0.1:4747] [--grpc 127.0.0.1:4749]", "Run the daemon, with an HTTP API", serve},
+		{"midi", "[--list] [--in <port>] [--out <port>]", "Control the interface from a MIDI controller", midiCommand},
+		{"osc", "[--listen <address>] [--feedback <addresses>]", "Control the interface with OSC", oscCommand},
+		{"mqtt", "", "Bridge the devices to an MQTT broker", mqttCommand},
+		{"homekit", "", "Expose the devices to HomeKit", homekitCommand},
+		{"streamdeck", "[--listen <address>]", "Serve the Stream Deck plugin", streamDeckCommand},
+		{"hotkeys", "", "Run commands when global hotkeys are pressed", noArgs(hotkeysCommand)},
+		{"service", "install [--listen 127.0.0.1:4747] [--grpc 127.0.0.1:4749] | uninstall | status", "Run the daemon at login", serviceCommand},
+		{"completion", "bash|zsh|fish", "Print a shell completion script", func(args []string) error { return completionCommand(append([]string{"completion"}, args...)) }},
+		{"help", "[<command>]", "Print help for a command", helpCommand},
+	}
+}
+
+// deviceVerbs are the commands that can be run against a
+// device, as "motu <device> <verb> [args]"
+var deviceVerbs = []*command{
+	{name: "inc", args: "[--step <dB>] [--fine] [--quiet]", summary: "Increase the level by one step"},
+	{name: "dec", args: "[--step <dB>] [--fine] [--quiet]", summary: "Decrease the level by one step"},
+	{name: "set", args: "<dB|percent%> [--quiet]", summary: "Set the level in dB, or as a percentage of the device's range"},
+	{name: "fade", args: "<dB|percent%> [--over 5s]", summary: "Ramp smoothly to a level"},
+	{name: "mute", args: "[on|off|toggle]", summary: "Mute or unmute, toggling by default"},
+	{name: "dim", args: "[on|off|toggle]", summary: "Dim or undim, toggling by default"},
+	{name: "status", args: "[--json]", summary: "Print the level and mute state"},
+}
+
+// Other names that device commands can be given
+var verbAliases = map[string]string{
+	"increment": "inc",
+	"decrement": "dec",
+}
+
+// findVerb returns the device command with the given name, or nil
+func findVerb(name string) *command {
+	if alias, ok := verbAliases[name]; ok {
+		name = alias
+	}
+
+	for _, v := range deviceVerbs {
+		if v.name == name {
+			return v
+		}
+	}
+	return nil
+}
+
+// noArgs adapts a command that doesn't take any arguments
+func noArgs(run func() error) func([]string) error {
+	return func(args []string) error {
+		if len(args) > 0 {
+			return usagef("unexpected arguments: %s", strings.Join(args, " "))
+		}
+		return run()
+	}
+}
+
+// findCommand returns the command with the given name, or nil
+func findCommand(name string) *command {
+	for _, c := range commands() {
+		if c.name == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// wantsHelp returns whether the arguments ask for help
+func wantsHelp(args []string) bool {
+	for _, a := range args {
+		if a == "-h" || a == "-help" || a == "--help" {
+			return true
+		}
+	}
+	return false
+}
+
+// helpCommand prints the help for a command, or an
+// overview of every command if none is given
+func helpCommand(args []string) error {
+	if len(args) > 0 {
+		if c := findCommand(args[0]); c != nil {
+			fmt.Printf("Usage: motu %s %s\n\n%s\n", c.name, c.args, c.summary)
+			return nil
+		}
+		if v := findVerb(args[0]); v != nil {
+			fmt.Printf("Usage: motu <device> %s %s\n\n%s\n", v.name, v.args, v.summary)
+			return nil
+		}
+		return usagef("unknown command: %s", args[0])
+	}
+
+	fmt.Print(`Usage: motu [global flags] <device> <command> [arguments]
+       motu [global flags] <command> [arguments]
+
+`)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(w, "Device commands:")
+	for _, v := range deviceVerbs {
+		fmt.Fprintf(w, "  %s\t%s\n", v.name, v.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands() {
+		fmt.Fprintf(w, "  %s\t%s\n", c.name, c.summary)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Global flags:")
+	fmt.Fprintln(w, "  --config <file>\tThe config file to use ($MOTU_CONFIG)")
+	fmt.Fprintln(w, "  --target <name>\tThe target or group of targets to use ($MOTU_TARGET)")
+	fmt.Fprintln(w, "  --json\tPrint the result, or the error, as JSON")
+	fmt.Fprintln(w, "  --password <password>\tThe interface's password ($MOTU_PASSWORD)")
+	fmt.Fprintln(w, "  --debug\tPrint every request to the interface ($MOTU_DEBUG)")
+	fmt.Fprintln(w, "  --log-format text|json\tHow to write logs ($MOTU_LOG_FORMAT)")
+	fmt.Fprintln(w, "  --log-level <level>\tThe least severe level to log ($MOTU_LOG_LEVEL)")
+	fmt.Fprintln(w, "  --record <file>\tRecord requests to a fixture file ($MOTU_RECORD)")
+	fmt.Fprintln(w, "  --replay <file>\tAnswer requests from a fixture file ($MOTU_REPLAY)")
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Println("\nRun \"motu help <command>\" for more about a command.")
+	return nil
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// completeCommand is the hidden command that the completion scripts
+// call with the words typed so far. It prints the candidates for the
+// last word, one per line.
+const completeCommand = "__complete"
+
+// Arguments that some commands take
+var subcommands = map[string][]string{
+	"avb":        {"list"},
+	"clock":      {"status", "set"},
+	"completion": {"bash", "zsh", "fish"},
+	"mono":       {"on", "off", "toggle"},
+	"raw":        {"get", "set"},
+	"scene":      {"list", "recall", "save"},
+	"service":    {"install", "uninstall", "status"},
+	"speakers":   {"a", "b", "toggle"},
+	"talkback":   {"push", "on", "off"},
+}
+
+// The arguments that mute and dim take
+var toggleStates = []string{"on", "off", "toggle"}
+
+// completionCommand prints a completion script for a shell, or,
+// when run as completeCommand, the candidates for the last word
+func completionCommand(args []string) error {
+	if args[0] == completeCommand {
+		for _, c := range complete(args[1:]) {
+			fmt.Println(c)
+		}
+		return nil
+	}
+
+	if len(args) != 2 {
+		return usagef("usage: completion bash|zsh|fish")
+	}
+
+	switch args[1] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return usagef("unsupported shell: %s", args[1])
+	}
+
+	return nil
+}
+
+// complete returns the candidates for the last of the words, which
+// are everything typed after "motu". Candidates come from the config
+// file, so errors reading it just mean fewer candidates.
+func complete(words []string) []string {
+	if len(words) == 0 {
+		return nil
+	}
+	current := words[len(words)-1]
+	words = words[:len(words)-1]
+
+	// Global flags can come before the command
+	selected := target
+	for len(words) > 0 && strings.HasPrefix(words[0], "-") {
+		name := strings.TrimLeft(words[0], "-")
+		if name == "debug" || name == "json" || strings.Contains(name, "=") {
+			words = words[1:]
+			continue
+		}
+		if len(words) == 1 {
+			if name == "target" {
+				return matching(targetNames(true), current)
+			}
+			return nil
+		}
+		switch name {
+		case "target":
+			selected = words[1]
+		case "config":
+			configFile = words[1]
+		}
+		words = words[2:]
+	}
+
+	var devices, aliases []string
+	if cfg, err := completionConfig(selected); err == nil {
+		for name := range cfg.Devices {
+			devices = append(devices, name)
+		}
+		for name := range cfg.Aliases {
+			aliases = append(aliases, name)
+		}
+	}
+
+	if len(words) == 0 {
+		// completion is left out so that it doesn't get
+		// in the way of devices starting with "co"
+		candidates := append(devices, aliases...)
+		for _, c := range commands() {
+			if c.name != "completion" {
+				candidates = append(candidates, c.name)
+			}
+		}
+		return matching(candidates, current)
+	}
+
+	var candidates []string
+	switch {
+	case len(words) == 1 && contains(devices, words[0]):
+		for _, v := range deviceVerbs {
+			candidates = append(candidates, v.name)
+		}
+	case len(words) == 2 && contains(devices, words[0]) && (words[1] == "mute" || words[1] == "dim"):
+		candidates = toggleStates
+	case words[0] == "sync" && len(words) <= 2:
+		candidates = targetNames(false)
+	case len(words) == 1 && words[0] == "help":
+		for _, c := range commands() {
+			candidates = append(candidates, c.name)
+		}
+	case len(words) == 1:
+		candidates = subcommands[words[0]]
+	case len(words) == 2 && words[0] == "scene" && words[1] == "recall":
+		if dir, err := scenesDir(); err == nil {
+			candidates, _ = listScenes(dir)
+		}
+	}
+
+	return matching(candidates, current)
+}
+
+// completionConfig loads the config for the selected target,
+// falling back to the top level if it's a group or unknown
+func completionConfig(selected string) (*Config, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg, err := loadConfig(path, selected); err == nil {
+		return cfg, nil
+	}
+	return loadConfig(path, "")
+}
+
+// targetNames returns the names of the targets, and
+// if groups is true, the groups that can be selected
+func targetNames(groups bool) []string {
+	cfg, err := completionConfig("")
+	if err != nil {
+		return nil
+	}
+
+	var names []string
+	for name := range cfg.Targets {
+		names = append(names, name)
+	}
+	if !groups {
+		return names
+	}
+
+	for name := range cfg.Groups {
+		names = append(names, name)
+	}
+	if len(cfg.Targets) > 0 {
+		names = append(names, allTargets)
+	}
+	return names
+}
+
+// matching returns the sorted candidates that start with prefix
+func matching(candidates []string, prefix string) []string {
+	var result []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, prefix) {
+			result = append(result, c)
+		}
+	}
+	sort.Strings(result)
+	return result
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+const bashCompletion = `# bash completion for motu
+# Add to ~/.bashrc: source <(motu completion bash)
+_motu() {
+	local IFS=$'\n'
+	COMPREPLY=($(motu __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _motu motu
+`
+
+const zshCompletion = `#compdef motu
+# Add to ~/.zshrc: source <(motu completion zsh)
+_motu() {
+	local -a candidates
+	candidates=("${(@f)$(motu __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+	compadd -a candidates
+}
+if (( $+functions[compdef] )); then
+	compdef _motu motu
+fi
+`
+
+const fishCompletion = `# fish completion for motu
+# Add to fish config: motu completion fish | source
+function __motu_complete
+	set -l words (commandline -opc) (commandline -ct)
+	motu __complete $words[2..-1] 2>/dev/null
+end
+complete -c motu -f -a '(__motu_complete)'
+`
@@ -0,0 +1,447 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Config describes the MOTU interface to talk to and the
+// devices that can be controlled on it.
+type Config struct {
+	// Network address of the MOTU interface: an IP
+	// address or host name, optionally with a port
+	Address string `yaml:"address"`
+
+	// Name or UID of an interface to find using mDNS.
+	// If set, this is used instead of Address.
+	Discover string `yaml:"discover"`
+
+	// UID of the interface at Address. If it stops answering there,
+	// e.g. because its DHCP lease changed, it's found again by UID
+	// and the new address is remembered.
+	UID string `yaml:"uid"`
+
+	// A subnet to scan for the interface with UID if
+	// mDNS doesn't find it, e.g. "192.168.88.0/24"
+	Scan string `yaml:"scan"`
+
+	// Credentials for interfaces that protect the datastore
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// How long to wait for the interface to respond to a request
+	Timeout time.Duration `yaml:"timeout"`
+
+	// How many times to retry a request when the
+	// interface can't be reached. Defaults to 2.
+	Retries *int `yaml:"retries"`
+
+	// How long to trust values cached from a previous run. Zero
+	// turns the cache off. Defaults to 10 seconds.
+	CacheMaxAge *time.Duration `yaml:"cache_max_age"`
+
+	// File to append every change to, as JSON lines. A relative
+	// path is relative to the config file. Empty turns it off.
+	AuditLog string `yaml:"audit_log"`
+
+	// How many steps between min and max. Devices
+	// that don't set their own step count use this.
+	Steps int `yaml:"steps"`
+
+	// How many dB to drop a device's level by when it is dimmed
+	Dim float64 `yaml:"dim"`
+
+	// Properties to change, and the values to set them to, when the
+	// main mix is collapsed to mono. Defaults to centring the pan
+	// of every mixer channel.
+	Mono map[string]float64 `yaml:"mono"`
+
+	// Devices that can be controlled by name. Without any, the built-in
+	// profile for the interface's model is used if there is one.
+	Devices map[string]*motu.Device `yaml:"devices"`
+
+	// The interface's model, to choose the built-in profile
+	// without asking the interface, e.g. "828es"
+	Model string `yaml:"model"`
+
+	// Input channels that can be referred to by name
+	Inputs map[string]*motu.Input `yaml:"inputs"`
+
+	// Also add a device for every mixer channel and output channel,
+	// named as in the web UI. Configured devices take precedence.
+	AutoDevices bool `yaml:"auto_devices"`
+
+	MIDI *MIDIConfig `yaml:"midi"`
+	OSC  *OSCConfig  `yaml:"osc"`
+	MQTT *MQTTConfig `yaml:"mqtt"`
+
+	HomeKit *HomeKitConfig `yaml:"homekit"`
+
+	Speakers *SpeakersConfig `yaml:"speakers"`
+	Talkback *TalkbackConfig `yaml:"talkback"`
+	Sleep    *SleepConfig    `yaml:"sleep"`
+	Phones   *PhonesConfig   `yaml:"phones"`
+
+	Hooks *HooksConfig `yaml:"hooks"`
+
+	// The sound that inc and dec play
+	Feedback *FeedbackConfig `yaml:"feedback"`
+
+	// A token and TLS for the daemon
+	Server *ServerConfig `yaml:"server"`
+
+	// Have the daemon take over the keyboard's volume keys on macOS
+	MediaKeys *MediaKeysConfig `yaml:"media_keys"`
+
+	// Have the daemon keep a device in step with the system volume
+	SystemVolume *SystemVolumeConfig `yaml:"system_volume"`
+
+	// Have the daemon mute things while the computer sleeps
+	SystemSleep *SystemSleepConfig `yaml:"system_sleep"`
+
+	// Bigger steps for bursts of presses in the daemon.
+	// Nil if each press always moves one step.
+	Acceleration *AccelerationConfig `yaml:"acceleration"`
+
+	// Names for lists of commands, run with "motu <name>"
+	Aliases map[string][]string `yaml:"aliases"`
+
+	// Commands for "motu hotkeys" to run when keys are
+	// pressed, e.g. "F12: main inc" or "ctrl+shift+m: mute-all"
+	Hotkeys map[string]string `yaml:"hotkeys"`
+
+	// Things for the daemon to do at certain times
+	Schedule []*ScheduleRule `yaml:"schedule"`
+
+	// Things for the daemon to do when properties change
+	Triggers []*Trigger `yaml:"triggers"`
+
+	Clip *ClipConfig `yaml:"clip"`
+
+	// Snapshots of the datastore for the daemon to save
+	Backups *BackupsConfig `yaml:"backups"`
+
+	// Commit scenes and snapshots to a git repository
+	// in the config file's directory when they're saved
+	Git bool `yaml:"git"`
+
+	// Another target for the daemon to copy changes from
+	Mirror *MirrorConfig `yaml:"mirror"`
+
+	// Named interfaces, selected with --target or MOTU_TARGET. Each
+	// one can set anything that can be set at the top level, such as
+	// the address and devices, and overrides the top level settings.
+	Targets map[string]yaml.Node `yaml:"targets"`
+
+	// Named lists of targets. Selecting a group, or "all" for
+	// every target, runs the command against each one at once.
+	Groups map[string][]string `yaml:"groups"`
+
+	// Whether Devices came from the config file
+	configuredDevices bool
+
+	// The built-in profile for the interface, nil if it
+	// hasn't been looked up or the config file has devices
+	profile *profileState
+}
+
+// defaultConfig is used when no config file exists
+func defaultConfig() *Config {
+	return &Config{
+		Address: "192.168.88.251",
+		Steps:   16,
+		Dim:     20,
+		Devices: map[string]*motu.Device{
+			"main": {
+				Property:     "datastore/ext/obank/1/ch/0/stereoTrim",
+				MuteProperty: "datastore/mix/main/0/matrix/mute", // 0.0 (unmuted) or 1.0 (muted)
+				Scale:        motu.ScaleLinear,
+				Max:          0,
+				Min:          -50,
+				ZeroVolume:   -127,
+			},
+			"computer": {
+				Property:     "datastore/mix/chan/10/matrix/fader",
+				MuteProperty: "datastore/mix/chan/10/matrix/mute",
+				Scale:        motu.ScaleLog,
+				Max:          0,
+				Min:          -64,
+				ZeroVolume:   0,
+			},
+		},
+	}
+}
+
+// configPath returns the location of the config file. --config or
+// MOTU_CONFIG takes precedence, otherwise it's motu/config.yaml inside
+// $XDG_CONFIG_HOME (or ~/.config if that isn't set).
+func configPath() (string, error) {
+	if configFile != "" {
+		return configFile, nil
+	}
+
+	dir := os.Getenv("XDG_CONFIG_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("failed to find home directory: %w", err)
+		}
+		dir = filepath.Join(home, ".config")
+	}
+
+	return filepath.Join(dir, "motu", "config.yaml"), nil
+}
+
+// scenesDir returns the directory that scenes are stored in,
+// which sits alongside the config file
+func scenesDir() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(path), "scenes"), nil
+}
+
+// snapshotsDir returns the directory that named snapshots are stored
+// in, which sits alongside the config file
+func snapshotsDir() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(filepath.Dir(path), "snapshots"), nil
+}
+
+// loadConfig reads the config file at path. If the file does
+// not exist, the default config is returned. If target is not
+// empty, that target's settings are applied on top.
+func loadConfig(path, target string) (*Config, error) {
+	defaults := defaultConfig()
+	cfg := &Config{}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		cfg = defaults
+	case err != nil:
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	default:
+		if err := yaml.Unmarshal(b, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+	}
+
+	if _, ok := cfg.Targets[allTargets]; ok {
+		return nil, fmt.Errorf("%q can't be used as a target name", allTargets)
+	}
+
+	for name, members := range cfg.Groups {
+		if _, ok := cfg.Targets[name]; ok || name == allTargets {
+			return nil, fmt.Errorf("group %q has the same name as a target", name)
+		}
+		for _, m := range members {
+			if _, ok := cfg.Targets[m]; !ok {
+				return nil, fmt.Errorf("group %q has unknown target %q", name, m)
+			}
+		}
+	}
+
+	if target != "" {
+		node, ok := cfg.Targets[target]
+		if !ok {
+			return nil, unknownf("unknown target: %s", target)
+		}
+
+		// A target's devices replace the top level ones rather
+		// than being merged with them
+		if hasKey(&node, "devices") {
+			cfg.Devices = nil
+		}
+
+		// Likewise, an address replaces discovery by name
+		if hasKey(&node, "address") && !hasKey(&node, "discover") {
+			cfg.Discover = ""
+		}
+
+		if err := node.Decode(cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse target %s: %w", target, err)
+		}
+	}
+
+	if cfg.Address == "" {
+		cfg.Address = defaults.Address
+	}
+	if cfg.UID != "" && cfg.Discover != "" {
+		return nil, fmt.Errorf("give either discover or uid, not both")
+	}
+	if cfg.Scan != "" {
+		if cfg.UID == "" {
+			return nil, fmt.Errorf("scan needs a uid to look for")
+		}
+		if _, err := netip.ParsePrefix(cfg.Scan); err != nil {
+			return nil, fmt.Errorf("invalid scan: %w", err)
+		}
+	}
+	if cfg.Timeout < 0 {
+		return nil, fmt.Errorf("timeout can't be negative")
+	}
+	if cfg.Retries != nil && *cfg.Retries < 0 {
+		return nil, fmt.Errorf("retries can't be negative")
+	}
+	if cfg.CacheMaxAge == nil {
+		maxAge := defaultCacheMaxAge
+		cfg.CacheMaxAge = &maxAge
+	}
+	if cfg.Steps == 0 {
+		cfg.Steps = defaults.Steps
+	}
+	if cfg.Dim == 0 {
+		cfg.Dim = defaults.Dim
+	}
+	cfg.configuredDevices = len(cfg.Devices) > 0
+	if !cfg.configuredDevices {
+		cfg.Devices = defaults.Devices
+
+		// The profile is looked up by readConfig the first time
+		if cfg.profile = cachedProfile(cfg, target); cfg.profile != nil && len(cfg.profile.Devices) > 0 {
+			cfg.Devices = cfg.profile.Devices
+		}
+	}
+
+	for name, d := range cfg.Devices {
+		if d.Steps == 0 {
+			d.Steps = cfg.Steps
+		}
+
+		if err := d.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid device %q: %w", name, err)
+		}
+	}
+
+	if cfg.Sleep == nil {
+		cfg.Sleep = &SleepConfig{}
+	}
+	if cfg.Sleep.Device == "" {
+		cfg.Sleep.Device = defaultSleepDevice
+	}
+	if cfg.Sleep.Fade == 0 {
+		cfg.Sleep.Fade = defaultSleepFade
+	}
+
+	if cfg.Feedback == nil {
+		cfg.Feedback = &FeedbackConfig{}
+	}
+	if err := cfg.Feedback.validate(); err != nil {
+		return nil, fmt.Errorf("invalid feedback: %w", err)
+	}
+
+	if cfg.Server == nil {
+		cfg.Server = &ServerConfig{}
+	}
+	if err := cfg.Server.validate(); err != nil {
+		return nil, fmt.Errorf("invalid server: %w", err)
+	}
+
+	if cfg.MediaKeys != nil {
+		if err := cfg.MediaKeys.validate(cfg.Devices); err != nil {
+			return nil, fmt.Errorf("invalid media_keys: %w", err)
+		}
+	}
+
+	if cfg.SystemVolume != nil {
+		if err := cfg.SystemVolume.validate(cfg.Devices); err != nil {
+			return nil, fmt.Errorf("invalid system_volume: %w", err)
+		}
+	}
+
+	if cfg.SystemSleep != nil {
+		if err := cfg.SystemSleep.validate(cfg.Devices); err != nil {
+			return nil, fmt.Errorf("invalid system_sleep: %w", err)
+		}
+	}
+
+	if cfg.Acceleration != nil {
+		if err := cfg.Acceleration.validate(); err != nil {
+			return nil, fmt.Errorf("invalid acceleration: %w", err)
+		}
+	}
+
+	if cfg.Speakers != nil {
+		if err := cfg.Speakers.validate(cfg.Devices); err != nil {
+			return nil, fmt.Errorf("invalid speakers: %w", err)
+		}
+	}
+
+	if cfg.Phones != nil {
+		if err := cfg.Phones.validate(); err != nil {
+			return nil, fmt.Errorf("invalid phones: %w", err)
+		}
+	}
+
+	for name, lines := range cfg.Aliases {
+		if findCommand(name) != nil {
+			return nil, fmt.Errorf("alias %q has the same name as a command", name)
+		}
+		if _, ok := cfg.Devices[name]; ok {
+			return nil, fmt.Errorf("alias %q has the same name as a device", name)
+		}
+		if len(lines) == 0 {
+			return nil, fmt.Errorf("alias %q has no commands", name)
+		}
+	}
+
+	if err := validateHotkeys(cfg.Hotkeys); err != nil {
+		return nil, fmt.Errorf("invalid hotkeys: %w", err)
+	}
+
+	for _, r := range cfg.Schedule {
+		if err := r.validate(cfg); err != nil {
+			return nil, fmt.Errorf("invalid schedule: %w", err)
+		}
+	}
+
+	if cfg.Mirror != nil {
+		if err := cfg.Mirror.validate(cfg.Targets); err != nil {
+			return nil, fmt.Errorf("invalid mirror: %w", err)
+		}
+	}
+
+	for _, t := range cfg.Triggers {
+		if err := t.validate(); err != nil {
+			return nil, fmt.Errorf("invalid trigger: %w", err)
+		}
+	}
+
+	if cfg.Backups != nil {
+		if err := cfg.Backups.validate(); err != nil {
+			return nil, fmt.Errorf("invalid backups: %w", err)
+		}
+	}
+
+	return cfg, nil
+}
+
+// hasKey returns whether a YAML mapping node has the given key
+func hasKey(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+
+	return false
+}
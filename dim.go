@@ -0,0 +1,75 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// dim drops the device's level by the configured amount, or restores
+// the level it had before it was dimmed. state is "on", "off", or
+// "toggle" (the default). The pre-dim level is kept in the state file
+// so that it survives between invocations.
+func dim(m *motu.Client, cfg *Config, name, state string) error {
+	d := cfg.Devices[name]
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	previous, dimmed := st.Dimmed[name]
+
+	var on bool
+	switch state {
+	case "", "toggle":
+		on = !dimmed
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		return usagef("usage: <device> dim [on|off|toggle]")
+	}
+
+	switch {
+	case on && !dimmed:
+		current, err := m.Get(d.Property)
+		if err != nil {
+			return fmt.Errorf("failed to get current value: %w", err)
+		}
+
+		if _, err := m.SetLevel(d, d.ToDB(current)-cfg.Dim); err != nil {
+			return err
+		}
+
+		if st.Dimmed == nil {
+			st.Dimmed = map[string]float64{}
+		}
+		st.Dimmed[name] = current
+
+	case !on && dimmed:
+		if err := m.Set(d.Property, previous); err != nil {
+			return fmt.Errorf("failed to restore level: %w", err)
+		}
+
+		delete(st.Dimmed, name)
+	}
+
+	if err := st.save(); err != nil {
+		return err
+	}
+
+	// With --json, the device's status is printed instead
+	if jsonOutput {
+		return nil
+	}
+
+	if on {
+		fmt.Println("dimmed")
+	} else {
+		fmt.Println("undimmed")
+	}
+
+	return nil
+}
@@ -0,0 +1,77 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// dumpCommand prints every value under a prefix, or with
+// find, every value whose key or value matches a regex
+func dumpCommand(find bool, args []string) error {
+	name := "dump"
+	if find {
+		name = "find"
+	}
+
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	asJSON := flags.Bool("json", jsonOutput, "print the values as a JSON object")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var prefix string
+	var re *regexp.Regexp
+	switch {
+	case find && len(positional) == 1:
+		re, err = regexp.Compile(positional[0])
+		if err != nil {
+			return fmt.Errorf("invalid regex: %w", err)
+		}
+	case find:
+		return usagef("usage: find <regex> [--json]")
+	case len(positional) == 1:
+		prefix = positional[0]
+	case len(positional) > 1:
+		return usagef("usage: dump [prefix] [--json]")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	tree, err := m.GetTree(motu.Path(prefix))
+	if err != nil {
+		return fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	if re != nil {
+		for k, v := range tree {
+			if !re.MatchString(k) && !re.MatchString(fmt.Sprint(v)) {
+				delete(tree, k)
+			}
+		}
+	}
+
+	if *asJSON {
+		return printJSON(tree)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, k := range sortedKeys(tree) {
+		fmt.Fprintf(w, "%s\t%v\n", k, tree[k])
+	}
+
+	return w.Flush()
+}
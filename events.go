@@ -0,0 +1,156 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"reflect"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// deviceEvent is pushed when a device's state changes
+type deviceEvent struct {
+	Type string `json:"type"`
+	*deviceStatus
+}
+
+// propertyEvent is pushed when a watched property changes
+type propertyEvent struct {
+	Type     string `json:"type"`
+	Property string `json:"property"`
+	Value    any    `json:"value"`
+}
+
+var eventsUpgrader = websocket.Upgrader{
+	// The events are read only, so dashboards
+	// served from anywhere are allowed to connect
+	CheckOrigin: func(*http.Request) bool { return true },
+}
+
+// handleEvents holds a WebSocket open and pushes the state of each
+// device whenever it changes, from whichever controller changed it.
+// The devices can be narrowed down with ?device=, and other
+// properties watched with ?property=. Everything watched is sent
+// once when the connection opens.
+func (s *server) handleEvents(w http.ResponseWriter, r *http.Request) {
+	names := r.URL.Query()["device"]
+	if len(names) == 0 && len(r.URL.Query()["property"]) == 0 {
+		names = sortedKeys(s.devices)
+	}
+
+	// Which devices each property belongs to
+	watched := map[string][]string{}
+	for _, name := range names {
+		d, ok := s.devices[name]
+		if !ok {
+			writeError(w, http.StatusNotFound, fmt.Errorf("unknown device: %s", name))
+			return
+		}
+
+		watched[motu.Key(d.Property)] = append(watched[motu.Key(d.Property)], name)
+		if d.MuteProperty != "" {
+			watched[motu.Key(d.MuteProperty)] = append(watched[motu.Key(d.MuteProperty)], name)
+		}
+	}
+
+	properties := map[string]bool{}
+	for _, p := range r.URL.Query()["property"] {
+		properties[motu.Key(p)] = true
+	}
+
+	ws, err := eventsUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		return
+	}
+	defer ws.Close()
+
+	// Subscribe before reading the states so that no changes are missed
+	changes, unsubscribe := s.mirror.Subscribe()
+	defer unsubscribe()
+
+	// Nothing is read from the client, but reading
+	// is how a close from its end is noticed
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := ws.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	// The mirror sends the whole datastore whenever it
+	// syncs, so only states that differ are sent again
+	lastDevices := map[string]*deviceStatus{}
+	sendDevice := func(name string) error {
+		status, err := s.status(r.Context(), name, s.devices[name])
+		if err != nil {
+			slog.Error("Failed to get status", "device", name, "err", err)
+			return nil
+		}
+		if reflect.DeepEqual(status, lastDevices[name]) {
+			return nil
+		}
+		lastDevices[name] = status
+		return ws.WriteJSON(&deviceEvent{Type: "device", deviceStatus: status})
+	}
+
+	lastProperties := map[string]any{}
+	sendProperty := func(key string, value any) error {
+		if last, ok := lastProperties[key]; ok && reflect.DeepEqual(value, last) {
+			return nil
+		}
+		lastProperties[key] = value
+		return ws.WriteJSON(&propertyEvent{Type: "property", Property: key, Value: value})
+	}
+
+	for _, name := range names {
+		if err := sendDevice(name); err != nil {
+			return
+		}
+	}
+	for _, key := range sortedKeys(properties) {
+		// Sent with the first batch instead if the mirror isn't synced yet
+		if value, ok := s.mirror.Value(key); ok {
+			if err := sendProperty(key, value); err != nil {
+				return
+			}
+		}
+	}
+
+	for {
+		var batch map[string]any
+		select {
+		case <-closed:
+			return
+		case batch = <-changes:
+		}
+
+		changed := map[string]bool{}
+		for key := range batch {
+			for _, name := range watched[key] {
+				changed[name] = true
+			}
+		}
+
+		for _, name := range names {
+			if changed[name] {
+				if err := sendDevice(name); err != nil {
+					return
+				}
+			}
+		}
+		for _, key := range sortedKeys(properties) {
+			if value, ok := batch[key]; ok {
+				if err := sendProperty(key, value); err != nil {
+					return
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Exit statuses, so that scripts can tell failures apart without
+// parsing the error message. These are part of the interface, so
+// don't renumber them.
+const (
+	exitOK           = 0
+	exitError        = 1 // Anything not covered below
+	exitUsage        = 2 // The arguments were wrong
+	exitUnreachable  = 3 // The interface didn't respond
+	exitUnknown      = 4 // No such device, property or other name
+	exitUnauthorized = 5 // The password is missing or wrong
+	exitOutOfRange   = 6 // The interface rejected the value
+)
+
+// statusError is an error that exits with a particular status
+type statusError struct {
+	msg    string
+	status int
+
+	// Set if the error has already been printed, in the
+	// output of a command run for each target in a group
+	reported bool
+}
+
+func (e *statusError) Error() string {
+	return e.msg
+}
+
+// usagef returns an error for arguments that are missing or wrong
+func usagef(format string, a ...any) error {
+	return &statusError{msg: fmt.Sprintf(format, a...), status: exitUsage}
+}
+
+// unknownf returns an error for a name that isn't in the
+// config file or on the interface
+func unknownf(format string, a ...any) error {
+	return &statusError{msg: fmt.Sprintf(format, a...), status: exitUnknown}
+}
+
+// exitStatus returns the status to exit with after err
+func exitStatus(err error) int {
+	var serr *statusError
+	switch {
+	case err == nil:
+		return exitOK
+	case errors.As(err, &serr):
+		return serr.status
+	case errors.Is(err, motu.ErrDeviceUnreachable):
+		return exitUnreachable
+	case errors.Is(err, motu.ErrPropertyNotFound):
+		return exitUnknown
+	case errors.Is(err, motu.ErrUnauthorized):
+		return exitUnauthorized
+	case errors.Is(err, motu.ErrValueOutOfRange):
+		return exitOutOfRange
+	default:
+		return exitError
+	}
+}
+
+// jsonError is how errors are printed with --json
+type jsonError struct {
+	Error  string `json:"error"`
+	Hint   string `json:"hint,omitempty"`
+	Status int    `json:"exit_status"`
+}
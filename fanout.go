@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// allTargets selects every target in the config
+const allTargets = "all"
+
+// groupTargets returns the targets in the group named by target, or
+// nil if target isn't a group. "all" is the group of every target.
+func groupTargets() ([]string, error) {
+	if target == "" {
+		return nil, nil
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config: %w", err)
+	}
+
+	cfg, err := loadConfig(path, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	if target == allTargets {
+		if len(cfg.Targets) == 0 {
+			return nil, fmt.Errorf("no targets are configured")
+		}
+
+		var names []string
+		for name := range cfg.Targets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return names, nil
+	}
+
+	return cfg.Groups[target], nil
+}
+
+// fanOut runs the command given by args against each target at once,
+// by running this program again for each one. Once they have all
+// finished, each target's output is printed with its name in front.
+func fanOut(targets []string, args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	outputs := make([]bytes.Buffer, len(targets))
+	errs := make([]error, len(targets))
+
+	var wg sync.WaitGroup
+	for i, name := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			cmdArgs := args
+			if jsonOutput {
+				cmdArgs = append([]string{"--json"}, args...)
+			}
+
+			cmd := exec.Command(exe, cmdArgs...)
+			cmd.Env = append(os.Environ(), "MOTU_TARGET="+name)
+			if debug {
+				cmd.Env = append(cmd.Env, "MOTU_DEBUG=1")
+			}
+			if configFile != "" {
+				cmd.Env = append(cmd.Env, "MOTU_CONFIG="+configFile)
+			}
+			if password != "" {
+				cmd.Env = append(cmd.Env, "MOTU_PASSWORD="+password)
+			}
+			if logFormat != "" {
+				cmd.Env = append(cmd.Env, "MOTU_LOG_FORMAT="+logFormat)
+			}
+			if record != "" {
+				cmd.Env = append(cmd.Env, "MOTU_RECORD="+record)
+			}
+			if replay != "" {
+				cmd.Env = append(cmd.Env, "MOTU_REPLAY="+replay)
+			}
+			if logLevel != "" {
+				cmd.Env = append(cmd.Env, "MOTU_LOG_LEVEL="+logLevel)
+			}
+			cmd.Stdout = &outputs[i]
+			cmd.Stderr = &outputs[i]
+			if jsonOutput {
+				// Keep logs out of the JSON
+				cmd.Stderr = os.Stderr
+			}
+			errs[i] = cmd.Run()
+		}()
+	}
+	wg.Wait()
+
+	if jsonOutput {
+		return fanOutJSON(targets, outputs, errs)
+	}
+
+	var failed int
+	for i, name := range targets {
+		out := strings.TrimRight(outputs[i].String(), "\n")
+		if out == "" {
+			out = "ok"
+		}
+		for _, line := range strings.Split(out, "\n") {
+			fmt.Printf("%s: %s\n", name, line)
+		}
+
+		if errs[i] != nil {
+			failed++
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d targets failed", failed, len(targets))
+	}
+
+	return nil
+}
+
+// fanOutJSON prints the output of each target as a JSON object keyed
+// by target name. Failures are already described in their output, so
+// the status of the first one is all that's left to exit with.
+func fanOutJSON(targets []string, outputs []bytes.Buffer, errs []error) error {
+	result := make(map[string]json.RawMessage, len(targets))
+	var failed *statusError
+	for i, name := range targets {
+		out := bytes.TrimSpace(outputs[i].Bytes())
+		if !json.Valid(out) {
+			out = []byte("null")
+		}
+		result[name] = out
+
+		if errs[i] != nil && failed == nil {
+			failed = &statusError{msg: fmt.Sprintf("%s failed", name), status: exitError, reported: true}
+			var exitErr *exec.ExitError
+			if errors.As(errs[i], &exitErr) {
+				failed.status = exitErr.ExitCode()
+			}
+		}
+	}
+
+	if err := printJSON(result); err != nil {
+		return err
+	}
+
+	if failed != nil {
+		return failed
+	}
+	return nil
+}
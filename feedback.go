@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"encoding/binary"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const (
+	// The sound macOS makes when the volume keys are pressed
+	macVolumeSound = "/System/Library/LoginPlugins/BezelServices.loginPlugin/Contents/Resources/volume.aiff"
+)
+
+// A short click for when there's no system sound to use
+//
+//go:embed sounds/volume.wav
+var volumeWAV []byte
+
+// FeedbackConfig changes the sound that inc and dec play
+type FeedbackConfig struct {
+	// Don't play a sound at all
+	Disabled bool `yaml:"disabled"`
+
+	// File to play instead of the built-in sound
+	Sound string `yaml:"sound"`
+
+	// Raise the pitch and volume of the click along with
+	// the level, like the volume keys on macOS
+	FollowLevel bool `yaml:"follow_level"`
+
+	// Also show the new level in a desktop notification
+	Notify bool `yaml:"notify"`
+}
+
+func (fc *FeedbackConfig) validate() error {
+	if fc.Sound != "" && fc.FollowLevel {
+		return fmt.Errorf("follow_level only works with the built-in sound")
+	}
+	return nil
+}
+
+// feedback plays the sound for a device's level changing to value, and
+// shows a notification if configured to. The change has already been
+// made, so failing to do either is only logged.
+func feedback(fc *FeedbackConfig, name string, d *motu.Device, value float64) {
+	percent := d.ToPercent(value)
+
+	if err := fc.play(percent); err != nil {
+		slog.Warn("Failed to play sound", "err", err)
+	}
+
+	if fc.Notify {
+		message := fmt.Sprintf("%s %s", levelBar(percent, notifyBarWidth, "▮", "▯"), formatDB(d.ToDB(value)))
+		if err := notify(name, message); err != nil {
+			slog.Warn("Failed to show notification", "err", err)
+		}
+	}
+}
+
+// How many segments make up the level bar in a notification,
+// the same as the one macOS shows when the volume keys are pressed
+const notifyBarWidth = 16
+
+// levelBar draws a percentage as a bar width segments long
+func levelBar(percent float64, width int, filled, empty string) string {
+	n := int(math.Round(percent / 100 * float64(width)))
+	return strings.Repeat(filled, n) + strings.Repeat(empty, width-n)
+}
+
+func (fc *FeedbackConfig) play(percent float64) error {
+	switch {
+	case fc.Disabled:
+		return nil
+	case fc.Sound != "":
+		return playSoundFile(fc.Sound)
+	case !fc.FollowLevel && runtime.GOOS == "darwin":
+		return playSoundFile(macVolumeSound)
+	}
+
+	step := -1
+	if fc.FollowLevel {
+		step = int(math.Round(percent / 100 * clickSteps))
+	}
+
+	path, err := clickFile(step)
+	if err != nil {
+		return err
+	}
+
+	return playSoundFile(path)
+}
+
+// playSoundFile plays a sound file with whatever the platform has
+// for doing so. On Linux and Windows, WAV files work everywhere.
+func playSoundFile(path string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		// Apple does not define a value range for this, but it appears to accept
+		// 0=silent, 1=normal (default) and then up to 255=Very loud.
+		// Setting to higher than default so it's easier to hear over other audio.
+		volume := "2"
+		cmd = exec.Command("afplay", "-v", volume, path)
+	case "linux":
+		// PulseAudio (or PipeWire's stand-in for it) plays
+		// alongside everything else, but plain ALSA is more
+		// likely to be there
+		if _, err := exec.LookPath("paplay"); err == nil {
+			cmd = exec.Command("paplay", path)
+		} else {
+			cmd = exec.Command("aplay", "-q", path)
+		}
+	case "windows":
+		script := fmt.Sprintf("(New-Object Media.SoundPlayer '%s').PlaySync()", strings.ReplaceAll(path, "'", "''"))
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	default:
+		return fmt.Errorf("playing sounds is not supported on %s", runtime.GOOS)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to run %s: %w", filepath.Base(cmd.Path), err)
+	}
+
+	return nil
+}
+
+// notify shows a desktop notification
+func notify(title, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", title, message)
+	default:
+		return fmt.Errorf("notifications are not supported on %s", runtime.GOOS)
+	}
+
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to show notification: %w", err)
+	}
+
+	return nil
+}
+
+// How many different clicks there are when following the level
+const clickSteps = 10
+
+// clickFile returns the path of the built-in click, writing it to the
+// cache directory first as the players only take files. A step from 0
+// to clickSteps gives a click pitched for that level, and -1 the
+// click as it is.
+func clickFile(step int) (string, error) {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+
+	name := "volume.wav"
+	wav := volumeWAV
+	if step >= 0 {
+		name = fmt.Sprintf("volume-%d.wav", step)
+		level := float64(step) / clickSteps
+		wav = retune(volumeWAV, 0.75+0.75*level, 0.4+0.6*level)
+	}
+	path := filepath.Join(dir, "motu", name)
+
+	if b, err := os.ReadFile(path); err == nil && bytes.Equal(b, wav) {
+		return path, nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return "", fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	if err := os.WriteFile(path, wav, 0o644); err != nil {
+		return "", fmt.Errorf("failed to write sound: %w", err)
+	}
+
+	return path, nil
+}
+
+// retune returns a copy of a 16-bit PCM WAV with its pitch and volume
+// scaled. The pitch changes by claiming a different sample rate,
+// which also changes the length, but the click is short anyway.
+func retune(wav []byte, pitch, gain float64) []byte {
+	const (
+		offsetRate       = 24
+		offsetByteRate   = 28
+		offsetBlockAlign = 32
+		offsetData       = 44
+	)
+
+	b := bytes.Clone(wav)
+
+	rate := uint32(float64(binary.LittleEndian.Uint32(b[offsetRate:])) * pitch)
+	blockAlign := uint32(binary.LittleEndian.Uint16(b[offsetBlockAlign:]))
+	binary.LittleEndian.PutUint32(b[offsetRate:], rate)
+	binary.LittleEndian.PutUint32(b[offsetByteRate:], rate*blockAlign)
+
+	for i := offsetData; i+1 < len(b); i += 2 {
+		sample := float64(int16(binary.LittleEndian.Uint16(b[i:])))
+		binary.LittleEndian.PutUint16(b[i:], uint16(int16(sample*gain)))
+	}
+
+	return b
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// gitIgnore is written to a new repository so that only scenes and
+// snapshots are committed, and not the state, cache or config file,
+// which may have a password in it
+const gitIgnore = `# Only scenes and snapshots are versioned
+/*
+!/.gitignore
+!/scenes/
+!/snapshots/
+`
+
+// gitRepo is the git repository in the config file's directory
+// that scenes and snapshots are committed to, with git: true
+type gitRepo struct {
+	dir string
+}
+
+// gitCommit is an entry in the repository's log
+type gitCommit struct {
+	Rev     string    `json:"rev"`
+	Time    time.Time `json:"time"`
+	Message string    `json:"message"`
+}
+
+// openGitRepo returns the repository, creating it if needed
+func openGitRepo() (*gitRepo, error) {
+	cfgPath, err := configPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config: %w", err)
+	}
+	dir, err := filepath.Abs(filepath.Dir(cfgPath))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config directory: %w", err)
+	}
+	r := &gitRepo{dir: dir}
+
+	// The config directory may be inside another repository, e.g.
+	// someone's dotfiles, so look for one of its own
+	if _, err := os.Stat(filepath.Join(r.dir, ".git")); err == nil {
+		return r, nil
+	}
+
+	if err := os.MkdirAll(r.dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create config directory: %w", err)
+	}
+	if _, err := r.git("init", "--quiet"); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(r.dir, ".gitignore"), []byte(gitIgnore), 0o644); err != nil {
+		return nil, fmt.Errorf("failed to write .gitignore: %w", err)
+	}
+	if err := r.commit("Start versioning scenes and snapshots", ".gitignore"); err != nil {
+		return nil, err
+	}
+
+	slog.Info("Created a git repository for scenes and snapshots", "dir", r.dir)
+	return r, nil
+}
+
+// git runs a git command in the repository and returns what it printed
+func (r *gitRepo) git(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.Command("git", append([]string{"-C", r.dir}, args...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("failed to run git %s: %s", args[0], msg)
+		}
+		return "", fmt.Errorf("failed to run git %s: %w", args[0], err)
+	}
+	return stdout.String(), nil
+}
+
+// commit commits the files, if they've changed. Paths are absolute
+// or relative to the repository. Files outside it are left out.
+func (r *gitRepo) commit(message string, files ...string) error {
+	var inside []string
+	for _, f := range files {
+		if filepath.IsAbs(f) {
+			rel, err := filepath.Rel(r.dir, f)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				continue
+			}
+			f = rel
+		}
+		inside = append(inside, f)
+	}
+	if len(inside) == 0 {
+		return nil
+	}
+	files = inside
+
+	status, err := r.git(append([]string{"status", "--porcelain", "--"}, files...)...)
+	if err != nil {
+		return err
+	}
+	if strings.TrimSpace(status) == "" {
+		return nil
+	}
+
+	if _, err := r.git(append([]string{"add", "--"}, files...)...); err != nil {
+		return err
+	}
+
+	// Commit as the tool if git doesn't know who the user is
+	var identity []string
+	if _, err := r.git("config", "user.email"); err != nil {
+		host, _ := os.Hostname()
+		identity = []string{"-c", "user.name=motu", "-c", "user.email=motu@" + host}
+	}
+
+	args := append(identity, "commit", "--quiet", "-m", message, "--")
+	_, err = r.git(append(args, files...)...)
+	return err
+}
+
+// log returns the commits that changed any of the paths, newest first
+func (r *gitRepo) log(paths ...string) ([]*gitCommit, error) {
+	out, err := r.git(append([]string{"log", "--format=%h%x09%aI%x09%s", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+
+	var commits []*gitCommit
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		parts := strings.SplitN(line, "\t", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		t, _ := time.Parse(time.RFC3339, parts[1])
+		commits = append(commits, &gitCommit{Rev: parts[0], Time: t, Message: parts[2]})
+	}
+	return commits, nil
+}
+
+// show returns a file as it was at rev
+func (r *gitRepo) show(rev, file string) ([]byte, error) {
+	out, err := r.git("show", rev+":"+filepath.ToSlash(file))
+	if err != nil {
+		return nil, err
+	}
+	return []byte(out), nil
+}
+
+// files returns the files in a directory of the repository as it was
+// at rev, relative to the repository
+func (r *gitRepo) files(rev, dir string) ([]string, error) {
+	out, err := r.git("ls-tree", "--name-only", rev, filepath.ToSlash(dir)+"/")
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(out), nil
+}
+
+// commitToGit commits files if git versioning is on, logging
+// instead of failing if it can't, as the files are saved anyway
+func commitToGit(cfg *Config, message string, files ...string) {
+	if !cfg.Git {
+		return
+	}
+
+	r, err := openGitRepo()
+	if err == nil {
+		err = r.commit(message, files...)
+	}
+	if err != nil {
+		slog.Warn("Failed to commit to git", "err", err)
+	}
+}
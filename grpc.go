@@ -0,0 +1,286 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/jakewright/motu-tools/motu"
+	"github.com/jakewright/motu-tools/motupb"
+)
+
+// grpcServer is the daemon's gRPC API, described in motupb/motu.proto.
+// It shares the HTTP API's server, so changes are serialised with it.
+type grpcServer struct {
+	motupb.UnimplementedMotuServer
+
+	s *server
+}
+
+// serveGRPC listens for gRPC requests on address until it
+// fails. Requests are served over TLS if tlsCfg isn't nil.
+func serveGRPC(address string, s *server, tlsCfg *tls.Config) error {
+	l, err := net.Listen("tcp", address)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+
+	opts := s.cfg.Server.grpcOptions()
+	if tlsCfg != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+	}
+
+	g := grpc.NewServer(opts...)
+	motupb.RegisterMotuServer(g, &grpcServer{s: s})
+
+	slog.Info("Listening for gRPC", "address", address)
+	return g.Serve(l)
+}
+
+func (g *grpcServer) ListDevices(ctx context.Context, req *motupb.ListDevicesRequest) (*motupb.ListDevicesResponse, error) {
+	rsp := &motupb.ListDevicesResponse{}
+	for _, name := range sortedKeys(g.s.devices) {
+		state, err := g.state(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		rsp.Devices = append(rsp.Devices, state)
+	}
+	return rsp, nil
+}
+
+func (g *grpcServer) GetDevice(ctx context.Context, req *motupb.GetDeviceRequest) (*motupb.DeviceState, error) {
+	if _, err := g.device(req.Device); err != nil {
+		return nil, err
+	}
+	return g.state(ctx, req.Device)
+}
+
+func (g *grpcServer) Step(ctx context.Context, req *motupb.StepRequest) (*motupb.DeviceState, error) {
+	d, err := g.device(req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	var inc bool
+	switch req.Direction {
+	case motupb.StepRequest_DIRECTION_UP:
+		inc = true
+	case motupb.StepRequest_DIRECTION_DOWN:
+	default:
+		return nil, status.Error(codes.InvalidArgument, "direction is required")
+	}
+
+	value, err := g.s.steppers[req.Device].step(ctx, inc)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	go feedback(g.s.cfg.Feedback, req.Device, d, value)
+
+	return g.state(ctx, req.Device)
+}
+
+func (g *grpcServer) SetLevel(ctx context.Context, req *motupb.SetLevelRequest) (*motupb.DeviceState, error) {
+	d, err := g.device(req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	g.s.mu.Lock()
+	switch level := req.Level.(type) {
+	case *motupb.SetLevelRequest_LevelDb:
+		err = g.s.setLevel(ctx, req.Device, d, level.LevelDb)
+	case *motupb.SetLevelRequest_Percent:
+		err = g.s.setPercent(ctx, req.Device, d, level.Percent)
+	default:
+		err = status.Error(codes.InvalidArgument, "one of level_db or percent is required")
+	}
+	g.s.mu.Unlock()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return g.state(ctx, req.Device)
+}
+
+func (g *grpcServer) SetMute(ctx context.Context, req *motupb.SetMuteRequest) (*motupb.DeviceState, error) {
+	d, err := g.device(req.Device)
+	if err != nil {
+		return nil, err
+	}
+
+	g.s.mu.Lock()
+	switch req.State {
+	case motupb.SetMuteRequest_STATE_ON:
+		err = g.s.client.SetMuteContext(ctx, d, true)
+	case motupb.SetMuteRequest_STATE_OFF:
+		err = g.s.client.SetMuteContext(ctx, d, false)
+	case motupb.SetMuteRequest_STATE_TOGGLE:
+		_, err = g.s.client.MuteContext(ctx, d)
+	default:
+		err = status.Error(codes.InvalidArgument, "state is required")
+	}
+	g.s.mu.Unlock()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return g.state(ctx, req.Device)
+}
+
+func (g *grpcServer) ListScenes(ctx context.Context, req *motupb.ListScenesRequest) (*motupb.ListScenesResponse, error) {
+	names, err := listScenes(g.s.scenes)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &motupb.ListScenesResponse{Scenes: names}, nil
+}
+
+func (g *grpcServer) RecallScene(ctx context.Context, req *motupb.RecallSceneRequest) (*motupb.RecallSceneResponse, error) {
+	if req.Fade != nil {
+		if err := req.Fade.CheckValid(); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid fade: %v", err)
+		}
+	}
+
+	scene, err := loadScene(g.s.scenes, req.Scene)
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	g.s.mu.Lock()
+	err = recallScene(g.s.client, g.s.cfg, scene, req.Fade.AsDuration())
+	g.s.mu.Unlock()
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &motupb.RecallSceneResponse{}, nil
+}
+
+func (g *grpcServer) WatchDevices(req *motupb.WatchDevicesRequest, stream grpc.ServerStreamingServer[motupb.DeviceState]) error {
+	names := req.Devices
+	if len(names) == 0 {
+		names = sortedKeys(g.s.devices)
+	}
+
+	// Which devices each property belongs to
+	watched := map[string][]string{}
+	for _, name := range names {
+		d, err := g.device(name)
+		if err != nil {
+			return err
+		}
+
+		watched[motu.Key(d.Property)] = append(watched[motu.Key(d.Property)], name)
+		if d.MuteProperty != "" {
+			watched[motu.Key(d.MuteProperty)] = append(watched[motu.Key(d.MuteProperty)], name)
+		}
+	}
+
+	// Subscribe before reading the states so that no changes are missed
+	changes, unsubscribe := g.s.mirror.Subscribe()
+	defer unsubscribe()
+
+	// The mirror sends the whole datastore whenever it
+	// syncs, so only states that differ are sent again
+	last := map[string]*motupb.DeviceState{}
+	send := func(name string) error {
+		state, err := g.state(stream.Context(), name)
+		if err != nil {
+			return err
+		}
+		if proto.Equal(state, last[name]) {
+			return nil
+		}
+		last[name] = state
+		return stream.Send(state)
+	}
+
+	for _, name := range names {
+		if err := send(name); err != nil {
+			return err
+		}
+	}
+
+	for {
+		var batch map[string]any
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case batch = <-changes:
+		}
+
+		changed := map[string]bool{}
+		for key := range batch {
+			for _, name := range watched[key] {
+				changed[name] = true
+			}
+		}
+
+		for _, name := range names {
+			if changed[name] {
+				if err := send(name); err != nil {
+					return err
+				}
+			}
+		}
+	}
+}
+
+// device returns the configured device with the given name
+func (g *grpcServer) device(name string) (*motu.Device, error) {
+	d, ok := g.s.devices[name]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "unknown device: %s", name)
+	}
+	return d, nil
+}
+
+// state returns the state of the named device
+func (g *grpcServer) state(ctx context.Context, name string) (*motupb.DeviceState, error) {
+	ds, err := g.s.status(ctx, name, g.s.devices[name])
+	if err != nil {
+		return nil, grpcError(err)
+	}
+
+	return &motupb.DeviceState{
+		Device:  ds.Device,
+		Value:   ds.Value,
+		LevelDb: ds.LevelDB,
+		Percent: ds.Percent,
+		Muted:   ds.Muted,
+		Dimmed:  ds.Dimmed,
+	}, nil
+}
+
+// grpcError converts an error to a gRPC status error, with
+// the code that matches the HTTP API's status code
+func grpcError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	switch {
+	case errors.Is(err, motu.ErrDeviceUnreachable):
+		return status.Error(codes.Unavailable, err.Error())
+	case errors.Is(err, motu.ErrPropertyNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, motu.ErrValueOutOfRange):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case exitStatus(err) == exitUnknown:
+		return status.Error(codes.NotFound, err.Error())
+	default:
+		return status.Error(codes.Internal, err.Error())
+	}
+}
@@ -0,0 +1,314 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// How many commands to remember
+const maxHistory = 50
+
+// Commands that don't add to the history. Long running commands
+// would hold on to changes until they exit, undo and redo move
+// things around in the history themselves, and batches and the REPL record
+// each of their commands separately.
+var unrecorded = map[string]bool{
+	"serve":      true,
+	"midi":       true,
+	"osc":        true,
+	"mqtt":       true,
+	"homekit":    true,
+	"streamdeck": true,
+	"meters":     true,
+	"clip":       true,
+	"watch":      true,
+	"undo":       true,
+	"redo":       true,
+	"history":    true,
+	"batch":      true,
+	"repl":       true,
+	"hotkeys":    true,
+}
+
+// recorder collects the changes made by the current command
+type recorder struct {
+	mu      sync.Mutex
+	changes []motu.Change
+}
+
+// recording is nil if the current command isn't recorded
+var recording *recorder
+
+func (r *recorder) record(c motu.Change) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.changes = append(r.changes, c)
+}
+
+// History is the changes made by recent commands, so they can be undone
+type History struct {
+	// Most recent last
+	Done []*HistoryEntry `json:"done"`
+
+	// Most recently undone last
+	Undone []*HistoryEntry `json:"undone"`
+}
+
+// HistoryEntry is the changes made by one command
+type HistoryEntry struct {
+	Time    time.Time     `json:"time"`
+	Command string        `json:"command"`
+	Changes []motu.Change `json:"changes"`
+}
+
+// historyPath returns the location of the history file, which sits
+// alongside the config file. Each target has its own history.
+func historyPath() (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	name := "history.json"
+	if target != "" {
+		name = "history-" + target + ".json"
+	}
+
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// loadHistory reads the history file. If the file
+// does not exist, an empty history is returned.
+func loadHistory() (*History, error) {
+	path, err := historyPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find history file: %w", err)
+	}
+
+	h := &History{}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return h, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read history file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, h); err != nil {
+		return nil, fmt.Errorf("failed to parse history file %s: %w", path, err)
+	}
+
+	return h, nil
+}
+
+func (h *History) save() error {
+	path, err := historyPath()
+	if err != nil {
+		return fmt.Errorf("failed to find history file: %w", err)
+	}
+
+	b, err := json.MarshalIndent(h, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal history: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create history directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write history file: %w", err)
+	}
+
+	return nil
+}
+
+// saveHistory adds the changes made by the command to the history
+func saveHistory(args []string) error {
+	if recording == nil {
+		return nil
+	}
+
+	changes := collapse(recording.changes)
+	if len(changes) == 0 {
+		return nil
+	}
+
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	h.Done = append(h.Done, &HistoryEntry{
+		Time:    time.Now(),
+		Command: strings.Join(args, " "),
+		Changes: changes,
+	})
+	if len(h.Done) > maxHistory {
+		h.Done = h.Done[len(h.Done)-maxHistory:]
+	}
+
+	// A new change means there's nothing left to redo
+	h.Undone = nil
+
+	return h.save()
+}
+
+// collapse combines changes to the same property, e.g. the steps of a
+// fade, into one change from the first old value to the last new one.
+// Changes that end up where they started are dropped.
+func collapse(changes []motu.Change) []motu.Change {
+	index := map[string]int{}
+	var result []motu.Change
+	for _, c := range changes {
+		if i, ok := index[c.Path]; ok {
+			result[i].New = c.New
+			continue
+		}
+		index[c.Path] = len(result)
+		result = append(result, c)
+	}
+
+	kept := result[:0]
+	for _, c := range result {
+		if !reflect.DeepEqual(c.Old, c.New) {
+			kept = append(kept, c)
+		}
+	}
+
+	return kept
+}
+
+// undoCommand reverts the most recent recorded command, or with
+// redo, makes the most recently undone command's changes again
+func undoCommand(redo bool) error {
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	from, to := &h.Done, &h.Undone
+	verb := "Undid"
+	if redo {
+		from, to = &h.Undone, &h.Done
+		verb = "Redid"
+	}
+
+	if len(*from) == 0 {
+		if redo {
+			return fmt.Errorf("nothing to redo")
+		}
+		return fmt.Errorf("nothing to undo")
+	}
+
+	entry := (*from)[len(*from)-1]
+
+	values := map[string]any{}
+	var skipped int
+	for _, c := range entry.Changes {
+		v := c.Old
+		if redo {
+			v = c.New
+		}
+
+		// The old value couldn't be read when the change was made
+		if v == nil {
+			skipped++
+			continue
+		}
+		values[c.Path] = v
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if len(values) > 0 {
+		if err := m.SetValues(values); err != nil {
+			return fmt.Errorf("failed to update properties: %w", err)
+		}
+	}
+
+	*from = (*from)[:len(*from)-1]
+	*to = append(*to, entry)
+	if err := h.save(); err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(map[string]any{"command": entry.Command, "values": values, "skipped": skipped})
+	}
+
+	fmt.Printf("%s %q (%d changes)\n", verb, entry.Command, len(values))
+	if skipped > 0 {
+		fmt.Printf("%d changes couldn't be reverted because their previous values weren't known\n", skipped)
+	}
+
+	return nil
+}
+
+// historyCommand lists the recorded commands, most recent first
+func historyCommand() error {
+	h, err := loadHistory()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(h)
+	}
+
+	if len(h.Done) == 0 && len(h.Undone) == 0 {
+		fmt.Println("No history")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "TIME\tCOMMAND\tPATH\tOLD\tNEW\n")
+
+	list := func(e *HistoryEntry, note string) {
+		for i, c := range e.Changes {
+			var when, command string
+			if i == 0 {
+				when, command = e.Time.Format(time.DateTime), e.Command+note
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", when, command, c.Path, formatValue(c.Old), formatValue(c.New))
+		}
+	}
+
+	// The first command undone is the most recent one
+	for _, e := range h.Undone {
+		list(e, " (undone)")
+	}
+	for i := len(h.Done) - 1; i >= 0; i-- {
+		list(h.Done[i], "")
+	}
+
+	return w.Flush()
+}
+
+// formatValue formats a value from the history. Old values
+// that weren't known are shown as a question mark.
+func formatValue(v any) string {
+	if v == nil {
+		return "?"
+	}
+	return fmt.Sprint(v)
+}
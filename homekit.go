@@ -0,0 +1,200 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+
+	"github.com/brutella/hap"
+	"github.com/brutella/hap/accessory"
+	"github.com/brutella/hap/characteristic"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// HomeKitConfig configures the HomeKit bridge
+type HomeKitConfig struct {
+	// Eight digit code entered in the Home app when pairing
+	Pin string `yaml:"pin"`
+
+	// Name of the bridge shown in the Home app
+	Name string `yaml:"name"`
+
+	// Address to listen on. A random port is used if empty.
+	Addr string `yaml:"addr"`
+}
+
+// homekitDevice is a device exposed as a dimmable light. The Home app
+// has no good way to show a speaker, but a light's brightness maps
+// nicely onto volume and its on/off switch onto mute.
+type homekitDevice struct {
+	name   string
+	device *motu.Device
+	bulb   *accessory.Lightbulb
+
+	brightness *characteristic.Brightness
+}
+
+func homekitCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.HomeKit == nil || cfg.HomeKit.Pin == "" {
+		return fmt.Errorf("no HomeKit pin is configured")
+	}
+
+	name := cfg.HomeKit.Name
+	if name == "" {
+		name = "MOTU"
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	mirror := m.Sync(ctx)
+
+	bridge := accessory.NewBridge(accessory.Info{
+		Name:         name,
+		Manufacturer: "MOTU",
+	})
+
+	// Sort so the accessories are always listed in the same order
+	names := make([]string, 0, len(cfg.Devices))
+	for n := range cfg.Devices {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+
+	var (
+		devices     []*homekitDevice
+		accessories []*accessory.A
+	)
+	for _, n := range names {
+		hd := newHomekitDevice(m, n, cfg.Devices[n])
+		if err := hd.refresh(m); err != nil {
+			return fmt.Errorf("failed to read state of %s: %w", n, err)
+		}
+
+		devices = append(devices, hd)
+		accessories = append(accessories, hd.bulb.A)
+	}
+
+	dir, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	// Pairing data has to survive restarts, otherwise
+	// the bridge would need to be paired again every time
+	store := hap.NewFsStore(filepath.Join(filepath.Dir(dir), "homekit"))
+
+	server, err := hap.NewServer(store, bridge.A, accessories...)
+	if err != nil {
+		return fmt.Errorf("failed to create HomeKit server: %w", err)
+	}
+	server.Pin = cfg.HomeKit.Pin
+	server.Addr = cfg.HomeKit.Addr
+
+	go func() {
+		changes, unsubscribe := mirror.Subscribe()
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case batch := <-changes:
+				for _, hd := range devices {
+					_, levelChanged := batch[motu.Key(hd.device.Property)]
+					_, muteChanged := batch[motu.Key(hd.device.MuteProperty)]
+					if !levelChanged && !muteChanged {
+						continue
+					}
+
+					if err := hd.refresh(m); err != nil {
+						slog.Error("Failed to read state", "device", hd.name, "err", err)
+					}
+				}
+			}
+		}
+	}()
+
+	slog.Info("Starting HomeKit bridge", "name", name)
+	return server.ListenAndServe(ctx)
+}
+
+func newHomekitDevice(m *motu.Client, name string, d *motu.Device) *homekitDevice {
+	bulb := accessory.NewLightbulb(accessory.Info{
+		Name:         name,
+		Manufacturer: "MOTU",
+	})
+
+	// Accessory IDs must not change between runs, even if
+	// devices are added to or removed from the config
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(name))
+	bulb.Id = h.Sum64()
+
+	brightness := characteristic.NewBrightness()
+	bulb.Lightbulb.AddC(brightness.C)
+
+	hd := &homekitDevice{
+		name:       name,
+		device:     d,
+		bulb:       bulb,
+		brightness: brightness,
+	}
+
+	brightness.OnValueRemoteUpdate(func(v int) {
+		if _, err := m.SetPercent(d, float64(v)); err != nil {
+			slog.Error("Failed to set level", "device", name, "err", err)
+		}
+	})
+
+	bulb.Lightbulb.On.OnValueRemoteUpdate(func(on bool) {
+		var err error
+		if d.MuteProperty != "" {
+			err = m.SetMute(d, !on)
+		} else if !on {
+			_, err = m.SetLevel(d, math.Inf(-1))
+		}
+		if err != nil {
+			slog.Error("Failed to switch", "device", name, "err", err)
+		}
+	})
+
+	return hd
+}
+
+// refresh updates the accessory's characteristics from the device
+func (hd *homekitDevice) refresh(m *motu.Client) error {
+	percent, err := m.Percent(hd.device)
+	if err != nil {
+		return err
+	}
+
+	on := percent > 0
+	if hd.device.MuteProperty != "" {
+		muted, err := m.Muted(hd.device)
+		if err != nil {
+			return err
+		}
+		on = !muted
+	}
+
+	hd.bulb.Lightbulb.On.SetValue(on)
+	return hd.brightness.SetValue(int(math.Round(percent)))
+}
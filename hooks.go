@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"os"
+	"os/exec"
+	"strconv"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// HooksConfig holds commands to run when things happen. Each is run
+// with sh -c, and details of the event are passed in environment
+// variables that start with MOTU_.
+type HooksConfig struct {
+	// Run when a device is muted or unmuted.
+	// Sets MOTU_DEVICE and MOTU_MUTED (0 or 1).
+	OnMute string `yaml:"on_mute"`
+
+	// Run when a device's level changes. Sets MOTU_DEVICE, MOTU_VALUE,
+	// MOTU_PERCENT, and MOTU_LEVEL_DB (empty at zero volume).
+	OnVolumeChange string `yaml:"on_volume_change"`
+
+	// Run by the clip command when a channel clips.
+	// Sets MOTU_BANK, MOTU_CHANNEL and MOTU_LEVEL_DB.
+	OnClip string `yaml:"on_clip"`
+
+	// Run by the daemon when the interface stops responding.
+	// Sets MOTU_ADDRESS.
+	OnDeviceOffline string `yaml:"on_device_offline"`
+}
+
+// watch runs the mute, volume and offline hooks until ctx is cancelled
+func (h *HooksConfig) watch(ctx context.Context, m *motu.Client, mirror *motu.Mirror, devices map[string]*motu.Device) {
+	changes, unsubscribe := mirror.Subscribe()
+	defer unsubscribe()
+
+	synced, unsubscribeSynced := mirror.SubscribeSynced()
+	defer unsubscribeSynced()
+
+	// The mirror sends the whole datastore whenever
+	// it syncs, so only run hooks for real changes
+	last := map[string]any{}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+
+		case ok := <-synced:
+			if !ok {
+				runHook("device_offline", h.OnDeviceOffline, map[string]string{
+					"MOTU_ADDRESS": m.Host(),
+				})
+			}
+
+		case batch := <-changes:
+			for name, d := range devices {
+				if v, ok := changed(last, batch, motu.Key(d.Property)); ok {
+					env := map[string]string{
+						"MOTU_DEVICE":   name,
+						"MOTU_VALUE":    formatFloat(v),
+						"MOTU_PERCENT":  formatFloat(d.ToPercent(v)),
+						"MOTU_LEVEL_DB": "",
+					}
+					if db := d.ToDB(v); !math.IsInf(db, 0) {
+						env["MOTU_LEVEL_DB"] = formatFloat(db)
+					}
+					runHook("volume_change", h.OnVolumeChange, env)
+				}
+
+				if d.MuteProperty == "" {
+					continue
+				}
+				if v, ok := changed(last, batch, motu.Key(d.MuteProperty)); ok {
+					runHook("mute", h.OnMute, map[string]string{
+						"MOTU_DEVICE": name,
+						"MOTU_MUTED":  formatFloat(v),
+					})
+				}
+			}
+		}
+	}
+}
+
+// changed records the numeric value of key from batch in last, and
+// returns it if it's different to the value that was there before
+func changed(last, batch map[string]any, key string) (float64, bool) {
+	v, ok := batch[key]
+	if !ok {
+		return 0, false
+	}
+
+	f, ok := toFloat(v)
+	if !ok {
+		return 0, false
+	}
+
+	previous, seen := last[key]
+	last[key] = f
+
+	return f, seen && previous != f
+}
+
+// clip runs the clip hook
+func (h *HooksConfig) clip(c motu.Clip) {
+	runHook("clip", h.OnClip, map[string]string{
+		"MOTU_BANK":     c.Bank,
+		"MOTU_CHANNEL":  strconv.Itoa(c.Channel),
+		"MOTU_LEVEL_DB": formatFloat(motu.AmplitudeToDB(c.Level)),
+	})
+}
+
+// runHook starts the command in the background, if there is one
+func runHook(event, command string, env map[string]string) {
+	if command == "" {
+		return
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	cmd.Env = append(os.Environ(), "MOTU_EVENT="+event)
+	for k, v := range env {
+		cmd.Env = append(cmd.Env, k+"="+v)
+	}
+
+	if err := cmd.Start(); err != nil {
+		slog.Error("Failed to run hook", "event", event, "err", err)
+		return
+	}
+
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			slog.Error("Hook failed", "event", event, "err", err)
+		}
+	}()
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"strings"
+)
+
+// Modifier keys that can be part of a hotkey
+type modifiers uint8
+
+const (
+	modCtrl modifiers = 1 << iota
+	modShift
+	modAlt
+	modCmd // The command key on macOS, and the super key on Linux
+)
+
+var modifierNames = map[string]modifiers{
+	"ctrl":  modCtrl,
+	"shift": modShift,
+	"alt":   modAlt,
+	"opt":   modAlt,
+	"cmd":   modCmd,
+	"super": modCmd,
+}
+
+// hotkey is a key and the modifiers held down with it
+type hotkey struct {
+	mods modifiers
+	key  string
+}
+
+// parseHotkey parses a key such as "F12" or "ctrl+shift+m". Keys are
+// named as in hotkeyNames, and case doesn't matter.
+func parseHotkey(s string) (hotkey, error) {
+	parts := strings.Split(strings.ToLower(s), "+")
+
+	var k hotkey
+	for _, p := range parts[:len(parts)-1] {
+		m, ok := modifierNames[strings.TrimSpace(p)]
+		if !ok {
+			return hotkey{}, fmt.Errorf("unknown modifier %q in %q", p, s)
+		}
+		k.mods |= m
+	}
+
+	k.key = strings.TrimSpace(parts[len(parts)-1])
+	if !hotkeyNames[k.key] {
+		return hotkey{}, fmt.Errorf("unknown key %q in %q", k.key, s)
+	}
+	return k, nil
+}
+
+// The keys that hotkeys can use. Each platform maps its key codes onto
+// these, except for the media keys, which macOS doesn't report as keys.
+var hotkeyNames = func() map[string]bool {
+	names := map[string]bool{
+		"space": true, "up": true, "down": true, "left": true, "right": true,
+		"mute": true, "volumedown": true, "volumeup": true,
+	}
+	for c := 'a'; c <= 'z'; c++ {
+		names[string(c)] = true
+	}
+	for c := '0'; c <= '9'; c++ {
+		names[string(c)] = true
+	}
+	for i := 1; i <= 12; i++ {
+		names[fmt.Sprintf("f%d", i)] = true
+	}
+	return names
+}()
+
+// hotkeyPress is a key going down, or repeating while it's held
+type hotkeyPress struct {
+	hotkey
+	repeat bool
+}
+
+// hotkeysCommand listens for the hotkeys in the config file anywhere
+// on the desktop and runs their commands, sharing the config and
+// connection like a batch. Holding a key down repeats inc and dec.
+func hotkeysCommand() error {
+	batch = &batchState{}
+	defer func() { batch = nil }()
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if len(cfg.Hotkeys) == 0 {
+		return fmt.Errorf("no hotkeys are configured")
+	}
+
+	bindings := map[hotkey]string{}
+	for key, line := range cfg.Hotkeys {
+		k, err := parseHotkey(key)
+		if err != nil {
+			return err
+		}
+		bindings[k] = line
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+	batch.client = m
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	// Commands run one at a time, in the order the keys were
+	// pressed, without holding up the platform's listener
+	presses := make(chan hotkeyPress, 16)
+	bound := func(k hotkey) bool {
+		_, ok := bindings[k]
+		return ok
+	}
+
+	errs := make(chan error, 1)
+	go func() { errs <- listenHotkeys(ctx, bound, presses) }()
+
+	slog.Info("Listening for hotkeys", "count", len(bindings))
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-errs:
+			return err
+		case p := <-presses:
+			line := bindings[p.hotkey]
+			if p.repeat && !repeats(line) {
+				continue
+			}
+			if err := runBatchLine(line); err != nil {
+				slog.Error("Hotkey failed", "command", line, "err", err)
+			}
+		}
+	}
+}
+
+// repeats returns whether holding down a key that
+// runs line should keep running it, as for inc and dec
+func repeats(line string) bool {
+	args, err := splitArgs(line)
+	if err != nil || len(args) < 2 {
+		return false
+	}
+
+	v := findVerb(args[1])
+	return v != nil && (v.name == "inc" || v.name == "dec")
+}
+
+// validateHotkeys returns an error if a hotkey can't be used. Commands
+// that don't finish, such as serve, can't be bound to a key.
+func validateHotkeys(hotkeys map[string]string) error {
+	for key, line := range hotkeys {
+		if _, err := parseHotkey(key); err != nil {
+			return err
+		}
+
+		args, err := splitArgs(line)
+		if err != nil {
+			return fmt.Errorf("hotkey %q: %w", key, err)
+		}
+		if len(args) == 0 {
+			return fmt.Errorf("hotkey %q has no command", key)
+		}
+		if name := args[0]; name == "batch" || name == "repl" || (unrecorded[name] && name != "undo" && name != "redo") {
+			return fmt.Errorf("hotkey %q can't run %s", key, name)
+		}
+	}
+	return nil
+}
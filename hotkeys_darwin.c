@@ -0,0 +1,38 @@
+//go:build cgo
+
+#include <ApplicationServices/ApplicationServices.h>
+#include "_cgo_export.h"
+
+static CFMachPortRef tap;
+
+static CGEventRef tapCallback(CGEventTapProxy proxy, CGEventType type, CGEventRef event, void *info) {
+	// macOS turns the tap off if it's slow, so turn it back on
+	if (type == kCGEventTapDisabledByTimeout || type == kCGEventTapDisabledByUserInput) {
+		CGEventTapEnable(tap, true);
+		return event;
+	}
+
+	int64_t keycode = CGEventGetIntegerValueField(event, kCGKeyboardEventKeycode);
+	int64_t repeat = CGEventGetIntegerValueField(event, kCGKeyboardEventAutorepeat);
+
+	// Bound keys are swallowed, so that they don't
+	// also do whatever they'd normally do
+	if (goHotkey(keycode, CGEventGetFlags(event), repeat)) {
+		return NULL;
+	}
+	return event;
+}
+
+int runEventTap(void) {
+	tap = CGEventTapCreate(kCGSessionEventTap, kCGHeadInsertEventTap, kCGEventTapOptionDefault,
+		CGEventMaskBit(kCGEventKeyDown), tapCallback, NULL);
+	if (!tap) {
+		return -1;
+	}
+
+	CFRunLoopSourceRef source = CFMachPortCreateRunLoopSource(kCFAllocatorDefault, tap, 0);
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), source, kCFRunLoopCommonModes);
+	CGEventTapEnable(tap, true);
+	CFRunLoopRun();
+	return 0;
+}
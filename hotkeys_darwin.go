@@ -0,0 +1,93 @@
+//go:build cgo
+
+package main
+
+/*
+#cgo LDFLAGS: -framework ApplicationServices
+#include <stdint.h>
+
+int runEventTap(void);
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+// Virtual key codes from HIToolbox's Events.h. The media keys
+// aren't key events on macOS, so they can't be hotkeys.
+var darwinKeys = map[int64]string{
+	0: "a", 1: "s", 2: "d", 3: "f", 4: "h", 5: "g", 6: "z", 7: "x", 8: "c", 9: "v",
+	11: "b", 12: "q", 13: "w", 14: "e", 15: "r", 16: "y", 17: "t",
+	18: "1", 19: "2", 20: "3", 21: "4", 22: "6", 23: "5", 25: "9", 26: "7", 28: "8", 29: "0",
+	31: "o", 32: "u", 34: "i", 35: "p", 37: "l", 38: "j", 40: "k", 45: "n", 46: "m", 49: "space",
+	122: "f1", 120: "f2", 99: "f3", 118: "f4", 96: "f5", 97: "f6",
+	98: "f7", 100: "f8", 101: "f9", 109: "f10", 103: "f11", 111: "f12",
+	123: "left", 124: "right", 125: "down", 126: "up",
+}
+
+// Modifier flags from CGEventTypes.h
+var darwinModifiers = map[uint64]modifiers{
+	0x020000: modShift,
+	0x040000: modCtrl,
+	0x080000: modAlt,
+	0x100000: modCmd,
+}
+
+// The event tap calls back into goHotkey, which can't be given
+// a closure, so the listener's state is kept here
+var (
+	darwinBound   func(hotkey) bool
+	darwinPresses chan<- hotkeyPress
+)
+
+// listenHotkeys reads key presses with an event tap. The terminal, or
+// whatever runs motu, needs to be allowed to monitor input under
+// Privacy & Security in System Settings. Function keys only arrive
+// as such when they're set to be standard function keys, or with fn.
+func listenHotkeys(ctx context.Context, bound func(hotkey) bool, presses chan<- hotkeyPress) error {
+	darwinBound = bound
+	darwinPresses = presses
+
+	errs := make(chan error, 1)
+	go func() {
+		// The run loop belongs to the thread
+		runtime.LockOSThread()
+		if C.runEventTap() != 0 {
+			errs <- fmt.Errorf("failed to create event tap: allow input monitoring in System Settings")
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+//export goHotkey
+func goHotkey(keycode C.int64_t, flags C.uint64_t, repeat C.int64_t) C.int {
+	name, ok := darwinKeys[int64(keycode)]
+	if !ok {
+		return 0
+	}
+
+	k := hotkey{key: name}
+	for flag, m := range darwinModifiers {
+		if uint64(flags)&flag != 0 {
+			k.mods |= m
+		}
+	}
+	if !darwinBound(k) {
+		return 0
+	}
+
+	select {
+	case darwinPresses <- hotkeyPress{hotkey: k, repeat: repeat != 0}:
+	default: // Still busy with earlier presses
+	}
+	return 1
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Event types and values from linux/input.h
+const (
+	evKey       = 0x01
+	keyReleased = 0
+	keyPressed  = 1
+	keyRepeated = 2
+)
+
+// Sizes of struct input_event: a struct timeval, made of two
+// longs, then the type, code and value
+var (
+	inputEventTimeSize = 2 * strconv.IntSize / 8
+	inputEventSize     = inputEventTimeSize + 8
+)
+
+// Key codes from linux/input-event-codes.h
+var linuxKeys = map[uint16]string{
+	2: "1", 3: "2", 4: "3", 5: "4", 6: "5", 7: "6", 8: "7", 9: "8", 10: "9", 11: "0",
+	16: "q", 17: "w", 18: "e", 19: "r", 20: "t", 21: "y", 22: "u", 23: "i", 24: "o", 25: "p",
+	30: "a", 31: "s", 32: "d", 33: "f", 34: "g", 35: "h", 36: "j", 37: "k", 38: "l",
+	44: "z", 45: "x", 46: "c", 47: "v", 48: "b", 49: "n", 50: "m",
+	57: "space",
+	59: "f1", 60: "f2", 61: "f3", 62: "f4", 63: "f5", 64: "f6",
+	65: "f7", 66: "f8", 67: "f9", 68: "f10", 87: "f11", 88: "f12",
+	103: "up", 105: "left", 106: "right", 108: "down",
+	113: "mute", 114: "volumedown", 115: "volumeup",
+}
+
+var linuxModifiers = map[uint16]modifiers{
+	29: modCtrl, 97: modCtrl,
+	42: modShift, 54: modShift,
+	56: modAlt, 100: modAlt,
+	125: modCmd, 126: modCmd,
+}
+
+// listenHotkeys reads key presses from every keyboard through evdev,
+// which works under X11 and Wayland alike but needs permission to read
+// /dev/input, e.g. by being in the input group. Presses still reach
+// the focused window too.
+func listenHotkeys(ctx context.Context, bound func(hotkey) bool, presses chan<- hotkeyPress) error {
+	paths, err := keyboardDevices()
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return fmt.Errorf("no keyboards found")
+	}
+
+	// Modifiers are shared between keyboards,
+	// which is simpler and rarely matters
+	var (
+		mu   sync.Mutex
+		held = map[uint16]bool{}
+	)
+	handle := func(code uint16, value int32) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, ok := linuxModifiers[code]; ok {
+			held[code] = value != keyReleased
+			return
+		}
+
+		name, ok := linuxKeys[code]
+		if !ok || value == keyReleased {
+			return
+		}
+
+		k := hotkey{key: name}
+		for c, m := range linuxModifiers {
+			if held[c] {
+				k.mods |= m
+			}
+		}
+		if !bound(k) {
+			return
+		}
+
+		select {
+		case presses <- hotkeyPress{hotkey: k, repeat: value == keyRepeated}:
+		default: // Still busy with earlier presses
+		}
+	}
+
+	errs := make(chan error, len(paths))
+	for _, path := range paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return fmt.Errorf("failed to open keyboard (are you in the input group?): %w", err)
+		}
+		defer f.Close()
+
+		go func() { errs <- readKeyEvents(f, handle) }()
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+// readKeyEvents passes each key event read from
+// an evdev device to handle until it fails
+func readKeyEvents(r io.Reader, handle func(code uint16, value int32)) error {
+	buf := make([]byte, inputEventSize)
+	for {
+		if _, err := io.ReadFull(r, buf); err != nil {
+			if errors.Is(err, os.ErrClosed) {
+				return nil
+			}
+			return fmt.Errorf("failed to read keyboard: %w", err)
+		}
+
+		event := buf[inputEventTimeSize:]
+		if binary.NativeEndian.Uint16(event) != evKey {
+			continue
+		}
+		handle(binary.NativeEndian.Uint16(event[2:]), int32(binary.NativeEndian.Uint32(event[4:])))
+	}
+}
+
+// keyboardDevices returns the evdev devices that have the keyboard
+// handler, which includes the media keys on their own device
+func keyboardDevices() ([]string, error) {
+	f, err := os.Open("/proc/bus/input/devices")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list input devices: %w", err)
+	}
+	defer f.Close()
+
+	var paths []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		handlers, ok := strings.CutPrefix(scanner.Text(), "H: Handlers=")
+		if !ok {
+			continue
+		}
+
+		fields := strings.Fields(handlers)
+		if !contains(fields, "kbd") {
+			continue
+		}
+		for _, h := range fields {
+			if strings.HasPrefix(h, "event") {
+				paths = append(paths, "/dev/input/"+h)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to list input devices: %w", err)
+	}
+
+	return paths, nil
+}
@@ -0,0 +1,16 @@
+//go:build !linux && !(darwin && cgo)
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+)
+
+func listenHotkeys(ctx context.Context, bound func(hotkey) bool, presses chan<- hotkeyPress) error {
+	if runtime.GOOS == "darwin" {
+		return fmt.Errorf("built without hotkey support: rebuild with CGO_ENABLED=1")
+	}
+	return fmt.Errorf("hotkeys aren't supported on %s", runtime.GOOS)
+}
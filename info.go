@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// infoCommand prints details of the interface,
+// which are useful to include in bug reports
+func infoCommand() error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	info, err := m.Info()
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		return printJSON(map[string]string{
+			"name":        info.Name,
+			"model":       info.Model,
+			"uid":         info.UID,
+			"firmware":    info.Firmware,
+			"api_version": info.APIVersion,
+			"address":     m.Host(),
+		})
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Name:\t%s\n", info.Name)
+	fmt.Fprintf(w, "Model:\t%s\n", info.Model)
+	fmt.Fprintf(w, "UID:\t%s\n", info.UID)
+	fmt.Fprintf(w, "Firmware:\t%s\n", info.Firmware)
+	fmt.Fprintf(w, "API version:\t%s\n", info.APIVersion)
+	fmt.Fprintf(w, "Address:\t%s\n", m.Host())
+
+	return w.Flush()
+}
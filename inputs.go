@@ -0,0 +1,165 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// gainCommand shows or changes the trim of an input channel
+func gainCommand(args []string) error {
+	flags := flag.NewFlagSet("gain", flag.ContinueOnError)
+	step := flags.Float64("step", 1, "dB to change the gain by for inc and dec")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) < 1 {
+		return usagef("usage: gain <input> [inc|dec|set <dB>]")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	input, err := findInput(cfg, positional[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	return adjustTrim(m, input, positional[1:], *step)
+}
+
+// adjustTrim changes a trim according to args, which can be "inc",
+// "dec" or "set <dB>", and prints the result. With no args, it just
+// prints the current trim.
+func adjustTrim(m *motu.Client, p motu.Port, args []string, step float64) error {
+	current, err := m.Trim(p)
+	if err != nil {
+		return fmt.Errorf("failed to get current trim: %w", err)
+	}
+
+	target := current
+	if len(args) > 0 {
+		switch args[0] {
+		case "inc":
+			target += step
+		case "dec":
+			target -= step
+		case "set":
+			if len(args) < 2 {
+				return usagef("usage: set <dB>")
+			}
+			target, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(args[1]), "db"), 64)
+			if err != nil {
+				return usagef("invalid trim: %s", args[1])
+			}
+		default:
+			return usagef("unrecognised trim command: %s", args[0])
+		}
+
+		if current, err = m.SetTrim(p, target); err != nil {
+			return err
+		}
+	}
+
+	return printResult(map[string]float64{"trim_db": current}, fmt.Sprintf("%.0f dB", current))
+}
+
+// invertCommand flips the polarity of an input channel
+func invertCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 {
+		return usagef("usage: invert <input> [on|off|toggle]")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	input, err := findInput(cfg, args[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	var state string
+	if len(args) > 1 {
+		state = args[1]
+	}
+
+	var inverted bool
+	switch state {
+	case "", "toggle":
+		current, err := m.Inverted(input)
+		if err != nil {
+			return fmt.Errorf("failed to get current polarity: %w", err)
+		}
+		inverted = !current
+	case "on":
+		inverted = true
+	case "off":
+		inverted = false
+	default:
+		return usagef("usage: invert <input> [on|off|toggle]")
+	}
+
+	if err := m.SetInverted(input, inverted); err != nil {
+		return err
+	}
+
+	text := "normal"
+	if inverted {
+		text = "inverted"
+	}
+	return printResult(map[string]bool{"inverted": inverted}, text)
+}
+
+// findInput returns the input with the given name in the config,
+// or parses the name as "bank/channel", e.g. "0/3"
+func findInput(cfg *Config, name string) (motu.Input, error) {
+	if i, ok := cfg.Inputs[name]; ok {
+		return *i, nil
+	}
+
+	bank, ch, err := parseChannel(name)
+	if err != nil {
+		return motu.Input{}, unknownf("unknown input: %s", name)
+	}
+
+	return motu.Input{Bank: bank, Channel: ch}, nil
+}
+
+// parseChannel parses a bank and channel given as "bank/channel"
+func parseChannel(s string) (int, int, error) {
+	bank, ch, ok := strings.Cut(s, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("expected bank/channel: %s", s)
+	}
+
+	b, err := strconv.Atoi(bank)
+	if err != nil {
+		return 0, 0, usagef("invalid bank: %s", bank)
+	}
+
+	c, err := strconv.Atoi(ch)
+	if err != nil {
+		return 0, 0, usagef("invalid channel: %s", ch)
+	}
+
+	return b, c, nil
+}
@@ -0,0 +1,70 @@
+package cron_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jakewright/motu-tools/internal/cron"
+)
+
+func TestMatches(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2026, time.October, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		spec string
+		t    time.Time
+		want bool
+	}{
+		{"0 9 * * *", at(14, 9, 0), true},
+		{"0 9 * * *", at(14, 9, 1), false},
+		{"*/15 8-18/2 * * *", at(14, 10, 45), true},
+		{"*/15 8-18/2 * * *", at(14, 11, 45), false},
+
+		// 14 October 2026 is a Wednesday
+		{"0 9 * * 1-5", at(14, 9, 0), true},
+		{"0 9 * * 1-5", at(17, 9, 0), false},
+		{"0 9 * * 0", at(18, 9, 0), true},
+		{"0 9 * * 7", at(18, 9, 0), true},
+
+		// With both day fields restricted, either one matching is enough
+		{"0 9 1 * 3", at(14, 9, 0), true},
+		{"0 9 1 * 3", at(1, 9, 0), true},
+		{"0 9 1 * 3", at(15, 9, 0), false},
+
+		// A step over every day is unrestricted, so both have to match:
+		// odd days that are also weekdays
+		{"0 9 */2 * 1-5", at(15, 9, 0), true},
+		{"0 9 */2 * 1-5", at(14, 9, 0), false},
+		{"0 9 */2 * 1-5", at(17, 9, 0), false},
+	}
+
+	for _, tt := range tests {
+		s, err := cron.Parse(tt.spec)
+		if err != nil {
+			t.Fatalf("Parse(%q) failed: %v", tt.spec, err)
+		}
+		if got := s.Matches(tt.t); got != tt.want {
+			t.Errorf("%q at %s: got %v, want %v", tt.spec, tt.t.Format("Mon 2 Jan 15:04"), got, tt.want)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, spec := range []string{
+		"0 9 * *",
+		"60 9 * * *",
+		"0 24 * * *",
+		"0 9 0 * *",
+		"0 9 * 13 *",
+		"0 9 * * 8",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		"a * * * *",
+	} {
+		if _, err := cron.Parse(spec); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", spec)
+		}
+	}
+}
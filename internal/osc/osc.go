@@ -0,0 +1,211 @@
+// Package osc encodes and decodes Open Sound Control messages. It
+// supports the argument types that control surfaces actually send:
+// int32, float32, string and boolean.
+//
+// See https://opensoundcontrol.stanford.edu/spec-1_0.html
+package osc
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Message is a single OSC message. Args can contain
+// int32, float32, string and bool values.
+type Message struct {
+	Address string
+	Args    []any
+}
+
+// Float returns the i'th argument as a float64 if it is numeric.
+// Booleans are converted to 0 or 1.
+func (m *Message) Float(i int) (float64, bool) {
+	if i >= len(m.Args) {
+		return 0, false
+	}
+
+	switch v := m.Args[i].(type) {
+	case float32:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case bool:
+		if v {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// Marshal encodes the message
+func (m *Message) Marshal() ([]byte, error) {
+	var buf bytes.Buffer
+	writeString(&buf, m.Address)
+
+	tags := ","
+	var args bytes.Buffer
+	for _, arg := range m.Args {
+		switch v := arg.(type) {
+		case int32:
+			tags += "i"
+			_ = binary.Write(&args, binary.BigEndian, v)
+		case float32:
+			tags += "f"
+			_ = binary.Write(&args, binary.BigEndian, math.Float32bits(v))
+		case string:
+			tags += "s"
+			writeString(&args, v)
+		case bool:
+			if v {
+				tags += "T"
+			} else {
+				tags += "F"
+			}
+		default:
+			return nil, fmt.Errorf("unsupported argument type %T", arg)
+		}
+	}
+
+	writeString(&buf, tags)
+	buf.Write(args.Bytes())
+
+	return buf.Bytes(), nil
+}
+
+// Parse decodes a packet, which may be a single message or a bundle.
+// The messages in a bundle (and any nested bundles) are flattened;
+// time tags are ignored and everything is treated as immediate.
+func Parse(b []byte) ([]*Message, error) {
+	if bytes.HasPrefix(b, []byte("#bundle\x00")) {
+		return parseBundle(b)
+	}
+
+	m, err := parseMessage(b)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*Message{m}, nil
+}
+
+func parseBundle(b []byte) ([]*Message, error) {
+	// Skip the "#bundle" string and the 8 byte time tag
+	if len(b) < 16 {
+		return nil, errors.New("bundle too short")
+	}
+	b = b[16:]
+
+	var result []*Message
+	for len(b) > 0 {
+		if len(b) < 4 {
+			return nil, errors.New("truncated bundle element")
+		}
+
+		size := int(binary.BigEndian.Uint32(b))
+		b = b[4:]
+		if size > len(b) {
+			return nil, errors.New("bundle element larger than bundle")
+		}
+
+		messages, err := Parse(b[:size])
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, messages...)
+		b = b[size:]
+	}
+
+	return result, nil
+}
+
+func parseMessage(b []byte) (*Message, error) {
+	address, b, err := readString(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read address: %w", err)
+	}
+
+	if !strings.HasPrefix(address, "/") {
+		return nil, fmt.Errorf("invalid address %q", address)
+	}
+
+	m := &Message{Address: address}
+
+	// The type tag string is optional in
+	// very old implementations
+	if len(b) == 0 {
+		return m, nil
+	}
+
+	tags, b, err := readString(b)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type tags: %w", err)
+	}
+
+	if !strings.HasPrefix(tags, ",") {
+		return nil, fmt.Errorf("invalid type tags %q", tags)
+	}
+
+	for _, tag := range tags[1:] {
+		switch tag {
+		case 'i':
+			if len(b) < 4 {
+				return nil, errors.New("truncated int32 argument")
+			}
+			m.Args = append(m.Args, int32(binary.BigEndian.Uint32(b)))
+			b = b[4:]
+		case 'f':
+			if len(b) < 4 {
+				return nil, errors.New("truncated float32 argument")
+			}
+			m.Args = append(m.Args, math.Float32frombits(binary.BigEndian.Uint32(b)))
+			b = b[4:]
+		case 's':
+			var s string
+			s, b, err = readString(b)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read string argument: %w", err)
+			}
+			m.Args = append(m.Args, s)
+		case 'T':
+			m.Args = append(m.Args, true)
+		case 'F':
+			m.Args = append(m.Args, false)
+		default:
+			return nil, fmt.Errorf("unsupported argument type %q", tag)
+		}
+	}
+
+	return m, nil
+}
+
+// readString reads a null-terminated string padded
+// to a multiple of four bytes, and returns the rest
+func readString(b []byte) (string, []byte, error) {
+	end := bytes.IndexByte(b, 0)
+	if end < 0 {
+		return "", nil, errors.New("unterminated string")
+	}
+
+	padded := (end + 4) &^ 3
+	if padded > len(b) {
+		return "", nil, errors.New("truncated string padding")
+	}
+
+	return string(b[:end]), b[padded:], nil
+}
+
+func writeString(buf *bytes.Buffer, s string) {
+	buf.WriteString(s)
+
+	// At least one null byte, then enough to
+	// pad to a multiple of four bytes
+	n := 4 - len(s)%4
+	buf.Write(make([]byte, n))
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const linkUsage = "usage: link|unlink input|output <bank>/<channel>"
+
+// linkCommand links or unlinks a pair of input or output channels.
+// Inputs can be given by the names in the config file.
+func linkCommand(linked bool, args []string) error {
+	if len(args) != 2 {
+		return usagef(linkUsage)
+	}
+
+	var kind string
+	switch args[0] {
+	case "input":
+		kind = motu.BankInput
+	case "output":
+		kind = motu.BankOutput
+	default:
+		return usagef(linkUsage)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	var bank, ch int
+	if kind == motu.BankInput {
+		input, err := findInput(cfg, args[1])
+		if err != nil {
+			return err
+		}
+		bank, ch = input.Bank, input.Channel
+	} else if bank, ch, err = parseChannel(args[1]); err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if err := m.SetLinked(kind, bank, ch, linked); err != nil {
+		return err
+	}
+
+	verb := "Linked"
+	if !linked {
+		verb = "Unlinked"
+	}
+	text := fmt.Sprintf("%s channels %d and %d", verb, ch, ch+1)
+	return printResult(map[string]any{"bank": bank, "channels": []int{ch, ch + 1}, "linked": linked}, text)
+}
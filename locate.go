@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/netip"
+	"strings"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// interfaceAddress returns where to find the interface: where
+// it was last found by UID if it has moved, or else the
+// configured address
+func interfaceAddress(cfg *Config) string {
+	if cfg.UID == "" {
+		return cfg.Address
+	}
+
+	st, err := loadState()
+	if err != nil || st.Address == "" {
+		return cfg.Address
+	}
+	return st.Address
+}
+
+// relocator returns a function for the client to call when the
+// interface can't be reached. It looks for the interface with the
+// configured UID using mDNS and then by scanning the configured
+// subnet, and saves where it was found for next time.
+func relocator(cfg *Config) func(context.Context) (string, error) {
+	return func(ctx context.Context) (string, error) {
+		slog.Info("Looking for the interface", "uid", cfg.UID)
+
+		address, err := findByUID(ctx, cfg.UID, cfg.Scan)
+		if err != nil {
+			slog.Warn("Failed to find the interface", "uid", cfg.UID, "err", err)
+			return "", err
+		}
+
+		slog.Info("Found the interface", "uid", cfg.UID, "address", address)
+
+		st, err := loadState()
+		if err == nil {
+			st.Address = address
+			err = st.save()
+		}
+		if err != nil {
+			slog.Warn("Failed to remember the interface's address", "err", err)
+		}
+
+		return address, nil
+	}
+}
+
+// findByUID returns the address of the interface with the given UID
+func findByUID(ctx context.Context, uid, subnet string) (string, error) {
+	interfaces, err := motu.Discover(motu.DefaultDiscoveryTimeout)
+	if err != nil {
+		slog.Debug("Failed to discover interfaces", "err", err)
+	}
+	for _, iface := range interfaces {
+		if strings.EqualFold(iface.UID, uid) {
+			return iface.Address, nil
+		}
+	}
+
+	if subnet == "" {
+		return "", fmt.Errorf("no interface with UID %s answered mDNS", uid)
+	}
+
+	prefix, err := netip.ParsePrefix(subnet)
+	if err != nil {
+		return "", fmt.Errorf("invalid scan subnet: %w", err)
+	}
+
+	results, err := motu.Scan(ctx, prefix, 80, motu.DefaultScanTimeout)
+	if err != nil {
+		return "", fmt.Errorf("failed to scan %s: %w", subnet, err)
+	}
+	for _, r := range results {
+		if strings.EqualFold(r.UID, uid) {
+			return r.Address, nil
+		}
+	}
+
+	return "", fmt.Errorf("no interface with UID %s answered mDNS or was found in %s", uid, subnet)
+}
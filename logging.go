@@ -0,0 +1,44 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logFormat is how the daemons write their logs: "text" or "json".
+// It's set by --log-format, which can be given before any command,
+// and defaults to $MOTU_LOG_FORMAT.
+var logFormat = os.Getenv("MOTU_LOG_FORMAT")
+
+// logLevel is the least severe level that is logged: "debug",
+// "info", "warn" or "error". It's set by --log-level, which can be
+// given before any command, and defaults to $MOTU_LOG_LEVEL.
+var logLevel = os.Getenv("MOTU_LOG_LEVEL")
+
+// setupLogging sends logs to stderr, so that they don't
+// get mixed up with the output of commands
+func setupLogging() error {
+	var level slog.Level
+	if logLevel != "" {
+		if err := level.UnmarshalText([]byte(logLevel)); err != nil {
+			return fmt.Errorf("invalid log level %q", logLevel)
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(logFormat) {
+	case "", "text":
+		handler = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		handler = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("invalid log format %q: must be text or json", logFormat)
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
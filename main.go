@@ -1,312 +1,680 @@
 package main
 
 import (
-	"encoding/json"
+	"errors"
+	"flag"
 	"fmt"
 	"io"
-	"math"
-	"net/http"
-	"net/url"
+	"log/slog"
 	"os"
-	"os/exec"
+	"strconv"
 	"strings"
+	"text/tabwriter"
 	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+	"github.com/jakewright/motu-tools/motu/motutest"
 )
 
 const (
-	// Network address of the Motu interface
-	motuAddress = "192.168.88.251"
+// motuPropertyPhonesTrim = "datastore/ext/obank/0/ch/0/stereoTrim""
+// motuPropertyFaderMain  = "datastore/mix/main/0/matrix/fader"
+)
+
+// target is the name of the interface to use from the config file's
+// targets, or of a group of them. It's set by --target, which can be
+// given before any command, and defaults to $MOTU_TARGET.
+var target = os.Getenv("MOTU_TARGET")
+
+// password is for interfaces that protect the datastore. It's set by
+// --password, which can be given before any command, and defaults to
+// $MOTU_PASSWORD. Either takes precedence over the config file.
+var password = os.Getenv("MOTU_PASSWORD")
+
+// debug logs every request to the interface, and its response, to
+// stderr. It's set by --debug, which can be given before any command,
+// or by setting $MOTU_DEBUG.
+var debug = os.Getenv("MOTU_DEBUG") != ""
+
+// configFile is the config file to use instead of the default. It's
+// set by --config, which can be given before any command, and
+// defaults to $MOTU_CONFIG.
+var configFile = os.Getenv("MOTU_CONFIG")
+
+// jsonOutput makes commands that can print JSON do so. It's set by
+// --json, which can be given before any command, or after the
+// commands that support it.
+var jsonOutput bool
+
+// record and replay name fixture files to record requests to the
+// interface to, or to answer them from instead of the interface.
+// They're set by --record and --replay, which can be given before
+// any command, and default to $MOTU_RECORD and $MOTU_REPLAY.
+var (
+	record = os.Getenv("MOTU_RECORD")
+	replay = os.Getenv("MOTU_REPLAY")
+)
 
-	// How many steps between min and max
-	volumeDenominations = 16
+func main() {
+	os.Args = extractGlobalFlags(os.Args)
 
-	// The type of scale used by the property
-	scaleLinear = "linear"
-	scaleLog    = "log"
+	if err := setupLogging(); err != nil {
+		exit(err)
+	}
 
-	volumeSound = "/System/Library/LoginPlugins/BezelServices.loginPlugin/Contents/Resources/volume.aiff"
-)
+	if len(os.Args) < 2 {
+		exit(helpCommand(nil))
+		os.Exit(2)
+	}
 
-type Device struct {
-	// The property that controls the gain of this property
-	Property string
+	switch {
+	case os.Args[1] == completeCommand:
+		exit(completionCommand(os.Args[1:]))
+		return
+	case os.Args[1] == "help" || os.Args[1] == "completion":
+		// These don't talk to an interface, so they
+		// aren't run once for each target in a group
+		exit(findCommand(os.Args[1]).run(os.Args[2:]))
+		return
+	case wantsHelp(os.Args[2:]) && findCommand(os.Args[1]) != nil:
+		exit(helpCommand(os.Args[1:2]))
+		return
+	}
+
+	// Commands for a group of targets are run once for each target
+	targets, err := groupTargets()
+	if err != nil {
+		exit(err)
+	}
+	if targets != nil {
+		exit(fanOut(targets, os.Args[1:]))
+		return
+	}
 
-	// The property that controls whether this device is muted
-	MuteProperty string
+	if !unrecorded[os.Args[1]] {
+		recording = &recorder{}
+	}
 
-	// Type of scale (linear or logarithmic)
-	Scale string
+	if c := findCommand(os.Args[1]); c != nil {
+		err = c.run(os.Args[2:])
+	} else {
+		err = deviceCommand(os.Args[1:])
+	}
 
-	// Allowed range of values.
-	// If scale is log, these are values in dB (as displayed in the MOTU UI).
-	Max float64
-	Min float64
+	// The cache is only a shortcut, so failing
+	// to save it doesn't fail the command
+	_ = saveCache()
 
-	// Once Min is reached, we skip straight to zero volume.
-	// If scale is log, this is NOT dB but instead the amplitude ratio value
-	ZeroVolume float64
-}
+	// Changes are recorded even if the command failed part way through
+	if herr := saveHistory(os.Args[1:]); herr != nil && err == nil {
+		err = fmt.Errorf("failed to save history: %w", herr)
+	}
 
-var devices = map[string]*Device{
-	"main": {
-		Property:     "datastore/ext/obank/1/ch/0/stereoTrim",
-		MuteProperty: "datastore/mix/main/0/matrix/mute", // 0.0 (unmuted) or 1.0 (muted)
-		Scale:        scaleLinear,
-		Max:          0,
-		Min:          -50,
-		ZeroVolume:   -127,
-	},
-	"computer": {
-		Property:     "datastore/mix/chan/10/matrix/fader",
-		MuteProperty: "datastore/mix/chan/10/matrix/mute",
-		Scale:        scaleLog,
-		Max:          0,
-		Min:          -64,
-		ZeroVolume:   0,
-	},
+	exit(err)
 }
 
-const (
-// motuPropertyPhonesTrim = "datastore/ext/obank/0/ch/0/stereoTrim""
-// motuPropertyFaderMain  = "datastore/mix/main/0/matrix/fader"
-)
+// exit reports the error, if there is one, and exits with the
+// status for it. With --json, the error is printed to stdout as
+// JSON, so that scripts only need to read one stream.
+func exit(err error) {
+	if err == nil {
+		return
+	}
+	status := exitStatus(err)
 
-func main() {
-	if len(os.Args) < 3 {
-		fmt.Printf("Not enough arguments\n")
-		os.Exit(1)
+	var serr *statusError
+	if errors.As(err, &serr) && serr.reported {
+		os.Exit(status)
+	}
+
+	var hint string
+	switch {
+	case motu.NoSuchHost(err):
+		hint = "Check the host name in the config file. Names ending in .local need mDNS, which on Linux means nss-mdns; otherwise use the IP address, which \"motu scan\" can find."
+	case errors.Is(err, motu.ErrDeviceUnreachable):
+		hint = "Check that the interface is on and its address is right, or run \"motu discover\""
+	case errors.Is(err, motu.ErrUnauthorized):
+		hint = "Set the interface's password in the config file, $MOTU_PASSWORD or --password"
+	case errors.Is(err, motu.ErrPropertyNotFound):
+		hint = "Check the property paths in the config file. \"motu find\" searches the datastore."
+	}
+
+	if jsonOutput {
+		_ = printJSON(&jsonError{Error: err.Error(), Hint: hint, Status: status})
+		os.Exit(status)
 	}
 
-	m, err := NewFromIPAddress(motuAddress)
+	// Log collectors expect every line to be JSON
+	if strings.EqualFold(logFormat, "json") {
+		if hint == "" {
+			slog.Error(err.Error(), "exit_status", status)
+		} else {
+			slog.Error(err.Error(), "hint", hint, "exit_status", status)
+		}
+		os.Exit(status)
+	}
+
+	fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+	if hint != "" {
+		fmt.Fprintln(os.Stderr, hint)
+	}
+
+	os.Exit(status)
+}
+
+// deviceCommand runs a command against one of the configured
+// devices. args[0] is the device name and args[1] is the command.
+func deviceCommand(args []string) error {
+	cfg, err := readConfig()
 	if err != nil {
-		fmt.Printf("Failed to create client: %v\n", err)
-		os.Exit(1)
+		return err
 	}
 
-	d, ok := devices[os.Args[1]]
+	if lines, ok := cfg.Aliases[args[0]]; ok {
+		if len(args) > 1 {
+			return usagef("aliases don't take arguments")
+		}
+		return runAlias(cfg, lines)
+	}
+
+	d, ok := cfg.Devices[args[0]]
 	if !ok {
-		fmt.Printf("Unknown device: %s\n", os.Args[1])
-		os.Exit(1)
+		return unknownf("unknown command or device: %s (run \"motu help\" for a list of commands)", args[0])
 	}
 
-	switch os.Args[2] {
+	if len(args) < 2 {
+		return usagef("usage: motu %s <command> (run \"motu help\" for a list of commands)", args[0])
+	}
 
-	case "mute":
-		err = m.Mute(d)
-	case "inc", "increment":
-		err = m.IncDec(d, true)
-	case "dec", "decrement":
-		err = m.IncDec(d, false)
-	default:
-		fmt.Printf("Unrecongised command: %s\n", os.Args[1])
-		os.Exit(1)
+	verb := findVerb(args[1])
+	if verb == nil {
+		return usagef("unknown command for device %s: %s (run \"motu help\" for a list of commands)", args[0], args[1])
+	}
+
+	// --json can come after the command, as well as before
+	verbArgs := make([]string, 0, len(args)-2)
+	for _, a := range args[2:] {
+		if a == "--json" || a == "-json" {
+			jsonOutput = true
+		} else {
+			verbArgs = append(verbArgs, a)
+		}
 	}
 
+	if wantsHelp(verbArgs) {
+		fmt.Printf("Usage: motu %s %s %s\n\n%s\n", args[0], verb.name, verb.args, verb.summary)
+		return nil
+	}
+
+	// Status has flags of its own and
+	// sets up its own config and client
+	if verb.name == "status" {
+		return statusCommand(args[0], verbArgs)
+	}
+
+	m, err := newClient(cfg)
 	if err != nil {
-		fmt.Printf("Error: %v\n", err)
-		os.Exit(1)
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch verb.name {
+	case "mute":
+		var state string
+		if len(verbArgs) > 0 {
+			state = verbArgs[0]
+		}
+		err = mute(m, d, state)
+	case "dim":
+		var state string
+		if len(verbArgs) > 0 {
+			state = verbArgs[0]
+		}
+		err = dim(m, cfg, args[0], state)
+	case "inc", "dec", "set":
+		err = changeLevel(m, cfg, args[0], verb.name, verbArgs)
+	default: // fade
+		err = fade(m, d, verbArgs)
+	}
+	if err != nil || !jsonOutput {
+		return err
+	}
+
+	return printDeviceStatus(m, args[0], d)
+}
+
+// parseFlags parses flags that may appear before, after or in between
+// positional arguments, and returns the positional arguments. Negative
+// numbers are treated as positional arguments rather than flags.
+func parseFlags(flags *flag.FlagSet, args []string) ([]string, error) {
+	var flagArgs, positional []string
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+
+		if arg == "--" {
+			positional = append(positional, args[i+1:]...)
+			break
+		}
+
+		if !strings.HasPrefix(arg, "-") || arg == "-" || isNumber(arg) {
+			positional = append(positional, arg)
+			continue
+		}
+
+		flagArgs = append(flagArgs, arg)
+
+		name := strings.TrimLeft(arg, "-")
+		if strings.Contains(name, "=") {
+			continue
+		}
+
+		// Unknown flags are left for Parse to report
+		f := flags.Lookup(name)
+		if f == nil {
+			continue
+		}
+
+		// Everything other than a boolean flag
+		// takes the next argument as its value
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); ok && b.IsBoolFlag() {
+			continue
+		}
+		if i+1 < len(args) {
+			i++
+			flagArgs = append(flagArgs, args[i])
+		}
+	}
+
+	if err := parseFlagSet(flags, flagArgs); err != nil {
+		return nil, err
 	}
+
+	return positional, nil
 }
 
-type MotuClient struct {
-	MOTUAddress *url.URL
-	HTTPClient  *http.Client
+// parseFlagSet parses args with flags, returning a mistake in them
+// as a usage error rather than exiting, so that one bad line doesn't
+// end a batch or the REPL. Flag sets are made with ContinueOnError.
+func parseFlagSet(flags *flag.FlagSet, args []string) error {
+	// The error is printed with the others
+	flags.SetOutput(io.Discard)
+
+	err := flags.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return usagef("run \"motu help\" for the usage of %s", flags.Name())
+	} else if err != nil {
+		return usagef("%s: %v", flags.Name(), err)
+	}
+	return nil
 }
 
-func NewFromIPAddress(ip string) (*MotuClient, error) {
-	addr, err := url.Parse(fmt.Sprintf("http://%s", ip))
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse URL: %w", err)
+func isNumber(s string) bool {
+	_, err := strconv.ParseFloat(strings.TrimSuffix(s, "%"), 64)
+	return err == nil
+}
+
+// extractGlobalFlags removes the global flags, such as --target and
+// --debug, from the start of the arguments and sets them, so that they work the
+// same for every command
+func extractGlobalFlags(args []string) []string {
+	globals := map[string]*string{
+		"config":     &configFile,
+		"target":     &target,
+		"password":   &password,
+		"log-format": &logFormat,
+		"log-level":  &logLevel,
+		"record":     &record,
+		"replay":     &replay,
+	}
+
+	for len(args) > 1 {
+		if args[1] == "--debug" || args[1] == "-debug" {
+			debug = true
+			args = append(args[:1], args[2:]...)
+			continue
+		}
+		if args[1] == "--json" || args[1] == "-json" {
+			jsonOutput = true
+			args = append(args[:1], args[2:]...)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimLeft(args[1], "-"), "=")
+		v, ok := globals[name]
+		if !ok || !strings.HasPrefix(args[1], "-") {
+			return args
+		}
+
+		if hasValue {
+			*v = value
+			args = append(args[:1], args[2:]...)
+		} else if len(args) > 2 {
+			*v = args[2]
+			args = append(args[:1], args[3:]...)
+		} else {
+			return args
+		}
 	}
 
-	return &MotuClient{
-		MOTUAddress: addr,
-		HTTPClient: &http.Client{
-			Timeout: time.Second * 3,
-		},
-	}, nil
+	return args
 }
 
-func (m *MotuClient) Mute(d *Device) error {
-	current, err := m.get(d.MuteProperty)
+func readConfig() (*Config, error) {
+	if batch != nil && batch.cfg != nil {
+		return batch.cfg, nil
+	}
+
+	path, err := configPath()
 	if err != nil {
-		return fmt.Errorf("failed to get current value: %w", err)
+		return nil, fmt.Errorf("failed to find config: %w", err)
 	}
 
-	var newValue float64 = 0
-	switch current {
-	case 0:
-		newValue = 1
-	case 1: // Ok
-	default:
-		return fmt.Errorf("unexpected current mute value: %f", current)
+	cfg, err := loadConfig(path, target)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	if err := m.patch(d.MuteProperty, newValue); err != nil {
-		return fmt.Errorf("failed to update property: %w", err)
+	if cfg, err = useProfile(path, cfg); err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
 
-	return nil
+	if batch != nil {
+		batch.cfg = cfg
+	}
+	return cfg, nil
 }
 
-func (m *MotuClient) IncDec(d *Device, inc bool) error {
-	current, err := m.get(d.Property)
+// newClient connects to the interface named in the config,
+// either by discovering it on the network or by its address.
+// Reads are served from the cache while it's fresh. If
+// auto_devices is set, the device list is filled in from
+// the interface's channels.
+func newClient(cfg *Config) (*motu.Client, error) {
+	if batch != nil && batch.client != nil {
+		return batch.client, nil
+	}
+
+	var m *motu.Client
+	var err error
+	if cfg.Discover != "" {
+		m, err = motu.NewFromDiscovery(cfg.Discover)
+	} else {
+		m, err = motu.NewFromAddress(interfaceAddress(cfg))
+	}
 	if err != nil {
-		return fmt.Errorf("failed to get current value: %w", err)
+		return nil, err
 	}
 
-	var newValue float64
-	switch d.Scale {
-	case scaleLinear:
-		newValue = m.newVolumeLinear(d, current, inc)
-	case scaleLog:
-		newValue = m.newVolumeLog(d, current, inc)
-	default:
-		panic("unknown scale")
+	// Fixtures are tied to an address, so don't go looking elsewhere
+	if cfg.UID != "" && record == "" && replay == "" {
+		m.Relocate = relocator(cfg)
 	}
 
-	if err := m.patch(d.Property, newValue); err != nil {
-		return fmt.Errorf("failed to update property: %w", err)
+	if password != "" {
+		cfg.Password = password
+	}
+	if cfg.Password != "" {
+		m.SetPassword(cfg.Username, cfg.Password)
 	}
 
-	if err := playSound(); err != nil {
-		return fmt.Errorf("failed to play sound: %w", err)
+	if cfg.Timeout != 0 {
+		m.HTTPClient.Timeout = cfg.Timeout
+	}
+	switch {
+	case record != "" && replay != "":
+		return nil, fmt.Errorf("can't record and replay at the same time")
+	case record != "":
+		r, err := motutest.NewRecorder(record, m.HTTPClient.Transport)
+		if err != nil {
+			return nil, err
+		}
+		m.HTTPClient.Transport = r
+	case replay != "":
+		r, err := motutest.NewReplayer(replay)
+		if err != nil {
+			return nil, err
+		}
+		m.HTTPClient.Transport = r
+	}
+	if debug {
+		m.HTTPClient.Transport = &motu.DebugTransport{
+			Base: m.HTTPClient.Transport,
+			Out:  os.Stderr,
+		}
+	}
+	if cfg.Retries != nil {
+		m.Retries = *cfg.Retries
 	}
 
-	return nil
-}
+	if cfg.AuditLog != "" {
+		if audit, err = newAuditLog(cfg.AuditLog); err != nil {
+			return nil, err
+		}
+	}
 
-func (m *MotuClient) newVolumeLinear(d *Device, current float64, inc bool) float64 {
-	delta := (d.Max - d.Min) / volumeDenominations
+	// Knowing what changed can mean reading values before
+	// changing them, so only ask if something needs to know.
+	// Commands later in a batch might.
+	if recording != nil || audit != nil || batch != nil {
+		m.OnChange = func(c motu.Change) {
+			if recording != nil {
+				recording.record(c)
+			}
+			if audit != nil {
+				audit.record(c)
+			}
+		}
+	}
 
-	var newVolume float64
-	if inc {
-		newVolume = math.Ceil(current) + delta
-	} else {
-		newVolume = math.Ceil(current) - delta
+	// Cached values would leave requests out of fixtures
+	if *cfg.CacheMaxAge > 0 && record == "" && replay == "" {
+		cache = loadCache(*cfg.CacheMaxAge)
+		m.UseCache(cache)
 	}
 
-	// Go straight to mute once we reach min volume to avoid the
-	// range of volumes being skewed towards the barely-audible range
-	if !inc && newVolume <= d.Min {
-		return d.ZeroVolume
+	if cfg.AutoDevices {
+		if err := addChannelDevices(m, cfg); err != nil {
+			return nil, err
+		}
 	}
 
-	// Keep the volume within the bounds
-	return math.Min(math.Max(newVolume, d.Min), d.Max)
+	if batch != nil {
+		batch.client = m
+	}
+	return m, nil
 }
 
-func (m *MotuClient) newVolumeLog(d *Device, current float64, inc bool) float64 {
-	// Convert the amplitude ratio value to a decibel value
-	// https://en.wikipedia.org/wiki/Decibel
-	currentDB := 10 * math.Log10(math.Pow(current, 2))
+func discover() error {
+	interfaces, err := motu.Discover(motu.DefaultDiscoveryTimeout)
+	if err != nil {
+		return err
+	}
 
-	delta := (d.Max - d.Min) / volumeDenominations
+	if jsonOutput {
+		result := make([]map[string]string, 0, len(interfaces))
+		for _, iface := range interfaces {
+			result = append(result, map[string]string{
+				"name":    iface.Name,
+				"uid":     iface.UID,
+				"address": iface.Address,
+			})
+		}
+		return printJSON(result)
+	}
 
-	var newDB float64
-	if inc {
-		newDB = math.Ceil(currentDB) + delta
-	} else {
-		newDB = math.Ceil(currentDB) - delta
+	if len(interfaces) == 0 {
+		fmt.Printf("No devices found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "NAME\tUID\tADDRESS\n")
+	for _, iface := range interfaces {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", iface.Name, iface.UID, iface.Address)
 	}
 
-	// Go straight to mute once we reach min volume to avoid the
-	// range of volumes being skewed towards the barely-audible range
-	if !inc && newDB <= d.Min {
-		if d.ZeroVolume != 0 {
-			panic("logarithmic zero volume should be zero")
+	return w.Flush()
+}
+
+// mute turns the device's mute on or off, or toggles it if
+// state is "toggle" or empty, and prints the resulting state
+func mute(m *motu.Client, d *motu.Device, state string) error {
+	var muted bool
+	switch state {
+	case "", "toggle":
+		var err error
+		if muted, err = m.Mute(d); err != nil {
+			return err
+		}
+	case "on":
+		muted = true
+		if err := m.SetMute(d, true); err != nil {
+			return err
 		}
-		return d.ZeroVolume
+	case "off":
+		if err := m.SetMute(d, false); err != nil {
+			return err
+		}
+	default:
+		return usagef("usage: <device> mute [on|off|toggle]")
 	}
 
-	// Keep the volume within the bounds
-	newDB = math.Min(math.Max(newDB, d.Min), d.Max)
+	// With --json, the device's status is printed instead
+	if jsonOutput {
+		return nil
+	}
 
-	// Convert back to amplitude ratio and bound to [0, 1]
-	newAmpRatio := math.Sqrt(math.Pow(10, newDB/10))
-	return math.Min(math.Max(newAmpRatio, 0), 1)
+	if muted {
+		fmt.Println("muted")
+	} else {
+		fmt.Println("unmuted")
+	}
+
+	return nil
 }
 
-func (m *MotuClient) get(property string) (float64, error) {
-	rsp, err := m.HTTPClient.Get(m.MOTUAddress.JoinPath(property).String())
+// How many segments make up the level bar printed after a change
+const terminalBarWidth = 20
+
+// changeLevel runs inc, dec or set against a device. When run from a
+// terminal, the new level is then printed as a bar unless --quiet is
+// given. inc and dec take --step to override the device's step size,
+// and --fine to move by half a step.
+func changeLevel(m *motu.Client, cfg *Config, name, command string, args []string) error {
+	flags := flag.NewFlagSet(command, flag.ContinueOnError)
+	quiet := flags.Bool("quiet", false, "don't print the new level")
+	flags.BoolVar(quiet, "q", false, "shorthand for --quiet")
+	stepFlag := flags.String("step", "", "size of the step, e.g. 2dB, instead of the device's")
+	fine := flags.Bool("fine", false, "move by half a step")
+	positional, err := parseFlags(flags, args)
 	if err != nil {
-		return 0, fmt.Errorf("failed to get property value: %w", err)
+		return err
 	}
 
-	defer rsp.Body.Close()
+	d := cfg.Devices[name]
 
-	body, err := io.ReadAll(rsp.Body)
-	if err != nil {
-		return 0, fmt.Errorf("failed to read body: %w", err)
+	// A copy of the device with the step to take, so
+	// that tapers still apply when it's made finer
+	stepping := *d
+	if *stepFlag != "" {
+		stepping.StepDB, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(*stepFlag), "db"), 64)
+		if err != nil || stepping.StepDB <= 0 {
+			return usagef("invalid step: %s", *stepFlag)
+		}
+	}
+	if *fine {
+		stepping.Steps *= 2
+		stepping.StepDB /= 2
 	}
 
-	// The default HTTP client's Transport may not
-	// reuse HTTP/1.x "keep-alive" TCP connections if the
-	// Body is not read to completion and closed.
-	// See: https://golang.org/pkg/net/http/#Response
-	defer func() {
-		if rsp.Body != nil {
-			_, _ = io.Copy(io.Discard, rsp.Body)
-			_ = rsp.Body.Close()
+	var value float64
+	switch command {
+	case "inc":
+		value, err = incDec(m, cfg, name, &stepping, true)
+	case "dec":
+		value, err = incDec(m, cfg, name, &stepping, false)
+	case "set":
+		if len(positional) < 1 {
+			return usagef("usage: <device> set <dB|percent%%> [--quiet]")
 		}
-	}()
+		value, err = setLevel(m, d, positional[0])
+	}
+	if err != nil {
+		return err
+	}
+
+	if !*quiet && !jsonOutput && isTerminal(os.Stdout) {
+		bar := levelBar(d.ToPercent(value), terminalBarWidth, "█", "░")
+		fmt.Printf("%s %s %s\n", name, bar, formatDB(d.ToDB(value)))
+	}
+
+	return nil
+}
 
-	type wrapper struct {
-		Value float64 `json:"value"`
+// setLevel sets the device to a level given either in dB
+// (e.g. "-12" or "-12dB") or as a percentage (e.g. "40%")
+func setLevel(m *motu.Client, d *motu.Device, level string) (float64, error) {
+	v, err := parseLevel(d, level)
+	if err != nil {
+		return 0, err
 	}
 
-	parsed := wrapper{}
-	if err := json.Unmarshal(body, &parsed); err != nil {
-		return 0, fmt.Errorf("failed to unmarshal response: %w", err)
+	if err := m.Set(d.Property, v); err != nil {
+		return 0, fmt.Errorf("failed to update property: %w", err)
 	}
 
-	return parsed.Value, nil
+	return v, nil
 }
 
-func (m *MotuClient) patch(property string, value float64) error {
-	// The API is cursed and wants the value to be formatted as JSON
-	// under the key "value", and then form-encoded.
-	form := url.Values{}
-	form.Add("json", fmt.Sprintf(`{"value": %f}`, value))
-
-	req, err := http.NewRequest(
-		http.MethodPatch,
-		m.MOTUAddress.JoinPath(property).String(),
-		strings.NewReader(form.Encode()),
-	)
+// fade ramps the device from its current level to the given level
+func fade(m *motu.Client, d *motu.Device, args []string) error {
+	flags := flag.NewFlagSet("fade", flag.ContinueOnError)
+	over := flags.Duration("over", 5*time.Second, "how long the fade takes")
+	positional, err := parseFlags(flags, args)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	if len(positional) != 1 {
+		return usagef("usage: <device> fade <dB|percent%%> [--over 5s]")
+	}
 
-	rsp, err := m.HTTPClient.Do(req)
+	v, err := parseLevel(d, positional[0])
 	if err != nil {
-		return fmt.Errorf("failed to make request: %w", err)
+		return err
 	}
 
-	// The default HTTP client's Transport may not
-	// reuse HTTP/1.x "keep-alive" TCP connections if the
-	// Body is not read to completion and closed.
-	// See: https://golang.org/pkg/net/http/#Response
-	defer func() {
-		if rsp.Body != nil {
-			_, _ = io.Copy(io.Discard, rsp.Body)
-			_ = rsp.Body.Close()
+	return m.Ramp([]motu.RampTarget{{Property: d.Property, To: v, Scale: d.Scale}}, *over)
+}
+
+// parseLevel converts a level given either in dB or as a
+// percentage to a value of the device's property
+func parseLevel(d *motu.Device, level string) (float64, error) {
+	if p, ok := strings.CutSuffix(level, "%"); ok {
+		percent, err := strconv.ParseFloat(p, 64)
+		if err != nil {
+			return 0, usagef("invalid percentage: %s", level)
 		}
-	}()
 
-	return nil
+		return d.FromPercent(percent), nil
+	}
+
+	db, err := strconv.ParseFloat(strings.TrimSuffix(strings.ToLower(level), "db"), 64)
+	if err != nil {
+		return 0, usagef("invalid level: %s", level)
+	}
+
+	return d.FromLevel(db), nil
 }
 
-func playSound() error {
-	// Apple does not define a value range for this, but it appears to accept
-	// 0=silent, 1=normal (default) and then up to 255=Very loud.
-	// Setting to higher than default so it's easier to hear over other audio.
-	volume := "2"
-	if err := exec.Command("afplay", "-v", volume, volumeSound).Run(); err != nil {
-		return fmt.Errorf("failed to run afplay: %w", err)
+// incDec moves a device's level by one of stepping's steps,
+// which is the device with the step size to use
+func incDec(m *motu.Client, cfg *Config, name string, stepping *motu.Device, inc bool) (float64, error) {
+	d := cfg.Devices[name]
+	value, err := m.IncDec(stepping, inc)
+	if err != nil {
+		return 0, err
 	}
 
-	return nil
+	feedback(cfg.Feedback, name, d, value)
+	return value, nil
 }
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// MediaKeysConfig has the daemon take over the keyboard's volume
+// and mute keys, which are otherwise handled by the system
+type MediaKeysConfig struct {
+	// The device that the keys control. Defaults to "main".
+	Device string `yaml:"device"`
+
+	// Only take over the keys while the system's sound output has
+	// this in its name, e.g. "UltraLite". Empty means always.
+	Output string `yaml:"output"`
+
+	// Don't show the system's volume HUD after each press
+	NoHUD bool `yaml:"no_hud"`
+}
+
+const defaultMediaKeysDevice = "main"
+
+func (mc *MediaKeysConfig) validate(devices map[string]*motu.Device) error {
+	if mc.Device == "" {
+		mc.Device = defaultMediaKeysDevice
+	}
+	if _, ok := devices[mc.Device]; !ok {
+		return fmt.Errorf("unknown device: %s", mc.Device)
+	}
+	return nil
+}
+
+type mediaKey int
+
+const (
+	mediaKeyVolumeUp mediaKey = iota
+	mediaKeyVolumeDown
+	mediaKeyMute
+)
+
+// mediaKeyPress is a media key going down, or repeating while it's held
+type mediaKeyPress struct {
+	key    mediaKey
+	repeat bool
+}
+
+// The number of segments in the volume HUD
+const hudSegments = 16
+
+// runMediaKeys changes the configured device when the media keys are
+// pressed. Volume presses go through the device's stepper, so they're
+// coalesced and accelerated like presses over HTTP.
+func (s *server) runMediaKeys(ctx context.Context) {
+	mc := s.cfg.MediaKeys
+
+	presses := make(chan mediaKeyPress, 16)
+	errs := make(chan error, 1)
+	go func() { errs <- listenMediaKeys(ctx, mc.Output, presses) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errs:
+			if err != nil {
+				slog.Error("Failed to listen for media keys", "err", err)
+			}
+			return
+		case p := <-presses:
+			if err := s.mediaKey(ctx, mc, p); err != nil {
+				slog.Error("Media key failed", "err", err)
+			}
+		}
+	}
+}
+
+// mediaKey handles one press of a media key
+func (s *server) mediaKey(ctx context.Context, mc *MediaKeysConfig, p mediaKeyPress) error {
+	d := s.devices[mc.Device]
+
+	switch p.key {
+	case mediaKeyVolumeUp, mediaKeyVolumeDown:
+		value, err := s.steppers[mc.Device].step(ctx, p.key == mediaKeyVolumeUp)
+		if err != nil {
+			return fmt.Errorf("failed to change level: %w", err)
+		}
+
+		go feedback(s.cfg.Feedback, mc.Device, d, value)
+
+		if !mc.NoHUD {
+			muted, err := s.client.MutedContext(ctx, d)
+			if err != nil {
+				return fmt.Errorf("failed to get mute state: %w", err)
+			}
+			showVolumeHUD(d.ToPercent(value), muted)
+		}
+
+	case mediaKeyMute:
+		if p.repeat {
+			return nil
+		}
+
+		s.mu.Lock()
+		muted, err := s.client.MuteContext(ctx, d)
+		s.mu.Unlock()
+		if err != nil {
+			return fmt.Errorf("failed to toggle mute: %w", err)
+		}
+
+		if !mc.NoHUD {
+			percent, err := s.client.PercentContext(ctx, d)
+			if err != nil {
+				return fmt.Errorf("failed to get level: %w", err)
+			}
+			showVolumeHUD(percent, muted)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,104 @@
+//go:build cgo
+
+package main
+
+/*
+#cgo LDFLAGS: -framework AppKit -framework CoreAudio
+#include <stdlib.h>
+
+int runMediaTap(void);
+char *defaultOutputName(void);
+void showVolumeHUD(int filled, int total, int muted);
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"runtime"
+	"strings"
+	"unsafe"
+)
+
+// Key types from IOKit's ev_keymap.h
+var darwinMediaKeys = map[C.int]mediaKey{
+	0: mediaKeyVolumeUp,
+	1: mediaKeyVolumeDown,
+	7: mediaKeyMute,
+}
+
+// The event tap calls back into goMediaKey, which can't be
+// given a closure, so the listener's state is kept here
+var (
+	darwinMediaOutput  string
+	darwinMediaPresses chan<- mediaKeyPress
+)
+
+// listenMediaKeys takes over the volume and mute keys with an event
+// tap, while the system's sound output has output in its name. Like
+// hotkeys, this needs input monitoring to be allowed in System Settings.
+func listenMediaKeys(ctx context.Context, output string, presses chan<- mediaKeyPress) error {
+	darwinMediaOutput = output
+	darwinMediaPresses = presses
+
+	errs := make(chan error, 1)
+	go func() {
+		// The run loop belongs to the thread
+		runtime.LockOSThread()
+		if C.runMediaTap() != 0 {
+			errs <- fmt.Errorf("failed to create event tap: allow input monitoring in System Settings")
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+//export goMediaKey
+func goMediaKey(key C.int, down C.int, repeat C.int) C.int {
+	k, ok := darwinMediaKeys[key]
+	if !ok || !systemOutputMatches() {
+		return 0
+	}
+
+	if down != 0 {
+		select {
+		case darwinMediaPresses <- mediaKeyPress{key: k, repeat: repeat != 0}:
+		default: // Still busy with earlier presses
+		}
+	}
+	return 1
+}
+
+// systemOutputMatches returns whether the keys should be taken over
+// for the system's sound output. It's looked up on every press, as
+// it changes whenever headphones or displays come and go.
+func systemOutputMatches() bool {
+	if darwinMediaOutput == "" {
+		return true
+	}
+
+	name := C.defaultOutputName()
+	if name == nil {
+		return false
+	}
+	defer C.free(unsafe.Pointer(name))
+
+	return strings.Contains(C.GoString(name), darwinMediaOutput)
+}
+
+// showVolumeHUD shows the level in the volume HUD
+// that the system shows for its own volume keys
+func showVolumeHUD(percent float64, muted bool) {
+	filled, mutedFlag := C.int(math.Round(percent*hudSegments/100)), C.int(0)
+	if muted {
+		filled, mutedFlag = 0, 1
+	}
+
+	C.showVolumeHUD(filled, hudSegments, mutedFlag)
+}
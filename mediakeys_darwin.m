@@ -0,0 +1,126 @@
+//go:build cgo
+
+#import <AppKit/AppKit.h>
+#import <CoreAudio/CoreAudio.h>
+#include "_cgo_export.h"
+
+// From IOKit/hidsystem/ev_keymap.h
+#define NX_SUBTYPE_AUX_CONTROL_BUTTONS 8
+#define NX_KEYTYPE_SOUND_UP 0
+#define NX_KEYTYPE_SOUND_DOWN 1
+#define NX_KEYTYPE_MUTE 7
+#define NX_KEYDOWN 0xA
+
+// The images that the volume HUD can show
+#define OSD_IMAGE_SPEAKER 3
+#define OSD_IMAGE_SPEAKER_MUTED 4
+
+static CFMachPortRef mediaTap;
+
+static CGEventRef mediaTapCallback(CGEventTapProxy proxy, CGEventType type, CGEventRef event, void *info) {
+	// macOS turns the tap off if it's slow, so turn it back on
+	if (type == kCGEventTapDisabledByTimeout || type == kCGEventTapDisabledByUserInput) {
+		CGEventTapEnable(mediaTap, true);
+		return event;
+	}
+
+	@autoreleasepool {
+		NSEvent *e = [NSEvent eventWithCGEvent:event];
+		if (e.type != NSEventTypeSystemDefined || e.subtype != NX_SUBTYPE_AUX_CONTROL_BUTTONS) {
+			return event;
+		}
+
+		int key = (e.data1 & 0xFFFF0000) >> 16;
+		int state = (e.data1 & 0xFF00) >> 8;
+		int repeat = e.data1 & 0x1;
+		if (key != NX_KEYTYPE_SOUND_UP && key != NX_KEYTYPE_SOUND_DOWN && key != NX_KEYTYPE_MUTE) {
+			return event;
+		}
+
+		// Taken over keys are swallowed, both going down and up,
+		// so that CoreAudio doesn't change the system volume too
+		if (goMediaKey(key, state == NX_KEYDOWN, repeat)) {
+			return NULL;
+		}
+		return event;
+	}
+}
+
+int runMediaTap(void) {
+	mediaTap = CGEventTapCreate(kCGSessionEventTap, kCGHeadInsertEventTap, kCGEventTapOptionDefault,
+		CGEventMaskBit(NSEventTypeSystemDefined), mediaTapCallback, NULL);
+	if (!mediaTap) {
+		return -1;
+	}
+
+	CFRunLoopSourceRef source = CFMachPortCreateRunLoopSource(kCFAllocatorDefault, mediaTap, 0);
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), source, kCFRunLoopCommonModes);
+	CGEventTapEnable(mediaTap, true);
+	CFRunLoopRun();
+	return 0;
+}
+
+// defaultOutputName returns the name of the system's sound output,
+// or NULL if it can't be found. The caller frees it.
+char *defaultOutputName(void) {
+	AudioObjectPropertyAddress addr = {
+		kAudioHardwarePropertyDefaultOutputDevice,
+		kAudioObjectPropertyScopeGlobal,
+		kAudioObjectPropertyElementMain,
+	};
+
+	AudioDeviceID device;
+	UInt32 size = sizeof(device);
+	if (AudioObjectGetPropertyData(kAudioObjectSystemObject, &addr, 0, NULL, &size, &device) != noErr) {
+		return NULL;
+	}
+
+	addr.mSelector = kAudioObjectPropertyName;
+	CFStringRef name = NULL;
+	size = sizeof(name);
+	if (AudioObjectGetPropertyData(device, &addr, 0, NULL, &size, &name) != noErr || name == NULL) {
+		return NULL;
+	}
+
+	char buf[256];
+	Boolean ok = CFStringGetCString(name, buf, sizeof(buf), kCFStringEncodingUTF8);
+	CFRelease(name);
+	return ok ? strdup(buf) : NULL;
+}
+
+// The private class that draws the system's volume HUD
+@protocol OSDManager
+- (void)showImage:(long long)image
+      onDisplayID:(CGDirectDisplayID)display
+         priority:(unsigned int)priority
+    msecUntilFade:(unsigned int)msec
+   filledChiclets:(unsigned int)filled
+    totalChiclets:(unsigned int)total
+           locked:(BOOL)locked;
+@end
+
+// showVolumeHUD shows the system's volume HUD on the main display.
+// It's a private framework, so if it's missing nothing is shown.
+void showVolumeHUD(int filled, int total, int muted) {
+	@autoreleasepool {
+		static BOOL loaded = NO;
+		if (!loaded) {
+			[[NSBundle bundleWithPath:@"/System/Library/PrivateFrameworks/OSD.framework"] load];
+			loaded = YES;
+		}
+
+		Class manager = NSClassFromString(@"OSDManager");
+		if (manager == nil || ![manager respondsToSelector:@selector(sharedManager)]) {
+			return;
+		}
+
+		id<OSDManager> osd = [manager performSelector:@selector(sharedManager)];
+		[osd showImage:(muted ? OSD_IMAGE_SPEAKER_MUTED : OSD_IMAGE_SPEAKER)
+			onDisplayID:CGMainDisplayID()
+			   priority:0x1f4
+		  msecUntilFade:1000
+		 filledChiclets:filled
+		  totalChiclets:total
+				 locked:NO];
+	}
+}
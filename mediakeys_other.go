@@ -0,0 +1,14 @@
+//go:build !(darwin && cgo)
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func listenMediaKeys(ctx context.Context, output string, presses chan<- mediaKeyPress) error {
+	return fmt.Errorf("media keys can only be taken over on macOS, built with cgo")
+}
+
+func showVolumeHUD(percent float64, muted bool) {}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const (
+	// The lowest level shown on a meter
+	meterFloorDB = -60
+
+	meterWidth = 30
+)
+
+var meterBanks = map[string]string{
+	"input":  motu.MeterInputs,
+	"output": motu.MeterOutputs,
+	"mix":    motu.MeterMix,
+}
+
+func metersCommand(args []string) error {
+	flags := flag.NewFlagSet("meters", flag.ContinueOnError)
+	interval := flags.Duration("interval", 100*time.Millisecond, "time between updates")
+	once := flags.Bool("once", false, "print the levels once and exit")
+	banks := flags.String("bank", "input,output,mix", "comma separated banks to show: input, output, mix")
+	asJSON := flags.Bool("json", jsonOutput, "print the levels as JSON lines, as amplitudes from 0 to 1")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	var names []string
+	for _, b := range strings.Split(*banks, ",") {
+		name, ok := meterBanks[strings.TrimSpace(b)]
+		if !ok {
+			return usagef("unknown meter bank: %s", b)
+		}
+		names = append(names, name)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	redraw := !*once && !*asJSON && isTerminal(os.Stdout)
+	enc := json.NewEncoder(os.Stdout)
+
+	for {
+		levels, err := m.Meters(names...)
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			if err := enc.Encode(levels); err != nil {
+				return fmt.Errorf("failed to write levels: %w", err)
+			}
+		} else {
+			var b strings.Builder
+			for _, bank := range names {
+				fmt.Fprintf(&b, "%s\n", bank)
+				for i, v := range levels[bank] {
+					fmt.Fprintf(&b, "  %3d %s %s\n", i, meterBar(v), formatDB(motu.AmplitudeToDB(v)))
+				}
+			}
+
+			if redraw {
+				// Move to the top left and clear the screen
+				fmt.Print("\033[H\033[2J")
+			}
+			fmt.Print(b.String())
+		}
+
+		if *once {
+			return nil
+		}
+
+		time.Sleep(*interval)
+	}
+}
+
+// meterBar draws a level as a bar from meterFloorDB to 0 dBFS
+func meterBar(v float64) string {
+	db := math.Max(motu.AmplitudeToDB(v), meterFloorDB)
+	filled := int(math.Round(meterWidth * (db - meterFloorDB) / -meterFloorDB))
+	return strings.Repeat("█", filled) + strings.Repeat("░", meterWidth-filled)
+}
+
+func formatDB(db float64) string {
+	if math.IsInf(db, -1) {
+		return "-inf dB"
+	}
+	return fmt.Sprintf("%.1f dB", db)
+}
+
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,365 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+
+	"gitlab.com/gomidi/midi/v2"
+	"gitlab.com/gomidi/midi/v2/drivers"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// MIDIConfig maps messages from a MIDI controller to properties
+type MIDIConfig struct {
+	// Name (or part of the name) of the port to receive from
+	Input string `yaml:"input"`
+
+	// Name of the port to send feedback to, e.g. for motorised
+	// faders or button LEDs. Feedback is disabled if empty.
+	Output string `yaml:"output"`
+
+	Mappings []*MIDIMapping `yaml:"mappings"`
+}
+
+// MIDIMapping connects a single control change or note to a property
+type MIDIMapping struct {
+	// MIDI channel, 1-16
+	Channel uint8 `yaml:"channel"`
+
+	// Either a controller number or a note number should be set
+	CC   *uint8 `yaml:"cc"`
+	Note *uint8 `yaml:"note"`
+
+	// A configured device to control
+	Device string `yaml:"device"`
+
+	// What to do to the device: "level" (default) or "mute"
+	Action string `yaml:"action"`
+
+	// Alternatively, a raw property whose value is scaled
+	// linearly from the controller's range to [Min, Max]
+	Property string  `yaml:"property"`
+	Min      float64 `yaml:"min"`
+	Max      float64 `yaml:"max"`
+}
+
+const (
+	midiActionLevel = "level"
+	midiActionMute  = "mute"
+)
+
+func midiCommand(args []string) error {
+	flags := flag.NewFlagSet("midi", flag.ContinueOnError)
+	list := flags.Bool("list", false, "list the available MIDI ports")
+	input := flags.String("in", "", "name of the MIDI input port (overrides config)")
+	output := flags.String("out", "", "name of the MIDI output port (overrides config)")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	if drivers.Get() == nil {
+		return fmt.Errorf("built without MIDI support: rebuild with -tags rtmidi")
+	}
+	defer midi.CloseDriver()
+
+	if *list {
+		fmt.Printf("Inputs:\n%s", midi.GetInPorts())
+		fmt.Printf("Outputs:\n%s", midi.GetOutPorts())
+		return nil
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.MIDI == nil || len(cfg.MIDI.Mappings) == 0 {
+		return fmt.Errorf("no MIDI mappings are configured")
+	}
+
+	if *input != "" {
+		cfg.MIDI.Input = *input
+	}
+	if *output != "" {
+		cfg.MIDI.Output = *output
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	b := &midiBridge{
+		client:   m,
+		devices:  cfg.Devices,
+		mappings: cfg.MIDI.Mappings,
+		mirror:   m.Sync(ctx),
+		lastIn:   map[*MIDIMapping]uint8{},
+	}
+
+	for _, mapping := range b.mappings {
+		if err := b.validate(mapping); err != nil {
+			return err
+		}
+	}
+
+	in, err := midi.FindInPort(cfg.MIDI.Input)
+	if err != nil {
+		return fmt.Errorf("failed to find MIDI input %q: %w", cfg.MIDI.Input, err)
+	}
+
+	if cfg.MIDI.Output != "" {
+		out, err := midi.FindOutPort(cfg.MIDI.Output)
+		if err != nil {
+			return fmt.Errorf("failed to find MIDI output %q: %w", cfg.MIDI.Output, err)
+		}
+
+		b.send, err = midi.SendTo(out)
+		if err != nil {
+			return fmt.Errorf("failed to open MIDI output: %w", err)
+		}
+
+		go b.feedback(ctx)
+	}
+
+	stop, err := midi.ListenTo(in, b.receive, midi.HandleError(func(err error) {
+		slog.Error("MIDI error", "err", err)
+	}))
+	if err != nil {
+		return fmt.Errorf("failed to listen to MIDI input: %w", err)
+	}
+	defer stop()
+
+	slog.Info("Listening for MIDI", "port", in.String())
+	<-ctx.Done()
+
+	return nil
+}
+
+// midiBridge translates between MIDI messages and properties
+type midiBridge struct {
+	client   *motu.Client
+	devices  map[string]*motu.Device
+	mappings []*MIDIMapping
+	mirror   *motu.Mirror
+
+	// Sends feedback to the controller. Nil if feedback is disabled.
+	send func(midi.Message) error
+
+	// The last value received for each mapping, used to avoid
+	// echoing a controller's own changes back to it
+	mu     sync.Mutex
+	lastIn map[*MIDIMapping]uint8
+}
+
+func (b *midiBridge) validate(mapping *MIDIMapping) error {
+	if (mapping.CC == nil) == (mapping.Note == nil) {
+		return fmt.Errorf("MIDI mapping must have exactly one of cc or note")
+	}
+
+	if mapping.Channel < 1 || mapping.Channel > 16 {
+		return fmt.Errorf("MIDI channel must be between 1 and 16")
+	}
+
+	if mapping.Device == "" {
+		if mapping.Property == "" {
+			return fmt.Errorf("MIDI mapping must have a device or a property")
+		}
+		// Feedback divides by the range, and a range of zero would
+		// pin the property to min wherever the control is
+		if mapping.Max <= mapping.Min {
+			return fmt.Errorf("MIDI mapping for %s must have a max greater than its min", mapping.Property)
+		}
+		return nil
+	}
+
+	d, ok := b.devices[mapping.Device]
+	if !ok {
+		return fmt.Errorf("unknown device in MIDI mapping: %s", mapping.Device)
+	}
+
+	switch mapping.Action {
+	case "", midiActionLevel:
+		if mapping.Note != nil {
+			return fmt.Errorf("MIDI notes can only be mapped to mute")
+		}
+	case midiActionMute:
+		if d.MuteProperty == "" {
+			return fmt.Errorf("device %s has no mute property", mapping.Device)
+		}
+	default:
+		return fmt.Errorf("unknown MIDI action: %s", mapping.Action)
+	}
+
+	return nil
+}
+
+// receive handles a message from the controller
+func (b *midiBridge) receive(msg midi.Message, _ int32) {
+	var channel, number, value uint8
+	isCC := msg.GetControlChange(&channel, &number, &value)
+	isNote := !isCC && msg.GetNoteStart(&channel, &number, &value)
+	if !isCC && !isNote {
+		return
+	}
+
+	for _, mapping := range b.mappings {
+		if mapping.Channel-1 != channel {
+			continue
+		}
+		if isCC && (mapping.CC == nil || *mapping.CC != number) {
+			continue
+		}
+		if isNote && (mapping.Note == nil || *mapping.Note != number) {
+			continue
+		}
+
+		b.mu.Lock()
+		b.lastIn[mapping] = value
+		b.mu.Unlock()
+
+		if err := b.apply(mapping, value); err != nil {
+			slog.Error("Failed to handle MIDI message", "message", msg.String(), "err", err)
+		}
+	}
+}
+
+// apply sets the mapped property from a 7-bit MIDI value
+func (b *midiBridge) apply(mapping *MIDIMapping, value uint8) error {
+	if mapping.Device == "" {
+		return b.client.Set(mapping.Property, mapping.Min+(mapping.Max-mapping.Min)*float64(value)/127)
+	}
+
+	d := b.devices[mapping.Device]
+
+	if mapping.Action == midiActionMute {
+		// Buttons send 127 when pressed and 0 when released.
+		// Only toggle on the press.
+		if value == 0 {
+			return nil
+		}
+		_, err := b.client.Mute(d)
+		return err
+	}
+
+	_, err := b.client.SetPercent(d, 100*float64(value)/127)
+	return err
+}
+
+// feedback sends the state of mapped properties back to the controller
+// whenever they change, so that faders and LEDs stay in sync with
+// changes made from the web UI or anywhere else
+func (b *midiBridge) feedback(ctx context.Context) {
+	changes, unsubscribe := b.mirror.Subscribe()
+	defer unsubscribe()
+
+	// Send everything once so the controller starts in the right state
+	for _, mapping := range b.mappings {
+		b.refresh(mapping)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case batch := <-changes:
+			for _, mapping := range b.mappings {
+				if _, ok := batch[motu.Key(b.property(mapping))]; ok {
+					b.refresh(mapping)
+				}
+			}
+		}
+	}
+}
+
+// refresh sends the current value of the mapping's property to the controller
+func (b *midiBridge) refresh(mapping *MIDIMapping) {
+	value, err := b.current(mapping)
+	if err != nil {
+		slog.Error("Failed to read property", "path", b.property(mapping), "err", err)
+		return
+	}
+
+	b.sendFeedback(mapping, value)
+}
+
+func (b *midiBridge) sendFeedback(mapping *MIDIMapping, value uint8) {
+	b.mu.Lock()
+	last, ok := b.lastIn[mapping]
+	b.mu.Unlock()
+
+	// Don't fight a fader that is being moved
+	if ok && last == value && mapping.Action != midiActionMute {
+		return
+	}
+
+	var msg midi.Message
+	if mapping.CC != nil {
+		msg = midi.ControlChange(mapping.Channel-1, *mapping.CC, value)
+	} else {
+		msg = midi.NoteOn(mapping.Channel-1, *mapping.Note, value)
+	}
+
+	if err := b.send(msg); err != nil {
+		slog.Error("Failed to send MIDI feedback", "err", err)
+	}
+}
+
+// property returns the property a mapping watches for feedback
+func (b *midiBridge) property(mapping *MIDIMapping) string {
+	if mapping.Device == "" {
+		return mapping.Property
+	}
+
+	d := b.devices[mapping.Device]
+	if mapping.Action == midiActionMute {
+		return d.MuteProperty
+	}
+	return d.Property
+}
+
+// current returns the mapped property's value as a 7-bit MIDI value
+func (b *midiBridge) current(mapping *MIDIMapping) (uint8, error) {
+	if mapping.Device == "" {
+		v, err := b.client.Get(mapping.Property)
+		if err != nil {
+			return 0, err
+		}
+		return clampMIDI(127 * (v - mapping.Min) / (mapping.Max - mapping.Min)), nil
+	}
+
+	d := b.devices[mapping.Device]
+
+	if mapping.Action == midiActionMute {
+		muted, err := b.client.Muted(d)
+		if err != nil {
+			return 0, err
+		}
+
+		// Light the button when muted
+		if muted {
+			return 127, nil
+		}
+		return 0, nil
+	}
+
+	percent, err := b.client.Percent(d)
+	if err != nil {
+		return 0, err
+	}
+	return clampMIDI(127 * percent / 100), nil
+}
+
+func clampMIDI(v float64) uint8 {
+	return uint8(math.Round(math.Min(math.Max(v, 0), 127)))
+}
@@ -0,0 +1,8 @@
+//go:build rtmidi
+
+package main
+
+// The rtmidi driver needs cgo and the platform's MIDI headers
+// (CoreMIDI on macOS, ALSA on Linux), so it's only included
+// when building with -tags rtmidi.
+import _ "gitlab.com/gomidi/midi/v2/drivers/rtmididrv"
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Matches the pan of a mixer channel, relative to the datastore root
+var mixerPanKey = regexp.MustCompile(`^mix/chan/\d+/matrix/pan$`)
+
+// monoCommand collapses the main mix to mono, or puts it back to
+// stereo. The values that are changed are kept in the state file
+// so that switching back restores them exactly.
+func monoCommand(args []string) error {
+	var state string
+	if len(args) > 0 {
+		state = args[0]
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	mono := st.Mono != nil
+
+	var on bool
+	switch state {
+	case "", "toggle":
+		on = !mono
+	case "on":
+		on = true
+	case "off":
+		on = false
+	default:
+		return usagef("usage: mono [on|off|toggle]")
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch {
+	case on && !mono:
+		values, err := monoValues(m, cfg)
+		if err != nil {
+			return err
+		}
+
+		previous := make(map[string]any, len(values))
+		for key := range values {
+			previous[key], err = m.Value(motu.Path(key))
+			if err != nil {
+				return fmt.Errorf("failed to read %s: %w", key, err)
+			}
+		}
+
+		if err := m.SetValues(values); err != nil {
+			return fmt.Errorf("failed to collapse to mono: %w", err)
+		}
+		st.Mono = previous
+
+	case !on && mono:
+		if err := m.SetValues(st.Mono); err != nil {
+			return fmt.Errorf("failed to restore stereo: %w", err)
+		}
+		st.Mono = nil
+	}
+
+	if err := st.save(); err != nil {
+		return err
+	}
+
+	text := "stereo"
+	if on {
+		text = "mono"
+	}
+	return printResult(map[string]bool{"mono": on}, text)
+}
+
+// monoValues returns the values to set to collapse the mix to mono,
+// keyed by path relative to the datastore root
+func monoValues(m *motu.Client, cfg *Config) (map[string]any, error) {
+	values := map[string]any{}
+
+	if len(cfg.Mono) > 0 {
+		for property, v := range cfg.Mono {
+			values[motu.Key(property)] = v
+		}
+		return values, nil
+	}
+
+	tree, err := m.GetTree("datastore/mix/chan")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read mixer channels: %w", err)
+	}
+
+	for key := range tree {
+		if mixerPanKey.MatchString(key) {
+			values[key] = 0.0
+		}
+	}
+
+	if len(values) == 0 {
+		return nil, fmt.Errorf("no mixer channel pans found")
+	}
+
+	return values, nil
+}
@@ -0,0 +1,149 @@
+package motu
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// NewFromAddress returns a client for the interface at address, which
+// is a host name or an IPv4 or IPv6 address, optionally with a port
+// and an http:// prefix, e.g. "192.168.88.251", "motu-828es.local",
+// "[fe80::1%en0]:8080" or "http://studio.lan". Host names are looked
+// up when the first request is made, not here.
+func NewFromAddress(address string) (*Client, error) {
+	host, err := parseAddress(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return newClient(&url.URL{Scheme: "http", Host: host}), nil
+}
+
+// parseAddress validates address and returns it
+// in the form used for the host of a URL
+func parseAddress(address string) (string, error) {
+	invalid := func(format string, a ...any) error {
+		return fmt.Errorf("invalid address %q: %s", address, fmt.Sprintf(format, a...))
+	}
+
+	s := strings.TrimSpace(address)
+	if s == "" {
+		return "", fmt.Errorf("no address given")
+	}
+
+	// Allow a URL pasted from the browser
+	if scheme, rest, ok := strings.Cut(s, "://"); ok {
+		if !strings.EqualFold(scheme, "http") {
+			return "", invalid("the interface only speaks http, not %s", scheme)
+		}
+		s = strings.TrimSuffix(rest, "/")
+		if strings.Contains(s, "/") {
+			return "", invalid("give just the host, without a path")
+		}
+	}
+
+	var host, port string
+	switch {
+	case strings.HasPrefix(s, "["):
+		// A bracketed IPv6 address, with or without a port
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return "", invalid("missing ]")
+		}
+		host = s[1:end]
+		if rest := s[end+1:]; rest != "" {
+			p, ok := strings.CutPrefix(rest, ":")
+			if !ok {
+				return "", invalid("unexpected %q after ]", rest)
+			}
+			port = p
+		}
+		if ip := net.ParseIP(stripZone(host)); ip == nil || ip.To4() != nil {
+			return "", invalid("%s isn't an IPv6 address", host)
+		}
+
+	case strings.Count(s, ":") > 1:
+		// A bare IPv6 address, which can't have a port
+		host = s
+		if net.ParseIP(stripZone(host)) == nil {
+			return "", invalid("not an IP address, and a host name can't contain colons; put IPv6 addresses with a port in brackets, e.g. [fe80::1]:80")
+		}
+
+	default:
+		var ok bool
+		host, port, ok = strings.Cut(s, ":")
+		if ok && port == "" {
+			return "", invalid("missing port after :")
+		}
+		if net.ParseIP(host) == nil {
+			if err := checkHostname(host); err != nil {
+				return "", invalid("%s", err)
+			}
+		}
+	}
+
+	if port != "" {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 {
+			return "", invalid("port must be a number from 1 to 65535")
+		}
+	}
+
+	if strings.Contains(host, ":") {
+		if port == "" {
+			return "[" + host + "]", nil
+		}
+		return net.JoinHostPort(host, port), nil
+	}
+	if port == "" {
+		return host, nil
+	}
+	return host + ":" + port, nil
+}
+
+// NoSuchHost returns whether err is from looking up a host name that
+// doesn't exist, as opposed to the interface not answering
+func NoSuchHost(err error) bool {
+	var dnsErr *net.DNSError
+	return errors.As(err, &dnsErr) && dnsErr.IsNotFound
+}
+
+// stripZone removes an IPv6 zone, e.g. "%en0"
+func stripZone(host string) string {
+	h, _, _ := strings.Cut(host, "%")
+	return h
+}
+
+// checkHostname returns an error if name isn't a valid host name
+func checkHostname(name string) error {
+	if name == "" {
+		return fmt.Errorf("missing host")
+	}
+	if len(name) > 253 {
+		return fmt.Errorf("host name is too long")
+	}
+
+	labels := strings.Split(strings.TrimSuffix(name, "."), ".")
+	if _, err := strconv.Atoi(labels[len(labels)-1]); err == nil {
+		return fmt.Errorf("%q isn't a valid IP address", name)
+	}
+
+	for _, label := range labels {
+		if label == "" || len(label) > 63 {
+			return fmt.Errorf("%q isn't a valid host name", name)
+		}
+		if label[0] == '-' || label[len(label)-1] == '-' {
+			return fmt.Errorf("%q isn't a valid host name: labels can't start or end with -", name)
+		}
+		for _, r := range label {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return fmt.Errorf("%q isn't a valid host name: %q isn't allowed", name, r)
+			}
+		}
+	}
+	return nil
+}
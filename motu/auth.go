@@ -0,0 +1,182 @@
+package motu
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// authenticator answers the interface's authentication challenge.
+// Interfaces that protect the datastore respond to requests without
+// credentials with 401 Unauthorized and a WWW-Authenticate header
+// saying whether they want Basic or Digest authentication.
+type authenticator struct {
+	username string
+	password string
+
+	mu        sync.Mutex
+	challenge map[string]string // nil until the interface asks for digest
+	basic     bool
+	count     int
+}
+
+// authorize adds credentials to the request, if the
+// interface has asked for them
+func (a *authenticator) authorize(req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch {
+	case a.basic:
+		req.SetBasicAuth(a.username, a.password)
+	case a.challenge != nil:
+		a.count++
+		req.Header.Set("Authorization", a.digest(req.Method, req.URL.RequestURI()))
+	}
+}
+
+// respond records the challenge in a 401 response. It returns false
+// if the challenge can't be answered, in which case there's no point
+// making the request again.
+func (a *authenticator) respond(rsp *http.Response) bool {
+	header := rsp.Header.Get("WWW-Authenticate")
+	scheme, params, _ := strings.Cut(header, " ")
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	switch strings.ToLower(scheme) {
+	case "basic":
+		// Already sent and rejected
+		if a.basic {
+			return false
+		}
+		a.basic = true
+		return true
+	case "digest":
+		challenge := parseAuthParams(params)
+
+		// A stale nonce can be retried with the new one, but
+		// anything else means the password was rejected
+		if a.challenge != nil && !strings.EqualFold(challenge["stale"], "true") {
+			return false
+		}
+
+		switch strings.ToUpper(challenge["algorithm"]) {
+		case "", "MD5", "SHA-256":
+		default:
+			return false
+		}
+
+		a.challenge = challenge
+		a.count = 0
+		return true
+	default:
+		return false
+	}
+}
+
+// digest returns an Authorization header for RFC 7616 digest
+// authentication. It must be called with mu held.
+func (a *authenticator) digest(method, uri string) string {
+	var newHash func() hash.Hash = md5.New
+	algorithm := a.challenge["algorithm"]
+	if strings.EqualFold(algorithm, "SHA-256") {
+		newHash = sha256.New
+	}
+
+	h := func(parts ...string) string {
+		sum := newHash()
+		sum.Write([]byte(strings.Join(parts, ":")))
+		return hex.EncodeToString(sum.Sum(nil))
+	}
+
+	realm, nonce := a.challenge["realm"], a.challenge["nonce"]
+	ha1 := h(a.username, realm, a.password)
+	ha2 := h(method, uri)
+
+	fields := []string{
+		fmt.Sprintf("username=%q", a.username),
+		fmt.Sprintf("realm=%q", realm),
+		fmt.Sprintf("nonce=%q", nonce),
+		fmt.Sprintf("uri=%q", uri),
+	}
+
+	// Only "auth" quality of protection is supported, and
+	// older servers might not ask for any at all
+	if qopAuth(a.challenge["qop"]) {
+		nc := fmt.Sprintf("%08x", a.count)
+		cnonce := newCNonce()
+		fields = append(fields,
+			"qop=auth",
+			"nc="+nc,
+			fmt.Sprintf("cnonce=%q", cnonce),
+			fmt.Sprintf("response=%q", h(ha1, nonce, nc, cnonce, "auth", ha2)),
+		)
+	} else {
+		fields = append(fields, fmt.Sprintf("response=%q", h(ha1, nonce, ha2)))
+	}
+
+	if algorithm != "" {
+		fields = append(fields, "algorithm="+algorithm)
+	}
+	if opaque, ok := a.challenge["opaque"]; ok {
+		fields = append(fields, fmt.Sprintf("opaque=%q", opaque))
+	}
+
+	return "Digest " + strings.Join(fields, ", ")
+}
+
+func qopAuth(qop string) bool {
+	for _, q := range strings.Split(qop, ",") {
+		if strings.TrimSpace(q) == "auth" {
+			return true
+		}
+	}
+	return false
+}
+
+func newCNonce() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// parseAuthParams parses the comma separated key=value pairs of
+// a WWW-Authenticate header. Values may be quoted, and quoted
+// values may contain commas.
+func parseAuthParams(s string) map[string]string {
+	params := map[string]string{}
+	for {
+		s = strings.TrimLeft(s, " ,")
+		key, rest, ok := strings.Cut(s, "=")
+		if !ok {
+			return params
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+
+		var value string
+		if strings.HasPrefix(rest, `"`) {
+			var b strings.Builder
+			i := 1
+			for ; i < len(rest) && rest[i] != '"'; i++ {
+				if rest[i] == '\\' && i+1 < len(rest) {
+					i++
+				}
+				b.WriteByte(rest[i])
+			}
+			value, s = b.String(), rest[min(i+1, len(rest)):]
+		} else {
+			value, s, _ = strings.Cut(rest, ",")
+			value = strings.TrimSpace(value)
+		}
+
+		params[key] = value
+	}
+}
@@ -0,0 +1,149 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Entity is an AVB device on the network, which may be
+// the interface itself or another device it can see
+type Entity struct {
+	// The entity ID, e.g. "0001f2fffe012345"
+	UID string
+
+	Name  string
+	Model string
+
+	// The streams the entity can receive and send
+	Inputs  []*Stream
+	Outputs []*Stream
+}
+
+// Stream is an AVB stream of an entity
+type Stream struct {
+	Name     string
+	Channels int
+}
+
+var streamKey = regexp.MustCompile(`^avb/([^/]+)/(input|output)/(\d+)/(name|num_ch)$`)
+
+// Entities returns every AVB entity the interface can see,
+// including itself, in order of name
+func (c *Client) Entities() ([]*Entity, error) {
+	return c.EntitiesContext(context.Background())
+}
+
+// EntitiesContext is like Entities but the request is cancelled with ctx
+func (c *Client) EntitiesContext(ctx context.Context) ([]*Entity, error) {
+	tree, err := c.GetTreeContext(ctx, "datastore/avb")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	return EntitiesFromTree(tree), nil
+}
+
+// EntitiesFromTree is like Entities but reads from values
+// that have already been fetched, e.g. by GetTree
+func EntitiesFromTree(tree map[string]any) []*Entity {
+	entities := map[string]*Entity{}
+	entity := func(uid string) *Entity {
+		e, ok := entities[uid]
+		if !ok {
+			e = &Entity{UID: uid}
+			entities[uid] = e
+		}
+		return e
+	}
+
+	// The device list is a colon separated list of UIDs
+	if devs, ok := tree["avb/devs"].(string); ok {
+		for _, uid := range strings.Split(devs, ":") {
+			if uid != "" {
+				entity(uid)
+			}
+		}
+	}
+
+	// Streams are numbered from zero, so collect them
+	// by index and put them in order afterwards
+	inputs := map[string]map[int]*Stream{}
+	outputs := map[string]map[int]*Stream{}
+
+	for k, v := range tree {
+		parts := strings.Split(k, "/")
+		if len(parts) == 3 && parts[0] == "avb" && parts[1] != "devs" {
+			s, _ := v.(string)
+			switch parts[2] {
+			case "entity_name":
+				entity(parts[1]).Name = s
+			case "model_name":
+				entity(parts[1]).Model = s
+			}
+			continue
+		}
+
+		m := streamKey.FindStringSubmatch(k)
+		if m == nil {
+			continue
+		}
+
+		entity(m[1])
+		streams := inputs
+		if m[2] == "output" {
+			streams = outputs
+		}
+		if streams[m[1]] == nil {
+			streams[m[1]] = map[int]*Stream{}
+		}
+
+		i, _ := strconv.Atoi(m[3])
+		s, ok := streams[m[1]][i]
+		if !ok {
+			s = &Stream{}
+			streams[m[1]][i] = s
+		}
+
+		switch m[4] {
+		case "name":
+			s.Name, _ = v.(string)
+		case "num_ch":
+			n, _ := v.(float64)
+			s.Channels = int(n)
+		}
+	}
+
+	var result []*Entity
+	for uid, e := range entities {
+		e.Inputs = orderStreams(inputs[uid])
+		e.Outputs = orderStreams(outputs[uid])
+		result = append(result, e)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Name != result[j].Name {
+			return result[i].Name < result[j].Name
+		}
+		return result[i].UID < result[j].UID
+	})
+
+	return result
+}
+
+func orderStreams(streams map[int]*Stream) []*Stream {
+	indexes := make([]int, 0, len(streams))
+	for i := range streams {
+		indexes = append(indexes, i)
+	}
+	sort.Ints(indexes)
+
+	result := make([]*Stream, len(indexes))
+	for n, i := range indexes {
+		result[n] = streams[i]
+	}
+	return result
+}
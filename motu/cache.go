@@ -0,0 +1,109 @@
+package motu
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Cache holds values that were read from or written to the interface
+// earlier, e.g. by a previous run of a command line tool. A client that
+// uses a cache serves reads from it while they're fresh, instead of
+// waiting for the interface. Values read from a cache still carry the
+// datastore's ETag, so read-modify-write changes such as IncDec are
+// retried if the cached value turns out to be stale. Values written
+// without learning the new ETag are dropped instead.
+type Cache struct {
+	// How long after a value was read it can be served from the cache
+	MaxAge time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*cacheEntry
+}
+
+type cacheEntry struct {
+	Value   any       `json:"value"`
+	ETag    string    `json:"etag,omitempty"`
+	Updated time.Time `json:"updated"`
+}
+
+// NewCache returns an empty cache
+func NewCache(maxAge time.Duration) *Cache {
+	return &Cache{
+		MaxAge:  maxAge,
+		entries: map[string]*cacheEntry{},
+	}
+}
+
+// UseCache makes the client serve reads from the cache while they're
+// fresh, and keep it up to date with what it reads and writes. A
+// synced mirror takes precedence over the cache.
+func (c *Client) UseCache(cache *Cache) {
+	c.cache = cache
+}
+
+// Put records the value of a property, along with the datastore's
+// ETag if it's known. The property can be given with or without the
+// "datastore/" prefix.
+func (c *Cache) Put(property string, value any, etag string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[Key(property)] = &cacheEntry{
+		Value:   value,
+		ETag:    etag,
+		Updated: time.Now(),
+	}
+}
+
+// forget drops a property from the cache
+func (c *Cache) forget(property string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.entries, Key(property))
+}
+
+// get returns the cached value of a property and the
+// datastore's ETag, as long as the value is fresh
+func (c *Cache) get(property string) (any, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.entries[Key(property)]
+	if !ok || time.Since(e.Updated) > c.MaxAge {
+		return nil, "", false
+	}
+
+	return e.Value, e.ETag, true
+}
+
+// MarshalJSON encodes the cached values so that the cache can be
+// saved. Values that are no longer fresh are left out.
+func (c *Cache) MarshalJSON() ([]byte, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	fresh := make(map[string]*cacheEntry, len(c.entries))
+	for k, e := range c.entries {
+		if time.Since(e.Updated) <= c.MaxAge {
+			fresh[k] = e
+		}
+	}
+
+	return json.Marshal(fresh)
+}
+
+// UnmarshalJSON decodes cached values saved with MarshalJSON
+func (c *Cache) UnmarshalJSON(b []byte) error {
+	entries := map[string]*cacheEntry{}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = entries
+	return nil
+}
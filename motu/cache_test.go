@@ -0,0 +1,140 @@
+package motu_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// cached returns the entries in a cache, by key
+func cached(t *testing.T, cache *motu.Cache) map[string]struct {
+	Value any    `json:"value"`
+	ETag  string `json:"etag"`
+} {
+	t.Helper()
+
+	b, err := json.Marshal(cache)
+	if err != nil {
+		t.Fatalf("failed to marshal cache: %v", err)
+	}
+
+	var entries map[string]struct {
+		Value any    `json:"value"`
+		ETag  string `json:"etag"`
+	}
+	if err := json.Unmarshal(b, &entries); err != nil {
+		t.Fatalf("failed to unmarshal cache: %v", err)
+	}
+	return entries
+}
+
+func TestCacheServesReads(t *testing.T) {
+	c, _ := newTestClient(t, trimValues(-20))
+	c.UseCache(motu.NewCache(time.Minute))
+	log := logRequests(c)
+
+	for range 3 {
+		if _, err := c.Get(trimProperty); err != nil {
+			t.Fatalf("Get failed: %v", err)
+		}
+	}
+
+	if n := log.methods(http.MethodGet); n != 1 {
+		t.Errorf("made %d GET requests, want 1", n)
+	}
+}
+
+func TestCacheKeepsETagOfWrites(t *testing.T) {
+	c, _ := newTestClient(t, trimValues(-20))
+	cache := motu.NewCache(time.Minute)
+	c.UseCache(cache)
+
+	if err := c.Set(trimProperty, -25); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	e, ok := cached(t, cache)["ext/obank/1/ch/0/stereoTrim"]
+	if !ok {
+		t.Fatal("the written value isn't in the cache")
+	}
+	if e.Value != -25.0 {
+		t.Errorf("cached value is %v, want -25", e.Value)
+	}
+	if e.ETag == "" {
+		t.Error("the cached value has no ETag")
+	}
+}
+
+// A change made elsewhere after a write through the client isn't
+// overwritten by a read-modify-write change based on the cache
+func TestCacheAfterWriteDoesNotLoseChanges(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	d := trimDevice(t)
+	c.UseCache(motu.NewCache(time.Minute))
+
+	if _, err := c.SetLevel(d, -20); err != nil {
+		t.Fatalf("SetLevel failed: %v", err)
+	}
+	s.Set(map[string]any{trimProperty: -40.0})
+
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	if want := -40 + d.StepSize(); v != want {
+		t.Errorf("IncDec returned %v, want %v from the value set elsewhere", v, want)
+	}
+}
+
+// Without an ETag from the write, the cache can't tell if the value
+// goes stale, so it's dropped and the next read goes to the interface
+func TestCacheDropsWritesWithoutETag(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	cache := motu.NewCache(time.Minute)
+	c.UseCache(cache)
+
+	log := logRequests(c)
+	log.modify = func(rsp *http.Response) {
+		if rsp.Request.Method == http.MethodPatch {
+			rsp.Header.Del("ETag")
+		}
+	}
+
+	if err := c.Set(trimProperty, -25); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if _, ok := cached(t, cache)["ext/obank/1/ch/0/stereoTrim"]; ok {
+		t.Error("a value written without an ETag is still in the cache")
+	}
+
+	s.Set(map[string]any{trimProperty: -40.0})
+	v, err := c.Get(trimProperty)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if v != -40 {
+		t.Errorf("Get returned %v, want -40 from the interface", v)
+	}
+}
+
+func TestCacheExpires(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	c.UseCache(motu.NewCache(50 * time.Millisecond))
+
+	if _, err := c.Get(trimProperty); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	s.Set(map[string]any{trimProperty: -40.0})
+	time.Sleep(100 * time.Millisecond)
+
+	v, err := c.Get(trimProperty)
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if v != -40 {
+		t.Errorf("Get returned %v, want -40 once the cache expired", v)
+	}
+}
@@ -0,0 +1,139 @@
+package motu
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// UnsupportedError is returned when the interface doesn't have a
+// property, usually because its model or firmware doesn't have the
+// feature that the property belongs to
+type UnsupportedError struct {
+	// The missing property, or the feature that needs a newer API
+	Property string
+
+	// The interface's API version, if it could be read
+	APIVersion string
+
+	// The API version the feature needs, if that's why it isn't supported
+	MinAPIVersion string
+}
+
+func (e *UnsupportedError) Error() string {
+	msg := fmt.Sprintf("this interface doesn't support %s", e.Property)
+	if e.APIVersion != "" {
+		msg = fmt.Sprintf("this interface (API version %s) doesn't support %s", e.APIVersion, e.Property)
+	}
+	if e.MinAPIVersion != "" {
+		msg += fmt.Sprintf(", which needs API version %s or later", e.MinAPIVersion)
+	}
+	return msg
+}
+
+// Is makes errors.Is report an UnsupportedError as ErrPropertyNotFound
+func (e *UnsupportedError) Is(target error) bool {
+	return target == ErrPropertyNotFound
+}
+
+// Has returns whether the interface has the given property
+func (c *Client) Has(property string) (bool, error) {
+	return c.HasContext(context.Background(), property)
+}
+
+// HasContext is like Has but the request is cancelled with ctx
+func (c *Client) HasContext(ctx context.Context, property string) (bool, error) {
+	if c.mirror != nil && c.mirror.Synced() {
+		_, ok := c.mirror.Value(property)
+		return ok, nil
+	}
+
+	body, err := c.get(ctx, property)
+	if errors.Is(err, ErrPropertyNotFound) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	var parsed struct {
+		Value json.RawMessage `json:"value"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return false, nil
+	}
+
+	return len(parsed.Value) > 0 && string(parsed.Value) != "null", nil
+}
+
+// Require returns an *UnsupportedError if the interface is missing
+// any of the properties. Check properties with this before setting
+// them, as some firmware accepts changes to properties it doesn't
+// have without complaint.
+func (c *Client) Require(properties ...string) error {
+	return c.RequireContext(context.Background(), properties...)
+}
+
+// RequireContext is like Require but requests are cancelled with ctx
+func (c *Client) RequireContext(ctx context.Context, properties ...string) error {
+	for _, p := range properties {
+		ok, err := c.HasContext(ctx, p)
+		if err != nil {
+			return err
+		}
+
+		if !ok {
+			// The version is only for the error message,
+			// so it doesn't matter if it can't be read
+			version, _ := c.APIVersionContext(ctx)
+			return &UnsupportedError{Property: p, APIVersion: version}
+		}
+	}
+
+	return nil
+}
+
+// RequireAPIVersion returns an *UnsupportedError for the feature if the
+// interface's API version is older than min, e.g. "1.0.0". Use it for
+// features whose properties older firmware lays out differently, where
+// Require would find the wrong property rather than none at all.
+func (c *Client) RequireAPIVersion(feature, min string) error {
+	return c.RequireAPIVersionContext(context.Background(), feature, min)
+}
+
+// RequireAPIVersionContext is like RequireAPIVersion but
+// the request is cancelled with ctx
+func (c *Client) RequireAPIVersionContext(ctx context.Context, feature, min string) error {
+	version, err := c.APIVersionContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if compareVersions(version, min) < 0 {
+		return &UnsupportedError{Property: feature, APIVersion: version, MinAPIVersion: min}
+	}
+	return nil
+}
+
+// compareVersions compares two dotted versions, e.g. "0.0.0" and
+// "1.2", returning -1, 0 or 1. Missing parts count as zero, as do
+// parts that aren't numbers.
+func compareVersions(a, b string) int {
+	as, bs := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < max(len(as), len(bs)); i++ {
+		var x, y int
+		if i < len(as) {
+			x, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			y, _ = strconv.Atoi(bs[i])
+		}
+		if x != y {
+			return cmp.Compare(x, y)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,70 @@
+package motu
+
+import (
+	"context"
+	"sort"
+)
+
+// Change is a change made to a property through a client
+type Change struct {
+	// Path relative to the datastore root, e.g. "mix/chan/0/matrix/mute"
+	Path string `json:"path"`
+
+	// The value before the change, or nil if it couldn't be read
+	Old any `json:"old"`
+
+	New any `json:"new"`
+}
+
+// previous returns the current values of the properties that are about
+// to change, so that OnChange can be told what they were. Values that
+// can't be read are left out.
+func (c *Client) previous(ctx context.Context, values map[string]any) map[string]any {
+	old := make(map[string]any, len(values))
+
+	var missing []string
+	for k := range values {
+		if v, _, ok := c.recall(k); ok {
+			old[k] = v
+		} else {
+			missing = append(missing, k)
+		}
+	}
+
+	switch {
+	case len(missing) == 1:
+		if v, err := c.ValueContext(ctx, Path(missing[0])); err == nil {
+			old[missing[0]] = v
+		}
+	case len(missing) > 1:
+		// One request for everything is quicker than one for each
+		tree, err := c.GetTreeContext(ctx, "datastore")
+		if err != nil {
+			break
+		}
+		for _, k := range missing {
+			if v, ok := tree[k]; ok {
+				old[k] = v
+			}
+		}
+	}
+
+	return old
+}
+
+// changed calls OnChange for each of the values, in order of path
+func (c *Client) changed(old, values map[string]any) {
+	if c.OnChange == nil {
+		return
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		c.OnChange(Change{Path: k, Old: old[k], New: values[k]})
+	}
+}
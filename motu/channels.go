@@ -0,0 +1,145 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// Channel is a mixer channel or an output channel, as found in
+// the datastore. Names are the ones given in the web UI.
+type Channel struct {
+	// Path relative to the datastore root, e.g.
+	// "mix/chan/10" or "ext/obank/1/ch/0"
+	Path string
+
+	// The name given by the user, or empty if the channel hasn't been named
+	Name string
+
+	// The name the device gives the channel
+	DefaultName string
+
+	// Name of the output bank, empty for mixer channels
+	Bank string
+
+	// Properties that control the channel's level and mute. MuteProperty
+	// is empty for output channels, which can't be muted.
+	Property     string
+	MuteProperty string
+	Scale        Scale
+}
+
+// DisplayName returns the user's name for the
+// channel if it has one, or its default name
+func (ch *Channel) DisplayName() string {
+	if ch.Name != "" {
+		return ch.Name
+	}
+	return ch.DefaultName
+}
+
+var (
+	mixerChannelKey  = regexp.MustCompile(`^mix/chan/(\d+)/matrix/fader$`)
+	outputChannelKey = regexp.MustCompile(`^ext/obank/(\d+)/ch/(\d+)/(stereoTrim|trim)$`)
+)
+
+// Channels lists the mixer channels and output channels in the
+// datastore, in order of mixer channel and then bank and channel
+func (c *Client) Channels() ([]*Channel, error) {
+	return c.ChannelsContext(context.Background())
+}
+
+// ChannelsContext is like Channels but the request is cancelled with ctx
+func (c *Client) ChannelsContext(ctx context.Context) ([]*Channel, error) {
+	tree, err := c.GetTreeContext(ctx, "datastore")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	return ChannelsFromTree(tree), nil
+}
+
+// ChannelsFromTree is like Channels but reads from
+// values that have already been fetched, e.g. by GetTree
+func ChannelsFromTree(tree map[string]any) []*Channel {
+	str := func(key string) string {
+		s, _ := tree[key].(string)
+		return s
+	}
+
+	type sortKey struct{ kind, bank, ch int }
+	keys := map[*Channel]sortKey{}
+	var channels []*Channel
+
+	for k := range tree {
+		if m := mixerChannelKey.FindStringSubmatch(k); m != nil {
+			path := "mix/chan/" + m[1]
+			n, _ := strconv.Atoi(m[1])
+
+			ch := &Channel{
+				Path:         path,
+				Name:         str(path + "/config/name"),
+				DefaultName:  "Channel " + strconv.Itoa(n+1),
+				Property:     Path(path + "/matrix/fader"),
+				MuteProperty: Path(path + "/matrix/mute"),
+				Scale:        ScaleLog,
+			}
+			channels = append(channels, ch)
+			keys[ch] = sortKey{0, 0, n}
+			continue
+		}
+
+		if m := outputChannelKey.FindStringSubmatch(k); m != nil {
+			path := "ext/obank/" + m[1] + "/ch/" + m[2]
+
+			// Stereo pairs have both properties. The stereo trim is
+			// the one that moves both channels, so it wins.
+			if m[3] == "trim" {
+				if _, ok := tree[path+"/stereoTrim"]; ok {
+					continue
+				}
+			}
+
+			bank, _ := strconv.Atoi(m[1])
+			n, _ := strconv.Atoi(m[2])
+
+			// The second channel of a linked pair is
+			// controlled along with the first one
+			if n%2 == 1 {
+				first := fmt.Sprintf("ext/obank/%s/ch/%d/%s", m[1], n-1, linkProperty)
+				if v, _ := tree[first].(float64); v == 1 {
+					continue
+				}
+			}
+
+			ch := &Channel{
+				Path:        path,
+				Name:        str(path + "/name"),
+				DefaultName: str(path + "/defaultName"),
+				Bank:        str("ext/obank/" + m[1] + "/name"),
+				Property:    Path(k),
+				Scale:       ScaleLinear,
+			}
+			if ch.DefaultName == "" {
+				ch.DefaultName = fmt.Sprintf("%s %d", ch.Bank, n+1)
+			}
+			channels = append(channels, ch)
+			keys[ch] = sortKey{1, bank, n}
+		}
+	}
+
+	sort.Slice(channels, func(i, j int) bool {
+		a, b := keys[channels[i]], keys[channels[j]]
+		if a.kind != b.kind {
+			return a.kind < b.kind
+		}
+		if a.bank != b.bank {
+			return a.bank < b.bank
+		}
+		return a.ch < b.ch
+	})
+
+	return channels
+}
@@ -0,0 +1,681 @@
+// Package motu is a client for the HTTP datastore API
+// exposed by MOTU AVB audio interfaces.
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultTimeout is the HTTP timeout used by clients created by this package
+	defaultTimeout = time.Second * 3
+
+	// How many times, and how soon, clients created by
+	// this package retry requests that couldn't be made
+	defaultRetries      = 2
+	defaultRetryBackoff = 200 * time.Millisecond
+
+	// How long to wait for a connection to the device
+	dialTimeout = time.Second
+
+	// How long to wait after Relocate fails before calling it again,
+	// so that a device that's turned off isn't searched for on every
+	// request
+	relocateInterval = 30 * time.Second
+
+	// How many connections to the device to keep open between requests
+	maxIdleConns = 4
+)
+
+// Client talks to a single MOTU interface
+type Client struct {
+	// Where the interface is. Use Host to read it while
+	// requests are being made, as Relocate changes it.
+	Address    *url.URL
+	HTTPClient *http.Client
+
+	// If set, Relocate is called when the interface can't be reached,
+	// to find out where it is now, e.g. after its DHCP lease changed.
+	// It returns an address in any form NewFromAddress takes, and the
+	// request is made again there, as are later requests.
+	Relocate func(ctx context.Context) (string, error)
+
+	// How many times to retry a request when the interface can't be
+	// reached or is temporarily unavailable. Zero means no retries.
+	Retries int
+
+	// How long to wait before the first retry.
+	// The wait doubles after each attempt.
+	RetryBackoff time.Duration
+
+	// If set, OnChange is called for every property changed through
+	// the client, after the change has been made. To know the old
+	// values, the client may have to read them before the change.
+	OnChange func(Change)
+
+	// Send values without checking them against the ranges of
+	// properties, e.g. for firmware with ranges this doesn't know
+	SkipValidation bool
+
+	// Set by SetPassword
+	auth *authenticator
+
+	// Set by Sync
+	mirror *Mirror
+
+	// Set by UseCache
+	cache *Cache
+
+	// Set by Defer
+	deferred *deferred
+
+	// Cached by APIVersion
+	apiVersion   string
+	apiVersionMu sync.Mutex
+
+	// Cached by validate
+	ranges propertyRanges
+
+	// Guards Address
+	addrMu sync.RWMutex
+
+	// Serialises calls to Relocate, and when it last failed
+	relocateMu     sync.Mutex
+	relocateFailed time.Time
+}
+
+// Errors returned by the client can be checked for these with errors.Is
+var (
+	// The interface didn't respond, e.g. because it's turned
+	// off or the address is wrong
+	ErrDeviceUnreachable = errors.New("device unreachable")
+
+	// The interface wants a password, or the password was wrong
+	ErrUnauthorized = errors.New("unauthorized")
+
+	// The interface doesn't have the property
+	ErrPropertyNotFound = errors.New("property not found")
+
+	// The interface rejected a value, or
+	// returned one that doesn't make sense
+	ErrValueOutOfRange = errors.New("value out of range")
+
+	// The datastore changed between reading a value and writing
+	// a new one based on it, e.g. because someone moved a fader
+	ErrConflict = errors.New("datastore changed")
+)
+
+// NewFromIPAddress returns a client for the interface at the given IP address
+//
+// Deprecated: Use NewFromAddress, which also takes host names.
+func NewFromIPAddress(ip string) (*Client, error) {
+	return NewFromAddress(ip)
+}
+
+// SetPassword sets the password for interfaces that protect
+// the datastore. It's only sent if the interface asks for it.
+func (c *Client) SetPassword(username, password string) {
+	c.auth = &authenticator{username: username, password: password}
+}
+
+// newClient returns a client with the default timeout and retries
+func newClient(addr *url.URL) *Client {
+	return &Client{
+		Address:      addr,
+		HTTPClient:   newHTTPClient(defaultTimeout),
+		Retries:      defaultRetries,
+		RetryBackoff: defaultRetryBackoff,
+	}
+}
+
+// newHTTPClient returns an HTTP client with its own transport, tuned
+// for talking to a single device on the local network. Connections are
+// kept open between requests so that a read followed by a write, as in
+// IncDec, only has to connect once.
+func newHTTPClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			// The device is on the local network, so it's
+			// either there almost immediately or not at all
+			DialContext: (&net.Dialer{
+				Timeout:   dialTimeout,
+				KeepAlive: 30 * time.Second,
+			}).DialContext,
+
+			// A mirror holds one connection open for its long
+			// poll, which leaves the others for everything else
+			MaxIdleConns:        maxIdleConns,
+			MaxIdleConnsPerHost: maxIdleConns,
+			IdleConnTimeout:     90 * time.Second,
+
+			// Responses are small and the network is fast
+			DisableCompression: true,
+		},
+	}
+}
+
+// Get returns the current value of a numeric property
+func (c *Client) Get(property string) (float64, error) {
+	return c.GetContext(context.Background(), property)
+}
+
+// GetContext is like Get but the request is cancelled with ctx
+func (c *Client) GetContext(ctx context.Context, property string) (float64, error) {
+	var v float64
+	if err := c.getValue(ctx, property, &v); err != nil {
+		return 0, err
+	}
+
+	return v, nil
+}
+
+// GetString returns the current value of a string property
+func (c *Client) GetString(property string) (string, error) {
+	return c.GetStringContext(context.Background(), property)
+}
+
+// GetStringContext is like GetString but the request is cancelled with ctx
+func (c *Client) GetStringContext(ctx context.Context, property string) (string, error) {
+	var v string
+	if err := c.getValue(ctx, property, &v); err != nil {
+		return "", err
+	}
+
+	return v, nil
+}
+
+// Value returns the current value of a property of any type. Numbers
+// are returned as float64 and strings as string.
+func (c *Client) Value(property string) (any, error) {
+	return c.ValueContext(context.Background(), property)
+}
+
+// ValueContext is like Value but the request is cancelled with ctx
+func (c *Client) ValueContext(ctx context.Context, property string) (any, error) {
+	var v any
+	if err := c.getValue(ctx, property, &v); err != nil {
+		return nil, err
+	}
+
+	return v, nil
+}
+
+// getValue reads a single property and unmarshals its value into v
+func (c *Client) getValue(ctx context.Context, property string, v any) error {
+	if cached, _, ok := c.recall(property); ok {
+		b, err := json.Marshal(cached)
+		if err != nil {
+			return fmt.Errorf("failed to marshal cached value: %w", err)
+		}
+		if err := json.Unmarshal(b, v); err != nil {
+			return fmt.Errorf("failed to unmarshal cached value: %w", err)
+		}
+		return nil
+	}
+
+	body, etag, err := c.getWithETag(ctx, property)
+	if errors.Is(err, ErrPropertyNotFound) {
+		version, _ := c.APIVersionContext(ctx)
+		return &UnsupportedError{Property: property, APIVersion: version}
+	} else if err != nil {
+		return err
+	}
+
+	type wrapper struct {
+		Value json.RawMessage `json:"value"`
+	}
+
+	parsed := wrapper{}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if err := json.Unmarshal(parsed.Value, v); err != nil {
+		return fmt.Errorf("failed to unmarshal value: %w", err)
+	}
+
+	if c.cache != nil {
+		var value any
+		if err := json.Unmarshal(parsed.Value, &value); err == nil {
+			c.cache.Put(property, value, etag)
+		}
+	}
+
+	return nil
+}
+
+// recall returns a property's value, and the datastore's ETag, from
+// the changes being deferred, the mirror if it's synced or otherwise
+// from the cache if it's fresh
+func (c *Client) recall(property string) (any, string, bool) {
+	if c.deferred != nil {
+		if v, ok := c.deferred.value(property); ok {
+			return v, "", true
+		}
+	}
+
+	if c.mirror != nil {
+		if v, etag, ok := c.mirror.valueWithETag(property); ok {
+			return v, etag, true
+		}
+	}
+
+	if c.cache != nil {
+		return c.cache.get(property)
+	}
+
+	return nil, "", false
+}
+
+// remember records values that were changed through this client in the
+// mirror and the cache. Keys are paths relative to the datastore root.
+// Etag is the datastore's ETag after the change. Without one, the values
+// are dropped from the cache instead, as a read-modify-write change based
+// on them couldn't tell if someone else has changed them since.
+func (c *Client) remember(values map[string]any, etag string) {
+	if c.mirror != nil {
+		c.mirror.apply(values)
+	}
+
+	if c.cache != nil {
+		for k, v := range values {
+			if etag == "" {
+				c.cache.forget(k)
+			} else {
+				c.cache.Put(k, v, etag)
+			}
+		}
+	}
+}
+
+// GetTree returns every value under the given path, e.g. "datastore/mix".
+// Keys in the result are paths relative to the datastore root.
+func (c *Client) GetTree(path string) (map[string]any, error) {
+	return c.GetTreeContext(context.Background(), path)
+}
+
+// GetTreeContext is like GetTree but the request is cancelled with ctx
+func (c *Client) GetTreeContext(ctx context.Context, path string) (map[string]any, error) {
+	body, err := c.get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	values := map[string]any{}
+	if err := json.Unmarshal(body, &values); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	// The keys in the response are relative to the requested path
+	prefix := Key(path)
+	if prefix == "datastore" {
+		return values, nil
+	}
+
+	result := make(map[string]any, len(values))
+	for k, v := range values {
+		result[prefix+"/"+k] = v
+	}
+	return result, nil
+}
+
+// Set updates the value of a numeric property
+func (c *Client) Set(property string, value float64) error {
+	return c.SetContext(context.Background(), property, value)
+}
+
+// SetContext is like Set but the request is cancelled with ctx
+func (c *Client) SetContext(ctx context.Context, property string, value float64) error {
+	return c.write(ctx, property, fmt.Sprintf(`{"value": %f}`, value), map[string]any{Key(property): value})
+}
+
+// SetValue updates the value of a property of any type,
+// e.g. a string or an integer. The value is encoded as JSON.
+func (c *Client) SetValue(property string, value any) error {
+	return c.SetValueContext(context.Background(), property, value)
+}
+
+// SetValueContext is like SetValue but the request is cancelled with ctx
+func (c *Client) SetValueContext(ctx context.Context, property string, value any) error {
+	b, err := json.Marshal(map[string]any{"value": value})
+	if err != nil {
+		return fmt.Errorf("failed to marshal value: %w", err)
+	}
+
+	return c.write(ctx, property, string(b), map[string]any{Key(property): value})
+}
+
+// SetValues updates many properties in a single request. Keys are
+// paths relative to the datastore root, e.g. "mix/chan/0/matrix/mute".
+func (c *Client) SetValues(values map[string]any) error {
+	return c.SetValuesContext(context.Background(), values)
+}
+
+// SetValuesContext is like SetValues but the request is cancelled with ctx
+func (c *Client) SetValuesContext(ctx context.Context, values map[string]any) error {
+	b, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal values: %w", err)
+	}
+
+	return c.write(ctx, "datastore", string(b), values)
+}
+
+// write sends a change to the interface. Values are the properties
+// the change sets, keyed by path relative to the datastore root.
+func (c *Client) write(ctx context.Context, path string, jsonBody string, values map[string]any) error {
+	if err := c.validate(ctx, values); err != nil {
+		return err
+	}
+
+	var old map[string]any
+	if c.OnChange != nil {
+		old = c.previous(ctx, values)
+	}
+
+	if c.deferred != nil {
+		c.deferred.hold(values, old)
+		return nil
+	}
+
+	etag, err := c.patch(ctx, path, jsonBody)
+	if err != nil {
+		return err
+	}
+
+	c.remember(values, etag)
+	c.changed(old, values)
+
+	return nil
+}
+
+func (c *Client) get(ctx context.Context, path string) ([]byte, error) {
+	body, _, err := c.getWithETag(ctx, path)
+	return body, err
+}
+
+// getWithETag is like get but also returns the datastore's
+// ETag, which is empty if the interface doesn't send one
+func (c *Client) getWithETag(ctx context.Context, path string) ([]byte, string, error) {
+	rsp, err := c.do(ctx, http.MethodGet, c.url(path), "", "")
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get property value: %w", err)
+	}
+
+	// The default HTTP client's Transport may not
+	// reuse HTTP/1.x "keep-alive" TCP connections if the
+	// Body is not read to completion and closed.
+	// See: https://golang.org/pkg/net/http/#Response
+	defer func() {
+		if rsp.Body != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+	}()
+
+	if rsp.StatusCode == http.StatusNotFound {
+		return nil, "", fmt.Errorf("%s: %w", path, ErrPropertyNotFound)
+	}
+	if rsp.StatusCode/100 != 2 {
+		return nil, "", newResponseError(rsp, path)
+	}
+
+	body, err := io.ReadAll(rsp.Body)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read body: %w", err)
+	}
+
+	return body, rsp.Header.Get("ETag"), nil
+}
+
+// patch sends a change and returns the datastore's ETag after
+// it, which is empty if the interface doesn't send one
+func (c *Client) patch(ctx context.Context, path string, jsonBody string) (string, error) {
+	return c.patchIfMatch(ctx, path, jsonBody, "")
+}
+
+// patchIfMatch is like patch but, if etag isn't empty, the
+// interface is asked to reject the change with ErrConflict
+// if the datastore has changed since the ETag was read
+func (c *Client) patchIfMatch(ctx context.Context, path string, jsonBody string, etag string) (string, error) {
+	// The API is cursed and wants the value to be formatted as JSON
+	// under the key "value", and then form-encoded.
+	form := url.Values{}
+	form.Add("json", jsonBody)
+
+	u := c.url(path)
+	if c.mirror != nil {
+		// Tell the device who made this change so
+		// it isn't sent back to us in the next poll
+		u.RawQuery = "client=" + strconv.FormatUint(uint64(c.mirror.clientID), 10)
+	}
+
+	rsp, err := c.do(ctx, http.MethodPatch, u, form.Encode(), etag)
+	if err != nil {
+		return "", fmt.Errorf("failed to make request: %w", err)
+	}
+
+	// The default HTTP client's Transport may not
+	// reuse HTTP/1.x "keep-alive" TCP connections if the
+	// Body is not read to completion and closed.
+	// See: https://golang.org/pkg/net/http/#Response
+	defer func() {
+		if rsp.Body != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+	}()
+
+	if rsp.StatusCode/100 != 2 {
+		return "", newResponseError(rsp, path)
+	}
+
+	return rsp.Header.Get("ETag"), nil
+}
+
+// ResponseError is returned when the interface responds
+// to a request with anything other than success
+type ResponseError struct {
+	Method     string
+	Path       string
+	StatusCode int
+
+	// What the interface said, which may explain what went wrong
+	Body string
+}
+
+func (e *ResponseError) Error() string {
+	msg := fmt.Sprintf("%s %s: %s", e.Method, e.Path, http.StatusText(e.StatusCode))
+	if e.Body != "" {
+		msg += ": " + e.Body
+	}
+	return msg
+}
+
+// Is makes errors.Is report a missing property as ErrPropertyNotFound,
+// a rejected value as ErrValueOutOfRange, a missing or wrong password
+// as ErrUnauthorized and a failed If-Match as ErrConflict
+func (e *ResponseError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrPropertyNotFound
+	case http.StatusBadRequest:
+		return target == ErrValueOutOfRange
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return target == ErrUnauthorized
+	case http.StatusPreconditionFailed:
+		return target == ErrConflict
+	default:
+		return false
+	}
+}
+
+// maxErrorBody is how much of a response body to include in a ResponseError
+const maxErrorBody = 512
+
+func newResponseError(rsp *http.Response, path string) *ResponseError {
+	body, _ := io.ReadAll(io.LimitReader(rsp.Body, maxErrorBody))
+
+	return &ResponseError{
+		Method:     rsp.Request.Method,
+		Path:       path,
+		StatusCode: rsp.StatusCode,
+		Body:       strings.TrimSpace(string(body)),
+	}
+}
+
+// do makes a request to the interface. A non-empty body is sent as a
+// form, and a non-empty ifMatch is sent as an If-Match header. Requests
+// that can't be made, or that get a response saying the interface is
+// temporarily unavailable, are retried with a backoff. If they still
+// can't be made, the request is made again wherever Relocate finds the
+// interface.
+func (c *Client) do(ctx context.Context, method string, u *url.URL, body string, ifMatch string) (*http.Response, error) {
+	rsp, err := c.attempt(ctx, method, u, body, ifMatch, c.Retries)
+	if c.Relocate != nil && errors.Is(err, ErrDeviceUnreachable) && ctx.Err() == nil {
+		if host, ok := c.relocate(ctx, u.Host); ok {
+			moved := *u
+			moved.Host = host
+			return c.attempt(ctx, method, &moved, body, ifMatch, c.Retries)
+		}
+	}
+	return rsp, err
+}
+
+// attempt is like do but without relocating, and with the given number of retries
+func (c *Client) attempt(ctx context.Context, method string, u *url.URL, body string, ifMatch string, retries int) (*http.Response, error) {
+	backoff := c.RetryBackoff
+	answered := false
+	for attempt := 0; ; attempt++ {
+		var r io.Reader
+		if body != "" {
+			r = strings.NewReader(body)
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, u.String(), r)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create request: %w", err)
+		}
+
+		if body != "" {
+			req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+		}
+		if ifMatch != "" {
+			req.Header.Set("If-Match", ifMatch)
+		}
+
+		if c.auth != nil {
+			c.auth.authorize(req)
+		}
+
+		rsp, err := c.HTTPClient.Do(req)
+
+		// Answering an authentication challenge doesn't count as a retry
+		if !answered && err == nil && rsp.StatusCode == http.StatusUnauthorized && c.auth != nil && c.auth.respond(rsp) {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+			answered = true
+			attempt--
+			continue
+		}
+
+		if err == nil && !unavailable(rsp.StatusCode) {
+			return rsp, nil
+		}
+
+		// There's no point retrying once the caller has given
+		// up, or if the host name doesn't exist
+		if attempt >= retries || ctx.Err() != nil || NoSuchHost(err) {
+			if err != nil {
+				return nil, fmt.Errorf("%w at %s: %w", ErrDeviceUnreachable, u.Host, err)
+			}
+			return rsp, nil
+		}
+
+		if rsp != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// Host returns the host and port that requests are made to
+func (c *Client) Host() string {
+	c.addrMu.RLock()
+	defer c.addrMu.RUnlock()
+	return c.Address.Host
+}
+
+// url returns the URL of a path on the interface
+func (c *Client) url(path string) *url.URL {
+	c.addrMu.RLock()
+	defer c.addrMu.RUnlock()
+	return c.Address.JoinPath(path)
+}
+
+// relocate calls Relocate to find the interface after it couldn't be
+// reached at failed, and returns the host it's at now. It returns
+// false if it couldn't be found anywhere else.
+func (c *Client) relocate(ctx context.Context, failed string) (string, bool) {
+	c.relocateMu.Lock()
+	defer c.relocateMu.Unlock()
+
+	// Another request may have already found it
+	if host := c.Host(); host != failed {
+		return host, true
+	}
+
+	if time.Since(c.relocateFailed) < relocateInterval {
+		return "", false
+	}
+
+	address, err := c.Relocate(ctx)
+	if err != nil {
+		c.relocateFailed = time.Now()
+		return "", false
+	}
+
+	host, err := parseAddress(address)
+	if err != nil || host == failed {
+		c.relocateFailed = time.Now()
+		return "", false
+	}
+
+	c.addrMu.Lock()
+	moved := *c.Address
+	moved.Host = host
+	c.Address = &moved
+	c.addrMu.Unlock()
+
+	return host, true
+}
+
+// unavailable returns whether a status code means that the
+// request might succeed if it's made again in a moment
+func unavailable(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,204 @@
+package motu_test
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"testing"
+
+	"github.com/jakewright/motu-tools/motu"
+	"github.com/jakewright/motu-tools/motu/motutest"
+)
+
+// newTestClient starts a fake interface with the given values and
+// returns a client for it. The server is closed when the test ends.
+func newTestClient(t *testing.T, values map[string]any) (*motu.Client, *motutest.Server) {
+	t.Helper()
+
+	s := motutest.NewServer(values)
+	t.Cleanup(s.Close)
+
+	c, err := motu.NewFromAddress(s.Address())
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	return c, s
+}
+
+// requestLog records the requests a client makes, and the
+// status of each response, so tests can check what was sent
+type requestLog struct {
+	base http.RoundTripper
+
+	// If set, called with each response before the client sees it
+	modify func(*http.Response)
+
+	mu       sync.Mutex
+	requests []string
+	statuses []int
+}
+
+// logRequests puts a requestLog in front of the client's transport
+func logRequests(c *motu.Client) *requestLog {
+	l := &requestLog{base: c.HTTPClient.Transport}
+	c.HTTPClient.Transport = l
+	return l
+}
+
+func (l *requestLog) RoundTrip(req *http.Request) (*http.Response, error) {
+	rsp, err := l.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	if l.modify != nil {
+		l.modify(rsp)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.requests = append(l.requests, req.Method+" "+req.URL.Path)
+	l.statuses = append(l.statuses, rsp.StatusCode)
+	return rsp, nil
+}
+
+// count returns how many requests got a response with the status
+func (l *requestLog) count(status int) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for _, s := range l.statuses {
+		if s == status {
+			n++
+		}
+	}
+	return n
+}
+
+// methods returns how many requests were made with the method
+func (l *requestLog) methods(method string) int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+	for _, r := range l.requests {
+		if len(r) > len(method) && r[:len(method)+1] == method+" " {
+			n++
+		}
+	}
+	return n
+}
+
+func TestGetAndSet(t *testing.T) {
+	c, s := newTestClient(t, map[string]any{
+		"mix/chan/0/matrix/fader": 0.5,
+		"mix/chan/0/name":         "Vocal",
+	})
+
+	v, err := c.Get("datastore/mix/chan/0/matrix/fader")
+	if err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	if v != 0.5 {
+		t.Errorf("Get returned %v, want 0.5", v)
+	}
+
+	name, err := c.GetString("datastore/mix/chan/0/name")
+	if err != nil {
+		t.Fatalf("GetString failed: %v", err)
+	}
+	if name != "Vocal" {
+		t.Errorf("GetString returned %q, want Vocal", name)
+	}
+
+	if err := c.Set("datastore/mix/chan/0/matrix/fader", 0.25); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, _ := s.Value("mix/chan/0/matrix/fader"); got != 0.25 {
+		t.Errorf("fader is %v after Set, want 0.25", got)
+	}
+}
+
+func TestGetMissingProperty(t *testing.T) {
+	c, _ := newTestClient(t, map[string]any{"mix/chan/0/matrix/fader": 0.5})
+
+	_, err := c.Get("datastore/mix/chan/9/matrix/fader")
+	if !errors.Is(err, motu.ErrPropertyNotFound) {
+		t.Errorf("Get returned %v, want ErrPropertyNotFound", err)
+	}
+
+	var unsupported *motu.UnsupportedError
+	if !errors.As(err, &unsupported) {
+		t.Errorf("Get returned %T, want *UnsupportedError", err)
+	}
+}
+
+func TestSetValues(t *testing.T) {
+	c, s := newTestClient(t, map[string]any{
+		"mix/chan/0/matrix/mute": 0.0,
+		"mix/chan/1/matrix/mute": 0.0,
+		"mix/chan/1/matrix/pan":  0.5,
+	})
+	log := logRequests(c)
+
+	err := c.SetValues(map[string]any{
+		"mix/chan/0/matrix/mute": 1.0,
+		"mix/chan/1/matrix/mute": 1.0,
+		"mix/chan/1/matrix/pan":  0.0,
+	})
+	if err != nil {
+		t.Fatalf("SetValues failed: %v", err)
+	}
+
+	if n := log.methods(http.MethodPatch); n != 1 {
+		t.Errorf("SetValues made %d PATCH requests, want 1", n)
+	}
+
+	want := map[string]any{
+		"mix/chan/0/matrix/mute": 1.0,
+		"mix/chan/1/matrix/mute": 1.0,
+		"mix/chan/1/matrix/pan":  0.0,
+	}
+	for k, w := range want {
+		if got, _ := s.Value(k); got != w {
+			t.Errorf("%s is %v, want %v", k, got, w)
+		}
+	}
+}
+
+func TestSetValuesUnknownProperty(t *testing.T) {
+	c, s := newTestClient(t, map[string]any{"mix/chan/0/matrix/mute": 0.0})
+
+	err := c.SetValues(map[string]any{
+		"mix/chan/0/matrix/mute": 1.0,
+		"mix/chan/0/matrix/nope": 1.0,
+	})
+	if !errors.Is(err, motu.ErrPropertyNotFound) {
+		t.Errorf("SetValues returned %v, want ErrPropertyNotFound", err)
+	}
+
+	// The fake rejects the whole change, like the interface
+	if got, _ := s.Value("mix/chan/0/matrix/mute"); got != 0.0 {
+		t.Errorf("mute is %v after a rejected change, want 0", got)
+	}
+}
+
+func TestGetTree(t *testing.T) {
+	c, _ := newTestClient(t, map[string]any{
+		"mix/chan/0/matrix/mute": 0.0,
+		"mix/chan/1/matrix/mute": 1.0,
+		"mix/main/0/matrix/mute": 0.0,
+	})
+
+	tree, err := c.GetTree("datastore/mix/chan")
+	if err != nil {
+		t.Fatalf("GetTree failed: %v", err)
+	}
+
+	if len(tree) != 2 {
+		t.Errorf("GetTree returned %d values, want 2: %v", len(tree), tree)
+	}
+	if tree["mix/chan/1/matrix/mute"] != 1.0 {
+		t.Errorf("GetTree returned %v for mix/chan/1/matrix/mute, want 1", tree["mix/chan/1/matrix/mute"])
+	}
+}
@@ -0,0 +1,65 @@
+package motu
+
+import (
+	"context"
+	"time"
+)
+
+// Clip describes a channel that has been clipping
+type Clip struct {
+	Bank    string
+	Channel int
+
+	// The peak level that triggered the clip, as an amplitude ratio
+	Level float64
+
+	Time time.Time
+}
+
+// WatchClips polls the meters of the given banks until ctx is cancelled
+// and calls fn whenever a channel reaches 0 dBFS for the given number of
+// consecutive samples. fn is called once per clipping event: a channel
+// has to drop below 0 dBFS again before it can trigger another call.
+func (c *Client) WatchClips(ctx context.Context, banks []string, samples int, interval time.Duration, fn func(Clip)) error {
+	if samples < 1 {
+		samples = 1
+	}
+
+	// Consecutive samples at or above 0 dBFS, per bank and channel
+	counts := map[string][]int{}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		levels, err := c.MetersContext(ctx, banks...)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		for bank, values := range levels {
+			if len(counts[bank]) != len(values) {
+				counts[bank] = make([]int, len(values))
+			}
+
+			for i, v := range values {
+				if v < 1 {
+					counts[bank][i] = 0
+					continue
+				}
+
+				counts[bank][i]++
+				if counts[bank][i] == samples {
+					fn(Clip{Bank: bank, Channel: i, Level: v, Time: now})
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,371 @@
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// The device holds a long poll open for around 15 seconds
+	// before responding with 304 Not Modified, so the timeout
+	// for these requests has to be comfortably longer.
+	pollTimeout = 30 * time.Second
+
+	// How long to wait before polling again after an error
+	pollRetryInterval = time.Second
+)
+
+// Mirror is a local copy of an interface's datastore. It is kept
+// up to date by long polling, so changes made elsewhere (e.g. in
+// the web UI) show up almost immediately.
+type Mirror struct {
+	client     *Client
+	httpClient *http.Client
+
+	// The device tracks what each client has
+	// seen using a random identifier
+	clientID uint32
+
+	mu         sync.RWMutex
+	etag       string
+	synced     bool
+	values     map[string]any
+	subs       map[chan map[string]any]struct{}
+	syncSubs   map[chan bool]struct{}
+	changeSubs map[chan []Change]struct{}
+}
+
+// Sync starts mirroring the datastore in the background until ctx is
+// cancelled. While the mirror is synced, reads from the client are
+// served locally instead of making a request to the device.
+func (c *Client) Sync(ctx context.Context) *Mirror {
+	m := &Mirror{
+		client: c,
+		httpClient: &http.Client{
+			Transport: c.HTTPClient.Transport,
+			Timeout:   pollTimeout,
+		},
+		clientID:   rand.Uint32(),
+		values:     map[string]any{},
+		subs:       map[chan map[string]any]struct{}{},
+		syncSubs:   map[chan bool]struct{}{},
+		changeSubs: map[chan []Change]struct{}{},
+	}
+
+	c.mirror = m
+	go m.run(ctx)
+
+	return m
+}
+
+// Synced returns whether the mirror holds a complete, current copy
+func (m *Mirror) Synced() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.synced
+}
+
+// Value returns the mirrored value of a property. The property can
+// be given with or without the "datastore/" prefix. The second return
+// value is false if the mirror isn't synced or the property is unknown.
+func (m *Mirror) Value(property string) (any, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.synced {
+		return nil, false
+	}
+
+	v, ok := m.values[Key(property)]
+	return v, ok
+}
+
+// ETag returns the ETag of the datastore as last mirrored
+func (m *Mirror) ETag() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.etag
+}
+
+// valueWithETag is like Value but also returns the
+// ETag of the datastore the value was mirrored from
+func (m *Mirror) valueWithETag(property string) (any, string, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if !m.synced {
+		return nil, "", false
+	}
+
+	v, ok := m.values[Key(property)]
+	return v, m.etag, ok
+}
+
+// Values returns a copy of every mirrored value, keyed by
+// path relative to the datastore root
+func (m *Mirror) Values() map[string]any {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make(map[string]any, len(m.values))
+	for k, v := range m.values {
+		result[k] = v
+	}
+	return result
+}
+
+// Subscribe returns a channel that receives every batch of changes the
+// mirror sees, keyed by path relative to the datastore root. Batches
+// are dropped if the receiver can't keep up. Call the returned function
+// to unsubscribe.
+func (m *Mirror) Subscribe() (<-chan map[string]any, func()) {
+	ch := make(chan map[string]any, 16)
+
+	m.mu.Lock()
+	m.subs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if _, ok := m.subs[ch]; ok {
+			delete(m.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscribeSynced returns a channel that receives the mirror's synced
+// state whenever it changes. The mirror goes out of sync when the device
+// can't be reached and comes back once it answers again. Call the
+// returned function to unsubscribe.
+func (m *Mirror) SubscribeSynced() (<-chan bool, func()) {
+	ch := make(chan bool, 16)
+
+	m.mu.Lock()
+	m.syncSubs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if _, ok := m.syncSubs[ch]; ok {
+			delete(m.syncSubs, ch)
+			close(ch)
+		}
+	}
+}
+
+// SubscribeChanges returns a channel that receives changes made
+// elsewhere, e.g. in the web UI or by another program, along with the
+// values they replaced. Changes made through this mirror's client, and
+// values seen for the first time, aren't included. Batches are dropped
+// if the receiver can't keep up. Call the returned function to
+// unsubscribe.
+func (m *Mirror) SubscribeChanges() (<-chan []Change, func()) {
+	ch := make(chan []Change, 16)
+
+	m.mu.Lock()
+	m.changeSubs[ch] = struct{}{}
+	m.mu.Unlock()
+
+	return ch, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		if _, ok := m.changeSubs[ch]; ok {
+			delete(m.changeSubs, ch)
+			close(ch)
+		}
+	}
+}
+
+// setSynced must be called with mu held
+func (m *Mirror) setSynced(synced bool) {
+	if m.synced == synced {
+		return
+	}
+	m.synced = synced
+
+	for ch := range m.syncSubs {
+		select {
+		case ch <- synced:
+		default:
+		}
+	}
+}
+
+func (m *Mirror) run(ctx context.Context) {
+	for {
+		if err := m.poll(ctx); err != nil {
+			// Our copy may now be stale, so stop serving
+			// reads from it and start again from scratch.
+			m.mu.Lock()
+			m.setSynced(false)
+			m.etag = ""
+			m.mu.Unlock()
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(pollRetryInterval):
+			}
+		}
+
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// poll makes a single long poll request and applies the result
+func (m *Mirror) poll(ctx context.Context) error {
+	u := m.client.url("datastore")
+	u.RawQuery = "client=" + strconv.FormatUint(uint64(m.clientID), 10)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	m.mu.RLock()
+	etag := m.etag
+	m.mu.RUnlock()
+
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+
+	if m.client.auth != nil {
+		m.client.auth.authorize(req)
+	}
+
+	rsp, err := m.httpClient.Do(req)
+	if err != nil {
+		// The interface may have moved, in which case
+		// the next poll goes to where it is now
+		if m.client.Relocate != nil && ctx.Err() == nil {
+			m.client.relocate(ctx, u.Host)
+		}
+		return fmt.Errorf("failed to poll datastore: %w", err)
+	}
+
+	// The default HTTP client's Transport may not
+	// reuse HTTP/1.x "keep-alive" TCP connections if the
+	// Body is not read to completion and closed.
+	// See: https://golang.org/pkg/net/http/#Response
+	defer func() {
+		if rsp.Body != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+	}()
+
+	switch rsp.StatusCode {
+	case http.StatusNotModified:
+		return nil
+	case http.StatusUnauthorized:
+		// Answer the challenge in the next poll
+		if m.client.auth != nil && m.client.auth.respond(rsp) {
+			return nil
+		}
+		return newResponseError(rsp, "datastore")
+	case http.StatusOK: // Ok
+	default:
+		return fmt.Errorf("unexpected status polling datastore: %s", rsp.Status)
+	}
+
+	changes := map[string]any{}
+	if err := json.NewDecoder(rsp.Body).Decode(&changes); err != nil {
+		return fmt.Errorf("failed to decode datastore: %w", err)
+	}
+
+	m.applyRemote(changes)
+
+	m.mu.Lock()
+	m.etag = rsp.Header.Get("ETag")
+	m.setSynced(true)
+	m.mu.Unlock()
+
+	return nil
+}
+
+// applyRemote applies changes that came from the device, telling
+// SubscribeChanges subscribers about values that actually changed
+func (m *Mirror) applyRemote(changes map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var diff []Change
+	for k, v := range changes {
+		old, ok := m.values[k]
+		if ok && !reflect.DeepEqual(old, v) {
+			diff = append(diff, Change{Path: k, Old: old, New: v})
+		}
+	}
+
+	m.applyLocked(changes)
+
+	if len(diff) == 0 {
+		return
+	}
+
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Path < diff[j].Path })
+
+	for ch := range m.changeSubs {
+		select {
+		case ch <- diff:
+		default:
+		}
+	}
+}
+
+func (m *Mirror) apply(changes map[string]any) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.applyLocked(changes)
+}
+
+// applyLocked must be called with mu held
+func (m *Mirror) applyLocked(changes map[string]any) {
+	for k, v := range changes {
+		m.values[k] = v
+	}
+
+	for ch := range m.subs {
+		select {
+		case ch <- changes:
+		default:
+		}
+	}
+}
+
+// Key converts a property path to the form used for keys
+// in the body of a datastore response, which are relative
+// to the datastore root, e.g. "mix/main/0/matrix/mute".
+func Key(property string) string {
+	property = strings.Trim(property, "/")
+	return strings.TrimPrefix(property, "datastore/")
+}
+
+// Path converts a key relative to the datastore root to a
+// property path, e.g. "datastore/mix/main/0/matrix/mute".
+func Path(key string) string {
+	key = Key(key)
+	if key == "" || key == "datastore" {
+		return "datastore"
+	}
+	return "datastore/" + key
+}
@@ -0,0 +1,142 @@
+package motu_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// syncTestClient starts mirroring and waits for the mirror to sync
+func syncTestClient(t *testing.T, c *motu.Client) *motu.Mirror {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	m := c.Sync(ctx)
+	waitFor(t, "the mirror to sync", m.Synced)
+	return m
+}
+
+// waitFor fails the test if cond isn't true within a second
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+
+	deadline := time.Now().Add(time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestMirrorSyncs(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	s.PollTimeout = 100 * time.Millisecond
+	m := syncTestClient(t, c)
+
+	if v, ok := m.Value(trimProperty); !ok || v != -20.0 {
+		t.Errorf("mirror has %v, %v for the trim, want -20", v, ok)
+	}
+	if m.ETag() == "" {
+		t.Error("mirror has no ETag")
+	}
+}
+
+// Changes made elsewhere arrive through the long poll
+func TestMirrorLongPoll(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	s.PollTimeout = time.Second
+	m := syncTestClient(t, c)
+
+	changes, unsubscribe := m.SubscribeChanges()
+	defer unsubscribe()
+
+	s.Set(map[string]any{trimProperty: -40.0})
+
+	select {
+	case batch := <-changes:
+		if len(batch) != 1 || batch[0].Path != "ext/obank/1/ch/0/stereoTrim" || batch[0].Old != -20.0 || batch[0].New != -40.0 {
+			t.Errorf("got changes %+v, want the trim from -20 to -40", batch)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the change")
+	}
+
+	if v, _ := m.Value(trimProperty); v != -40.0 {
+		t.Errorf("mirror has %v for the trim, want -40", v)
+	}
+}
+
+// Reads are served from a synced mirror without asking the interface
+func TestMirrorServesReads(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	s.PollTimeout = time.Second
+	log := logRequests(c)
+	syncTestClient(t, c)
+
+	before := log.methods(http.MethodGet)
+	for range 3 {
+		if v, err := c.Get(trimProperty); err != nil || v != -20 {
+			t.Fatalf("Get returned %v, %v, want -20", v, err)
+		}
+	}
+	if n := log.methods(http.MethodGet) - before; n != 0 {
+		t.Errorf("made %d GET requests, want none", n)
+	}
+}
+
+// Changes made through the mirror's client show up in the mirror
+// straight away, and aren't reported as changes made elsewhere
+func TestMirrorOwnChanges(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	s.PollTimeout = 100 * time.Millisecond
+	m := syncTestClient(t, c)
+
+	changes, unsubscribe := m.SubscribeChanges()
+	defer unsubscribe()
+
+	if err := c.Set(trimProperty, -30); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if v, _ := m.Value(trimProperty); v != -30.0 {
+		t.Errorf("mirror has %v for the trim, want -30", v)
+	}
+
+	select {
+	case batch := <-changes:
+		t.Errorf("got changes %+v for the client's own write", batch)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
+
+// IncDec based on a mirror that hasn't caught up is retried
+func TestMirrorIncDecConflict(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	s.PollTimeout = time.Second
+	d := trimDevice(t)
+	m := syncTestClient(t, c)
+
+	// Change the trim and wait for the mirror, then write something
+	// else through the client, leaving the mirror's ETag behind
+	s.Set(map[string]any{trimProperty: -40.0})
+	waitFor(t, "the mirror to see the change", func() bool {
+		v, _ := m.Value(trimProperty)
+		return v == -40.0
+	})
+	if err := c.Set("datastore/mix/main/0/matrix/mute", 1); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	if want := -40 + d.StepSize(); v != want {
+		t.Errorf("IncDec returned %v, want %v", v, want)
+	}
+}
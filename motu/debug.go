@@ -0,0 +1,86 @@
+package motu
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// DebugTransport writes the details of every request and response to
+// Out, which helps when working out how the interface's properties
+// behave. Use it as the Transport of a client's HTTPClient.
+type DebugTransport struct {
+	// The transport that makes the requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	Out io.Writer
+
+	// Requests can be made from several goroutines,
+	// e.g. by a mirror, so writes are serialised
+	mu sync.Mutex
+}
+
+// RoundTrip implements http.RoundTripper
+func (t *DebugTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			_ = body.Close()
+		}
+	}
+
+	start := time.Now()
+	rsp, err := base.RoundTrip(req)
+	elapsed := time.Since(start).Round(time.Millisecond)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "> %s %s\n", req.Method, req.URL)
+	if len(reqBody) > 0 {
+		// Form bodies are much easier to read decoded
+		if form, err := url.ParseQuery(string(reqBody)); err == nil {
+			for k, vs := range form {
+				for _, v := range vs {
+					fmt.Fprintf(&b, "> %s=%s\n", k, v)
+				}
+			}
+		} else {
+			fmt.Fprintf(&b, "> %s\n", reqBody)
+		}
+	}
+
+	if err != nil {
+		fmt.Fprintf(&b, "< error after %s: %v\n", elapsed, err)
+	} else {
+		fmt.Fprintf(&b, "< %s (%s)\n", rsp.Status, elapsed)
+
+		// Read the body so it can be logged, and give
+		// the caller a copy to read in its place
+		rspBody, readErr := io.ReadAll(rsp.Body)
+		_ = rsp.Body.Close()
+		rsp.Body = io.NopCloser(bytes.NewReader(rspBody))
+
+		if len(rspBody) > 0 {
+			fmt.Fprintf(&b, "< %s\n", bytes.TrimSpace(rspBody))
+		}
+		if readErr != nil {
+			fmt.Fprintf(&b, "< failed to read body: %v\n", readErr)
+		}
+	}
+
+	t.mu.Lock()
+	_, _ = t.Out.Write(b.Bytes())
+	t.mu.Unlock()
+
+	return rsp, err
+}
@@ -0,0 +1,88 @@
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// deferred holds the changes made while a client is deferring them
+type deferred struct {
+	mu     sync.Mutex
+	values map[string]any
+
+	// What the values were before the first change to each
+	old map[string]any
+}
+
+// Defer holds back changes made through the client until Flush is
+// called, which sends them all in a single request. Reads of changed
+// properties return the new values in the meantime. Changes based on
+// the current value, like IncDec, aren't checked against the ETag.
+func (c *Client) Defer() {
+	c.deferred = &deferred{values: map[string]any{}, old: map[string]any{}}
+}
+
+// Flush sends the changes held back since Defer in a single request,
+// and stops holding changes back
+func (c *Client) Flush(ctx context.Context) error {
+	d := c.deferred
+	if d == nil {
+		return nil
+	}
+	c.deferred = nil
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if len(d.values) == 0 {
+		return nil
+	}
+
+	b, err := json.Marshal(d.values)
+	if err != nil {
+		return fmt.Errorf("failed to marshal values: %w", err)
+	}
+
+	etag, err := c.patch(ctx, "datastore", string(b))
+	if err != nil {
+		return err
+	}
+
+	c.remember(d.values, etag)
+	c.changed(d.old, d.values)
+
+	return nil
+}
+
+// hold adds values to the changes being held back. Old has the values
+// from before the change, if OnChange needs them.
+func (d *deferred) hold(values, old map[string]any) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for k, v := range values {
+		if _, ok := d.values[k]; !ok {
+			if o, ok := old[k]; ok {
+				d.old[k] = o
+			}
+		}
+		d.values[k] = v
+	}
+}
+
+// value returns the new value of a property changed while deferring
+func (d *deferred) value(property string) (any, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	v, ok := d.values[Key(property)]
+	return v, ok
+}
+
+// Discard drops the changes held back since Defer without
+// sending them, and stops holding changes back
+func (c *Client) Discard() {
+	c.deferred = nil
+}
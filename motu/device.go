@@ -0,0 +1,451 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Scale is the type of scale used by a property
+type Scale string
+
+const (
+	ScaleLinear Scale = "linear"
+	ScaleLog    Scale = "log"
+)
+
+// Device is something with a level and a mute
+// switch, e.g. an output or a mixer channel.
+type Device struct {
+	// The property that controls the gain of this property
+	Property string `yaml:"property"`
+
+	// The property that controls whether this device is muted
+	MuteProperty string `yaml:"mute_property"`
+
+	// Type of scale (linear or logarithmic)
+	Scale Scale `yaml:"scale"`
+
+	// Allowed range of values.
+	// If scale is log, these are values in dB (as displayed in the MOTU UI).
+	Max float64 `yaml:"max"`
+	Min float64 `yaml:"min"`
+
+	// Stepping down past Min skips straight to zero volume.
+	// If scale is log, this is NOT dB but instead the amplitude ratio value
+	ZeroVolume float64 `yaml:"zero_volume"`
+
+	// Whether stepping down to SnapDB skips straight to zero
+	// volume. If false, stepping down stops at Min. Defaults to true.
+	Snap *bool `yaml:"snap"`
+
+	// The level in dB that stepping down snaps to zero volume
+	// at. Defaults to Min.
+	SnapDB *float64 `yaml:"snap_below"`
+
+	// How many steps between min and max
+	Steps int `yaml:"steps"`
+
+	// Size of each step in dB. If set, it's used instead of Steps.
+	StepDB float64 `yaml:"step"`
+
+	// How steps and percentages map onto levels. Defaults to
+	// spreading them evenly in dB.
+	Taper Taper `yaml:"taper"`
+}
+
+// Validate returns an error if the device definition is unusable
+func (d *Device) Validate() error {
+	if d.Property == "" {
+		return fmt.Errorf("property is required")
+	}
+
+	switch d.Scale {
+	case ScaleLinear:
+	case ScaleLog:
+		if d.ZeroVolume != 0 {
+			return fmt.Errorf("logarithmic zero volume should be zero")
+		}
+	default:
+		return fmt.Errorf("unknown scale %q", d.Scale)
+	}
+
+	if d.Min >= d.Max {
+		return fmt.Errorf("min must be less than max")
+	}
+
+	if d.Steps < 1 {
+		return fmt.Errorf("steps must be at least 1")
+	}
+
+	if d.StepDB < 0 || d.StepDB > d.Max-d.Min {
+		return fmt.Errorf("step must be between 0 and the size of the range")
+	}
+
+	if d.SnapDB != nil && (*d.SnapDB < d.Min || *d.SnapDB > d.Max) {
+		return fmt.Errorf("snap_below must be between min and max")
+	}
+
+	if err := d.Taper.validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Status is a snapshot of a device's state
+type Status struct {
+	// The raw value of the device's property
+	Value float64
+
+	// The level in dB. Negative infinity if a
+	// logarithmic property is at zero.
+	LevelDB float64
+
+	// The level as a percentage of the device's range
+	Percent float64
+
+	// Always false if the device has no mute property
+	Muted bool
+}
+
+// Status reads the device's level and mute state
+func (c *Client) Status(d *Device) (*Status, error) {
+	return c.StatusContext(context.Background(), d)
+}
+
+// StatusContext is like Status but requests are cancelled with ctx
+func (c *Client) StatusContext(ctx context.Context, d *Device) (*Status, error) {
+	value, err := c.GetContext(ctx, d.Property)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current value: %w", err)
+	}
+
+	s := &Status{
+		Value:   value,
+		LevelDB: d.ToDB(value),
+		Percent: d.ToPercent(value),
+	}
+
+	if d.MuteProperty != "" {
+		s.Muted, err = c.MutedContext(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return s, nil
+}
+
+// Muted returns whether the device is currently muted
+func (c *Client) Muted(d *Device) (bool, error) {
+	return c.MutedContext(context.Background(), d)
+}
+
+// MutedContext is like Muted but requests are cancelled with ctx
+func (c *Client) MutedContext(ctx context.Context, d *Device) (bool, error) {
+	current, err := c.GetContext(ctx, d.MuteProperty)
+	if err != nil {
+		return false, fmt.Errorf("failed to get current value: %w", err)
+	}
+
+	switch current {
+	case 0:
+		return false, nil
+	case 1:
+		return true, nil
+	default:
+		return false, fmt.Errorf("%w: unexpected current mute value: %f", ErrValueOutOfRange, current)
+	}
+}
+
+// SetMute mutes or unmutes the device
+func (c *Client) SetMute(d *Device, muted bool) error {
+	return c.SetMuteContext(context.Background(), d, muted)
+}
+
+// SetMuteContext is like SetMute but requests are cancelled with ctx
+func (c *Client) SetMuteContext(ctx context.Context, d *Device, muted bool) error {
+	var newValue float64 = 0
+	if muted {
+		newValue = 1
+	}
+
+	if err := c.SetContext(ctx, d.MuteProperty, newValue); err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return nil
+}
+
+// Mute toggles the device's mute property and returns the new state
+func (c *Client) Mute(d *Device) (bool, error) {
+	return c.MuteContext(context.Background(), d)
+}
+
+// MuteContext is like Mute but requests are cancelled with ctx
+func (c *Client) MuteContext(ctx context.Context, d *Device) (bool, error) {
+	newValue, err := c.update(ctx, d.MuteProperty, func(current float64) (float64, error) {
+		switch current {
+		case 0:
+			return 1, nil
+		case 1:
+			return 0, nil
+		default:
+			return 0, fmt.Errorf("%w: unexpected current mute value: %f", ErrValueOutOfRange, current)
+		}
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return newValue == 1, nil
+}
+
+// Level returns the device's current level in dB
+func (c *Client) Level(d *Device) (float64, error) {
+	return c.LevelContext(context.Background(), d)
+}
+
+// LevelContext is like Level but requests are cancelled with ctx
+func (c *Client) LevelContext(ctx context.Context, d *Device) (float64, error) {
+	current, err := c.GetContext(ctx, d.Property)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current value: %w", err)
+	}
+
+	return d.ToDB(current), nil
+}
+
+// SetLevel sets the device's level in dB, keeping it within Min
+// and Max. Levels below Min go straight to ZeroVolume. It returns
+// the new value of the property.
+func (c *Client) SetLevel(d *Device, db float64) (float64, error) {
+	return c.SetLevelContext(context.Background(), d, db)
+}
+
+// SetLevelContext is like SetLevel but requests are cancelled with ctx
+func (c *Client) SetLevelContext(ctx context.Context, d *Device, db float64) (float64, error) {
+	newValue := d.FromLevel(db)
+
+	if err := c.SetContext(ctx, d.Property, newValue); err != nil {
+		return 0, fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return newValue, nil
+}
+
+// Percent returns the device's current level as a percentage of its
+// range, as described by DBToPercent
+func (c *Client) Percent(d *Device) (float64, error) {
+	return c.PercentContext(context.Background(), d)
+}
+
+// PercentContext is like Percent but requests are cancelled with ctx
+func (c *Client) PercentContext(ctx context.Context, d *Device) (float64, error) {
+	current, err := c.GetContext(ctx, d.Property)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get current value: %w", err)
+	}
+
+	return d.ToPercent(current), nil
+}
+
+// SetPercent sets the device's level as a percentage of its range,
+// as described by PercentToDB. Zero (or less) goes straight to
+// ZeroVolume. It returns the new value of the property.
+func (c *Client) SetPercent(d *Device, percent float64) (float64, error) {
+	return c.SetPercentContext(context.Background(), d, percent)
+}
+
+// SetPercentContext is like SetPercent but requests are cancelled with ctx
+func (c *Client) SetPercentContext(ctx context.Context, d *Device, percent float64) (float64, error) {
+	newValue := d.FromPercent(percent)
+
+	if err := c.SetContext(ctx, d.Property, newValue); err != nil {
+		return 0, fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return newValue, nil
+}
+
+// PercentToDB maps a percentage onto the device's range in dB through
+// its taper, so that equal steps in percent sound like equal steps in
+// loudness whatever the property's scale. Zero (or less) is reserved
+// for zero volume and gives negative infinity. This suits sliders,
+// dials and anything else that speaks percent.
+func (d *Device) PercentToDB(percent float64) float64 {
+	if percent <= 0 {
+		return math.Inf(-1)
+	}
+	return d.Taper.toDB(d, math.Min(percent, 100))
+}
+
+// DBToPercent is the inverse of PercentToDB. Levels below
+// Min, including zero volume, give zero.
+func (d *Device) DBToPercent(db float64) float64 {
+	if db < d.Min {
+		return 0
+	}
+	return math.Min(math.Max(d.Taper.toPosition(d, db), 0), 100)
+}
+
+// FromPercent converts a percentage of the device's range, as
+// described by PercentToDB, to a value of its property
+func (d *Device) FromPercent(percent float64) float64 {
+	return d.FromLevel(d.PercentToDB(percent))
+}
+
+// ToPercent converts a value of the device's property to a
+// percentage of its range. It is the inverse of FromPercent.
+func (d *Device) ToPercent(value float64) float64 {
+	return d.DBToPercent(d.ToDB(value))
+}
+
+// FromLevel converts a level in dB to a value of the device's property,
+// keeping it within Min and Max. Levels below Min give ZeroVolume.
+func (d *Device) FromLevel(db float64) float64 {
+	if db < d.Min {
+		return d.ZeroVolume
+	}
+
+	return d.FromDB(math.Min(db, d.Max))
+}
+
+// ToDB converts a value of the device's property to dB. The result
+// is negative infinity for a logarithmic property at zero.
+func (d *Device) ToDB(value float64) float64 {
+	if d.Scale == ScaleLog {
+		return AmplitudeToDB(value)
+	}
+
+	return value
+}
+
+// FromDB converts a dB value to a value of the device's property
+func (d *Device) FromDB(db float64) float64 {
+	if d.Scale == ScaleLog {
+		return DBToAmplitude(db)
+	}
+
+	return db
+}
+
+// AmplitudeToDB converts an amplitude ratio value to a decibel value
+// https://en.wikipedia.org/wiki/Decibel
+func AmplitudeToDB(value float64) float64 {
+	return 10 * math.Log10(math.Pow(value, 2))
+}
+
+// DBToAmplitude converts a decibel value back to an
+// amplitude ratio, bounded to [0, 1]
+func DBToAmplitude(db float64) float64 {
+	ampRatio := math.Sqrt(math.Pow(10, db/10))
+	return math.Min(math.Max(ampRatio, 0), 1)
+}
+
+// IncDec moves the device's level up (inc = true)
+// or down by one step and returns the new value
+func (c *Client) IncDec(d *Device, inc bool) (float64, error) {
+	return c.IncDecContext(context.Background(), d, inc)
+}
+
+// IncDecContext is like IncDec but requests are cancelled with ctx
+func (c *Client) IncDecContext(ctx context.Context, d *Device, inc bool) (float64, error) {
+	return c.update(ctx, d.Property, func(current float64) (float64, error) {
+		return d.Step(current, inc), nil
+	})
+}
+
+// IncDecBy moves the device's level up (inc = true) or down
+// by a step of the given size in dB and returns the new value
+func (c *Client) IncDecBy(d *Device, inc bool, db float64) (float64, error) {
+	return c.IncDecByContext(context.Background(), d, inc, db)
+}
+
+// IncDecByContext is like IncDecBy but requests are cancelled with ctx
+func (c *Client) IncDecByContext(ctx context.Context, d *Device, inc bool, db float64) (float64, error) {
+	return c.update(ctx, d.Property, func(current float64) (float64, error) {
+		return d.StepBy(current, inc, db), nil
+	})
+}
+
+// StepSize returns the size of one step in dB: StepDB if
+// it's set, or otherwise the range divided into Steps
+func (d *Device) StepSize() float64 {
+	if d.StepDB > 0 {
+		return d.StepDB
+	}
+	return (d.Max - d.Min) / float64(d.Steps)
+}
+
+// Step returns the value of the device's property one step up
+// (inc = true) or down from the current value. Unless StepDB is
+// set, a device with a taper moves by an even step in position
+// along it, which can be any size in dB.
+func (d *Device) Step(current float64, inc bool) float64 {
+	if d.StepDB > 0 || d.Taper.linear() {
+		return d.StepBy(current, inc, d.StepSize())
+	}
+
+	// Rounded like levels are, so that positions stay on the steps
+	position := math.Round(d.ToPercent(current)/stepResolution) * stepResolution
+	delta := 100 / float64(d.Steps)
+	if !inc {
+		delta = -delta
+	}
+
+	return d.FromLevel(d.limit(d.ToDB(current), d.PercentToDB(position+delta), inc))
+}
+
+// StepBy is like Step but with a step of the given size in dB
+func (d *Device) StepBy(current float64, inc bool, delta float64) float64 {
+	currentDB := d.ToDB(current)
+	if !inc {
+		delta = -delta
+	}
+
+	return d.FromLevel(d.limit(currentDB, roundLevel(currentDB)+delta, inc))
+}
+
+// limit keeps the level that a step from currentDB arrived at within
+// the range. The first step up from zero volume goes to Min, and a
+// step down to the snap level goes to zero volume, which is returned
+// as negative infinity.
+func (d *Device) limit(currentDB, newDB float64, inc bool) float64 {
+	if inc && currentDB < d.Min {
+		return d.Min
+	}
+
+	// Go straight to mute to avoid the range of volumes
+	// being skewed towards the barely-audible range
+	if !inc && d.snaps() && newDB <= d.snapLevel() {
+		return math.Inf(-1)
+	}
+
+	// Keep the volume within the bounds
+	return math.Min(math.Max(newDB, d.Min), d.Max)
+}
+
+// snaps returns whether stepping down can reach zero volume
+func (d *Device) snaps() bool {
+	return d.Snap == nil || *d.Snap
+}
+
+// snapLevel returns the level in dB that stepping down goes
+// straight to zero volume at
+func (d *Device) snapLevel() float64 {
+	if d.SnapDB != nil {
+		return *d.SnapDB
+	}
+	return d.Min
+}
+
+// Levels are rounded to this many dB before stepping, so
+// that values read back from the interface as amplitudes
+// don't drift off the steps
+const stepResolution = 0.1
+
+func roundLevel(db float64) float64 {
+	return math.Round(db/stepResolution) * stepResolution
+}
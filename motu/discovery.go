@@ -0,0 +1,221 @@
+package motu
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/dns/dnsmessage"
+)
+
+const (
+	// MOTU AVB interfaces advertise their web app as a plain HTTP
+	// service. Other things on the network do too, so every result
+	// is checked for a datastore before it's returned.
+	mdnsService = "_http._tcp.local."
+
+	mdnsAddress = "224.0.0.251:5353"
+
+	// DefaultDiscoveryTimeout is how long to wait for devices to respond
+	DefaultDiscoveryTimeout = 2 * time.Second
+)
+
+// Interface is a MOTU interface found on the network
+type Interface struct {
+	// The name the device advertises itself with
+	Name string
+
+	// The device's unique identifier, as reported by the datastore
+	UID string
+
+	// Host and port of the device's HTTP API
+	Address string
+}
+
+// Discover finds MOTU interfaces on the local network using mDNS
+func Discover(timeout time.Duration) ([]*Interface, error) {
+	candidates, err := browse(mdnsService, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	// Check each candidate for a datastore concurrently,
+	// keeping only the ones that turn out to be a MOTU.
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result []*Interface
+	)
+
+	for _, candidate := range candidates {
+		wg.Add(1)
+		go func(iface *Interface) {
+			defer wg.Done()
+
+			c := &Client{
+				Address:    &url.URL{Scheme: "http", Host: iface.Address},
+				HTTPClient: newHTTPClient(timeout),
+			}
+			defer c.HTTPClient.CloseIdleConnections()
+
+			uid, err := c.GetString("datastore/uid")
+			if err != nil {
+				return
+			}
+
+			iface.UID = uid
+
+			mu.Lock()
+			result = append(result, iface)
+			mu.Unlock()
+		}(candidate)
+	}
+
+	wg.Wait()
+
+	return result, nil
+}
+
+// NewFromDiscovery searches the local network for an interface
+// with the given name or UID and returns a client for it
+func NewFromDiscovery(id string) (*Client, error) {
+	interfaces, err := Discover(DefaultDiscoveryTimeout)
+	if err != nil {
+		return nil, fmt.Errorf("failed to discover devices: %w", err)
+	}
+
+	for _, iface := range interfaces {
+		if strings.EqualFold(iface.Name, id) || strings.EqualFold(iface.UID, id) {
+			return newClient(&url.URL{Scheme: "http", Host: iface.Address}), nil
+		}
+	}
+
+	return nil, fmt.Errorf("no device found with name or UID %q", id)
+}
+
+// browse sends a one-shot mDNS query for the service and collects
+// the instances that respond before the timeout expires
+func browse(service string, timeout time.Duration) ([]*Interface, error) {
+	name, err := dnsmessage.NewName(service)
+	if err != nil {
+		return nil, fmt.Errorf("invalid service name: %w", err)
+	}
+
+	query := dnsmessage.Message{
+		Questions: []dnsmessage.Question{{
+			Name:  name,
+			Type:  dnsmessage.TypePTR,
+			Class: dnsmessage.ClassINET,
+		}},
+	}
+
+	packet, err := query.Pack()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build query: %w", err)
+	}
+
+	group, err := net.ResolveUDPAddr("udp4", mdnsAddress)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve mDNS address: %w", err)
+	}
+
+	// Sending from an ephemeral port makes this a "one-shot" query
+	// which responders answer by unicast directly back to us.
+	// See RFC 6762 section 5.1.
+	conn, err := net.ListenUDP("udp4", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open socket: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.WriteToUDP(packet, group); err != nil {
+		return nil, fmt.Errorf("failed to send query: %w", err)
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return nil, fmt.Errorf("failed to set deadline: %w", err)
+	}
+
+	var (
+		instances = map[string]bool{}
+		srvs      = map[string]dnsmessage.SRVResource{}
+		hosts     = map[string]net.IP{}
+	)
+
+	buf := make([]byte, 9000)
+	for {
+		n, _, err := conn.ReadFromUDP(buf)
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read response: %w", err)
+		}
+
+		var msg dnsmessage.Message
+		if err := msg.Unpack(buf[:n]); err != nil {
+			// Ignore anything we can't parse
+			continue
+		}
+
+		records := append(append(msg.Answers, msg.Authorities...), msg.Additionals...)
+		for _, r := range records {
+			switch body := r.Body.(type) {
+			case *dnsmessage.PTRResource:
+				if strings.EqualFold(r.Header.Name.String(), service) {
+					instances[body.PTR.String()] = true
+				}
+			case *dnsmessage.SRVResource:
+				srvs[r.Header.Name.String()] = *body
+			case *dnsmessage.AResource:
+				hosts[r.Header.Name.String()] = net.IP(body.A[:])
+			}
+		}
+	}
+
+	var result []*Interface
+	for instance := range instances {
+		srv, ok := srvs[instance]
+		if !ok {
+			continue
+		}
+
+		host := strings.TrimSuffix(srv.Target.String(), ".")
+		if ip, ok := hosts[srv.Target.String()]; ok {
+			host = ip.String()
+		}
+
+		result = append(result, &Interface{
+			Name:    unescapeInstance(strings.TrimSuffix(instance, "."+service)),
+			Address: net.JoinHostPort(host, strconv.Itoa(int(srv.Port))),
+		})
+	}
+
+	return result, nil
+}
+
+// unescapeInstance removes the backslash escaping
+// that DNS applies to characters in a label
+func unescapeInstance(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if s[i] == '\\' && i+1 < len(s) {
+			// Decimal escapes look like \032
+			if i+3 < len(s) {
+				if n, err := strconv.Atoi(s[i+1 : i+4]); err == nil {
+					b.WriteByte(byte(n))
+					i += 3
+					continue
+				}
+			}
+			i++
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
@@ -0,0 +1,86 @@
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Info describes an interface
+type Info struct {
+	// The name given to the interface, e.g. in the web UI
+	Name string
+
+	Model    string
+	UID      string
+	Firmware string
+
+	// Version of the datastore API
+	APIVersion string
+}
+
+// Info reads the interface's name, model and versions. Anything
+// the interface doesn't report is left empty.
+func (c *Client) Info() (*Info, error) {
+	return c.InfoContext(context.Background())
+}
+
+// InfoContext is like Info but requests are cancelled with ctx
+func (c *Client) InfoContext(ctx context.Context) (*Info, error) {
+	uid, err := c.GetStringContext(ctx, "datastore/uid")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get UID: %w", err)
+	}
+
+	info := &Info{UID: uid}
+
+	// The interface describes itself in the same way as
+	// the other AVB devices it can see on the network
+	tree, err := c.GetTreeContext(ctx, "datastore/avb/"+uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get device details: %w", err)
+	}
+
+	str := func(name string) string {
+		s, _ := tree["avb/"+uid+"/"+name].(string)
+		return s
+	}
+	info.Name = str("entity_name")
+	info.Model = str("model_name")
+	info.Firmware = str("firmware_version")
+
+	if info.APIVersion, err = c.APIVersionContext(ctx); err != nil {
+		return nil, err
+	}
+
+	return info, nil
+}
+
+// APIVersion returns the version of the datastore API, e.g. "0.0.0".
+// The version is only read from the interface the first time.
+func (c *Client) APIVersion() (string, error) {
+	return c.APIVersionContext(context.Background())
+}
+
+// APIVersionContext is like APIVersion but the request is cancelled with ctx
+func (c *Client) APIVersionContext(ctx context.Context) (string, error) {
+	c.apiVersionMu.Lock()
+	defer c.apiVersionMu.Unlock()
+
+	if c.apiVersion != "" {
+		return c.apiVersion, nil
+	}
+
+	body, err := c.get(ctx, "apiversion")
+	if err != nil {
+		return "", fmt.Errorf("failed to get API version: %w", err)
+	}
+
+	// Some firmware returns the version as a JSON string
+	if err := json.Unmarshal(body, &c.apiVersion); err != nil {
+		c.apiVersion = strings.TrimSpace(string(body))
+	}
+
+	return c.apiVersion, nil
+}
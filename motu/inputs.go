@@ -0,0 +1,168 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Port is an input or output channel. Both have a trim.
+type Port interface {
+	// Property returns the path of one of the channel's properties
+	Property(name string) string
+
+	trimProperty() string
+
+	// The trim range to use if the device doesn't say
+	defaultTrimRange() (float64, float64)
+}
+
+// Input is a channel of an input bank, e.g. a mic preamp
+type Input struct {
+	Bank    int `yaml:"bank"`
+	Channel int `yaml:"channel"`
+}
+
+// Property returns the path of one of the input's properties, e.g. "trim"
+func (i Input) Property(name string) string {
+	return fmt.Sprintf("datastore/ext/ibank/%d/ch/%d/%s", i.Bank, i.Channel, name)
+}
+
+func (i Input) trimProperty() string { return "trim" }
+
+func (i Input) defaultTrimRange() (float64, float64) { return 0, 60 }
+
+// Output is a channel of an output bank
+type Output struct {
+	Bank    int `yaml:"bank"`
+	Channel int `yaml:"channel"`
+
+	// Use the trim that controls the channel along with the next
+	// one as a stereo pair, rather than the channel's own trim
+	Stereo bool `yaml:"stereo"`
+}
+
+// Property returns the path of one of the output's properties, e.g. "trim"
+func (o Output) Property(name string) string {
+	return fmt.Sprintf("datastore/ext/obank/%d/ch/%d/%s", o.Bank, o.Channel, name)
+}
+
+func (o Output) trimProperty() string {
+	if o.Stereo {
+		return "stereoTrim"
+	}
+	return "trim"
+}
+
+func (o Output) defaultTrimRange() (float64, float64) { return -127, 0 }
+
+// Trim returns the channel's trim in dB. For an
+// input, this is the preamp gain.
+func (c *Client) Trim(p Port) (float64, error) {
+	return c.TrimContext(context.Background(), p)
+}
+
+// TrimContext is like Trim but the request is cancelled with ctx
+func (c *Client) TrimContext(ctx context.Context, p Port) (float64, error) {
+	return c.GetContext(ctx, p.Property(p.trimProperty()))
+}
+
+// TrimRange returns the lowest and highest trim the channel allows
+func (c *Client) TrimRange(p Port) (float64, float64, error) {
+	return c.TrimRangeContext(context.Background(), p)
+}
+
+// TrimRangeContext is like TrimRange but requests are cancelled with ctx
+func (c *Client) TrimRangeContext(ctx context.Context, p Port) (float64, float64, error) {
+	lo, hi := p.defaultTrimRange()
+
+	// Older firmware doesn't report the range
+	if ok, err := c.HasContext(ctx, p.Property("trimRange")); err != nil {
+		return 0, 0, err
+	} else if !ok {
+		return lo, hi, nil
+	}
+
+	v, err := c.ValueContext(ctx, p.Property("trimRange"))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	r, ok := v.([]any)
+	if !ok || len(r) != 2 {
+		return lo, hi, nil
+	}
+
+	rangeLo, ok1 := r[0].(float64)
+	rangeHi, ok2 := r[1].(float64)
+	if !ok1 || !ok2 {
+		return lo, hi, nil
+	}
+
+	return rangeLo, rangeHi, nil
+}
+
+// SetTrim sets the channel's trim in dB, keeping it within the
+// channel's range. Trims are whole numbers of dB, so it's rounded.
+// It returns the new trim.
+func (c *Client) SetTrim(p Port, db float64) (float64, error) {
+	return c.SetTrimContext(context.Background(), p, db)
+}
+
+// SetTrimContext is like SetTrim but requests are cancelled with ctx
+func (c *Client) SetTrimContext(ctx context.Context, p Port, db float64) (float64, error) {
+	if err := c.RequireContext(ctx, p.Property(p.trimProperty())); err != nil {
+		return 0, err
+	}
+
+	lo, hi, err := c.TrimRangeContext(ctx, p)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get trim range: %w", err)
+	}
+
+	db = math.Round(math.Min(math.Max(db, lo), hi))
+
+	if err := c.SetValueContext(ctx, p.Property(p.trimProperty()), int(db)); err != nil {
+		return 0, fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return db, nil
+}
+
+// Inverted returns whether the input's polarity is inverted
+func (c *Client) Inverted(i Input) (bool, error) {
+	return c.InvertedContext(context.Background(), i)
+}
+
+// InvertedContext is like Inverted but the request is cancelled with ctx
+func (c *Client) InvertedContext(ctx context.Context, i Input) (bool, error) {
+	v, err := c.GetContext(ctx, i.Property("phase"))
+	if err != nil {
+		return false, err
+	}
+
+	return v != 0, nil
+}
+
+// SetInverted inverts the input's polarity, or puts it back to normal
+func (c *Client) SetInverted(i Input, inverted bool) error {
+	return c.SetInvertedContext(context.Background(), i, inverted)
+}
+
+// SetInvertedContext is like SetInverted but requests are cancelled with ctx
+func (c *Client) SetInvertedContext(ctx context.Context, i Input, inverted bool) error {
+	var v int
+	if inverted {
+		v = 1
+	}
+
+	if err := c.RequireContext(ctx, i.Property("phase")); err != nil {
+		return err
+	}
+
+	if err := c.SetValueContext(ctx, i.Property("phase"), v); err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,49 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+)
+
+// Kinds of bank that have channels which can be linked in stereo pairs
+const (
+	BankInput  = "ibank"
+	BankOutput = "obank"
+)
+
+// Property of the first channel of a pair that links the
+// channel with the next one, so they act as a stereo pair
+const linkProperty = "pair"
+
+// SetLinked links a channel with the channel after it as a stereo pair,
+// or unlinks them. Pairs start on even channels, e.g. 0 and 1.
+func (c *Client) SetLinked(kind string, bank, channel int, linked bool) error {
+	return c.SetLinkedContext(context.Background(), kind, bank, channel, linked)
+}
+
+// SetLinkedContext is like SetLinked but requests are cancelled with ctx
+func (c *Client) SetLinkedContext(ctx context.Context, kind string, bank, channel int, linked bool) error {
+	if kind != BankInput && kind != BankOutput {
+		return fmt.Errorf("unknown kind of bank: %s", kind)
+	}
+
+	if channel%2 != 0 {
+		return fmt.Errorf("stereo pairs start on an even channel")
+	}
+
+	var v int
+	if linked {
+		v = 1
+	}
+
+	property := fmt.Sprintf("datastore/ext/%s/%d/ch/%d/%s", kind, bank, channel, linkProperty)
+	if err := c.RequireContext(ctx, property); err != nil {
+		return err
+	}
+
+	if err := c.SetValueContext(ctx, property, v); err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,63 @@
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// Meter banks that can be read with Meters. These are the only banks
+// the datastore API has. There's none for compressor gain reduction.
+const (
+	MeterInputs  = "ext/input"
+	MeterOutputs = "ext/output"
+	MeterMix     = "mix/level"
+)
+
+// Meters returns the current peak levels of each requested bank.
+// Levels are amplitude ratios from 0 to 1, one per channel, in the
+// same order as the channels appear in the datastore.
+func (c *Client) Meters(banks ...string) (map[string][]float64, error) {
+	return c.MetersContext(context.Background(), banks...)
+}
+
+// MetersContext is like Meters but the request is cancelled with ctx
+func (c *Client) MetersContext(ctx context.Context, banks ...string) (map[string][]float64, error) {
+	if len(banks) == 0 {
+		banks = []string{MeterInputs, MeterOutputs, MeterMix}
+	}
+
+	u := c.url("meters")
+	u.RawQuery = url.Values{"meters": {strings.Join(banks, ":")}}.Encode()
+
+	rsp, err := c.do(ctx, http.MethodGet, u, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to get meters: %w", err)
+	}
+
+	// The default HTTP client's Transport may not
+	// reuse HTTP/1.x "keep-alive" TCP connections if the
+	// Body is not read to completion and closed.
+	// See: https://golang.org/pkg/net/http/#Response
+	defer func() {
+		if rsp.Body != nil {
+			_, _ = io.Copy(io.Discard, rsp.Body)
+			_ = rsp.Body.Close()
+		}
+	}()
+
+	if rsp.StatusCode != http.StatusOK {
+		return nil, newResponseError(rsp, "meters")
+	}
+
+	levels := map[string][]float64{}
+	if err := json.NewDecoder(rsp.Body).Decode(&levels); err != nil {
+		return nil, fmt.Errorf("failed to decode meters: %w", err)
+	}
+
+	return levels, nil
+}
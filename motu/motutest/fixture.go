@@ -0,0 +1,235 @@
+package motutest
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+)
+
+// Headers worth keeping in a fixture. Anything else, and in
+// particular Authorization, is left out.
+var (
+	requestHeaders  = []string{"If-Match", "If-None-Match"}
+	responseHeaders = []string{"Content-Type", "ETag", "WWW-Authenticate"}
+)
+
+// Interaction is a request to an interface and its response,
+// as stored in a fixture file
+type Interaction struct {
+	Method        string            `json:"method"`
+	Path          string            `json:"path"`
+	Query         string            `json:"query,omitempty"`
+	RequestHeader map[string]string `json:"request_header,omitempty"`
+	RequestBody   string            `json:"request_body,omitempty"`
+
+	Status int               `json:"status"`
+	Header map[string]string `json:"header,omitempty"`
+	Body   string            `json:"body,omitempty"`
+}
+
+// Recorder appends every request it makes, and the response, to a
+// fixture file as JSON lines. Use it as the Transport of a client's
+// HTTPClient while talking to a real interface, then replay the
+// file with a Replayer.
+type Recorder struct {
+	// The transport that makes the requests. If nil,
+	// http.DefaultTransport is used.
+	Base http.RoundTripper
+
+	mu sync.Mutex
+	f  *os.File
+}
+
+// NewRecorder returns a Recorder that appends to the file at path
+func NewRecorder(path string, base http.RoundTripper) (*Recorder, error) {
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture file: %w", err)
+	}
+
+	return &Recorder{Base: base, f: f}, nil
+}
+
+// Close closes the fixture file
+func (r *Recorder) Close() error {
+	return r.f.Close()
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := r.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+
+	var reqBody []byte
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			reqBody, _ = io.ReadAll(body)
+			_ = body.Close()
+		}
+	}
+
+	rsp, err := base.RoundTrip(req)
+	if err != nil {
+		// There's nothing to replay
+		return nil, err
+	}
+
+	// Read the body so it can be recorded, and give
+	// the caller a copy to read in its place
+	rspBody, err := io.ReadAll(rsp.Body)
+	_ = rsp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response: %w", err)
+	}
+	rsp.Body = io.NopCloser(bytes.NewReader(rspBody))
+
+	line, err := json.Marshal(&Interaction{
+		Method:        req.Method,
+		Path:          req.URL.Path,
+		Query:         withoutClient(req.URL.Query()),
+		RequestHeader: pick(req.Header, requestHeaders),
+		RequestBody:   string(reqBody),
+		Status:        rsp.StatusCode,
+		Header:        pick(rsp.Header, responseHeaders),
+		Body:          string(rspBody),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal interaction: %w", err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if _, err := r.f.Write(append(line, '\n')); err != nil {
+		return nil, fmt.Errorf("failed to write fixture: %w", err)
+	}
+
+	return rsp, nil
+}
+
+// Replayer answers requests with the responses in a fixture file
+// instead of talking to an interface. Each request gets the first
+// recorded response, not already used, for the same method, path,
+// query and body. It has to be used as the Transport of a client's
+// HTTPClient, as it doesn't listen on the network.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions []*Interaction
+	used         []bool
+}
+
+// NewReplayer returns a Replayer for the fixture file at path
+func NewReplayer(path string) (*Replayer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open fixture file: %w", err)
+	}
+	defer f.Close()
+
+	r := &Replayer{}
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20) // Whole datastores are big
+	for scanner.Scan() {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+
+		i := &Interaction{}
+		if err := json.Unmarshal(scanner.Bytes(), i); err != nil {
+			return nil, fmt.Errorf("failed to parse fixture file %s: %w", path, err)
+		}
+		r.interactions = append(r.interactions, i)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read fixture file: %w", err)
+	}
+
+	r.used = make([]bool, len(r.interactions))
+	return r, nil
+}
+
+// Remaining returns how many recorded responses haven't been used
+func (r *Replayer) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	n := 0
+	for _, used := range r.used {
+		if !used {
+			n++
+		}
+	}
+	return n
+}
+
+// RoundTrip implements http.RoundTripper
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqBody []byte
+	if req.Body != nil {
+		var err error
+		if reqBody, err = io.ReadAll(req.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request: %w", err)
+		}
+		_ = req.Body.Close()
+	}
+	query := withoutClient(req.URL.Query())
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for n, i := range r.interactions {
+		if r.used[n] || i.Method != req.Method || i.Path != req.URL.Path || i.Query != query || i.RequestBody != string(reqBody) {
+			continue
+		}
+		r.used[n] = true
+
+		rsp := &http.Response{
+			Status:        fmt.Sprintf("%d %s", i.Status, http.StatusText(i.Status)),
+			StatusCode:    i.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{},
+			Body:          io.NopCloser(bytes.NewReader([]byte(i.Body))),
+			ContentLength: int64(len(i.Body)),
+			Request:       req,
+		}
+		for k, v := range i.Header {
+			rsp.Header.Set(k, v)
+		}
+		return rsp, nil
+	}
+
+	return nil, fmt.Errorf("no recorded response for %s %s", req.Method, req.URL.Path)
+}
+
+// withoutClient encodes a query without the client ID that mirrors
+// send, which is random so would never match between runs
+func withoutClient(query url.Values) string {
+	query.Del("client")
+	return query.Encode()
+}
+
+// pick returns the given headers, where they're set
+func pick(header http.Header, names []string) map[string]string {
+	picked := map[string]string{}
+	for _, name := range names {
+		if v := header.Get(name); v != "" {
+			picked[name] = v
+		}
+	}
+
+	if len(picked) == 0 {
+		return nil
+	}
+	return picked
+}
@@ -0,0 +1,272 @@
+// Package motutest provides an in-memory fake of a MOTU interface's
+// datastore API, for testing code that uses package motu without
+// any hardware.
+package motutest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// defaultPollTimeout is how long the real interface holds a long poll
+// open for. Tests will usually want something much shorter.
+const defaultPollTimeout = 15 * time.Second
+
+// Server is a fake interface. It answers GET and PATCH requests for
+// properties and subtrees, sends an ETag with every response, rejects
+// changes whose If-Match is out of date, and holds long polls of the
+// whole datastore open until something changes.
+type Server struct {
+	*httptest.Server
+
+	// How long a long poll is held open before responding with
+	// 304 Not Modified. Defaults to 15 seconds, like the real thing.
+	PollTimeout time.Duration
+
+	// Returned by the apiversion endpoint
+	APIVersion string
+
+	mu sync.Mutex
+
+	// Incremented on every change. It's sent as the ETag.
+	version int
+	values  map[string]any
+
+	// When each key last changed, and which client changed it, so
+	// long polls can return only what's new to the poller
+	changedAt map[string]int
+	changedBy map[string]string
+
+	// Closed and replaced on every change, to wake long polls
+	wake chan struct{}
+}
+
+// NewServer starts a fake interface with the given values, keyed
+// relative to the datastore root, e.g. "mix/chan/0/matrix/fader".
+// Connect to it with motu.NewFromAddress(s.Address()), and call
+// Close when the test is done.
+func NewServer(values map[string]any) *Server {
+	s := &Server{
+		PollTimeout: defaultPollTimeout,
+		APIVersion:  "0.0.0",
+		version:     1,
+		values:      map[string]any{},
+		changedAt:   map[string]int{},
+		changedBy:   map[string]string{},
+		wake:        make(chan struct{}),
+	}
+
+	for k, v := range values {
+		s.values[motu.Key(k)] = v
+		s.changedAt[motu.Key(k)] = s.version
+	}
+
+	s.Server = httptest.NewServer(s)
+	return s
+}
+
+// Address returns the host and port to connect to
+func (s *Server) Address() string {
+	return strings.TrimPrefix(s.URL, "http://")
+}
+
+// Value returns the current value of a property
+func (s *Server) Value(property string) (any, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	v, ok := s.values[motu.Key(property)]
+	return v, ok
+}
+
+// Values returns a copy of the whole datastore
+func (s *Server) Values() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	values := make(map[string]any, len(s.values))
+	for k, v := range s.values {
+		values[k] = v
+	}
+	return values
+}
+
+// Set changes properties as if someone had changed them on the
+// interface itself, e.g. in the web UI. Unlike a PATCH, it can
+// add properties that don't exist yet.
+func (s *Server) Set(values map[string]any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.setLocked(values, "")
+}
+
+func (s *Server) setLocked(values map[string]any, client string) {
+	s.version++
+	for k, v := range values {
+		k = motu.Key(k)
+		s.values[k] = v
+		s.changedAt[k] = s.version
+		s.changedBy[k] = client
+	}
+
+	close(s.wake)
+	s.wake = make(chan struct{})
+}
+
+// ServeHTTP implements the datastore API
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.Trim(r.URL.Path, "/")
+
+	switch {
+	case path == "apiversion" && r.Method == http.MethodGet:
+		writeJSON(w, http.StatusOK, "", s.APIVersion)
+	case path == "datastore" && r.Method == http.MethodGet && r.Header.Get("If-None-Match") != "":
+		s.poll(w, r)
+	case path == "datastore" || strings.HasPrefix(path, "datastore/"):
+		switch r.Method {
+		case http.MethodGet:
+			s.get(w, motu.Key(path))
+		case http.MethodPatch:
+			s.patch(w, r, motu.Key(path))
+		default:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+// get responds with a single property's value, or with
+// every property under the path relative to the path
+func (s *Server) get(w http.ResponseWriter, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	etag := strconv.Itoa(s.version)
+
+	if v, ok := s.values[key]; ok {
+		writeJSON(w, http.StatusOK, etag, map[string]any{"value": v})
+		return
+	}
+
+	tree := map[string]any{}
+	for k, v := range s.values {
+		if key == "datastore" {
+			tree[k] = v
+		} else if rel, ok := strings.CutPrefix(k, key+"/"); ok {
+			tree[rel] = v
+		}
+	}
+
+	if len(tree) == 0 {
+		writeJSON(w, http.StatusNotFound, etag, map[string]string{"error": "no such property"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, etag, tree)
+}
+
+// patch changes one property, or several when the path is the
+// datastore root. Changes to properties that don't exist are
+// rejected, so that tests catch mistyped paths.
+func (s *Server) patch(w http.ResponseWriter, r *http.Request, key string) {
+	if err := r.ParseForm(); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal([]byte(r.PostForm.Get("json")), &body); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	values := body
+	if key != "datastore" {
+		v, ok := body["value"]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		values = map[string]any{key: v}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if ifMatch := r.Header.Get("If-Match"); ifMatch != "" && ifMatch != strconv.Itoa(s.version) {
+		w.WriteHeader(http.StatusPreconditionFailed)
+		return
+	}
+
+	for k := range values {
+		if _, ok := s.values[motu.Key(k)]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+	}
+
+	s.setLocked(values, r.URL.Query().Get("client"))
+	w.Header().Set("ETag", strconv.Itoa(s.version))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// poll waits for the datastore to change since the version in
+// If-None-Match, then responds with what changed. Changes made
+// by the polling client aren't sent back to it.
+func (s *Server) poll(w http.ResponseWriter, r *http.Request) {
+	since, err := strconv.Atoi(r.Header.Get("If-None-Match"))
+	if err != nil {
+		// An ETag from somewhere else, so start from scratch
+		since = 0
+	}
+	client := r.URL.Query().Get("client")
+
+	timeout := time.NewTimer(s.PollTimeout)
+	defer timeout.Stop()
+
+	for {
+		s.mu.Lock()
+		if s.version > since {
+			changes := map[string]any{}
+			for k, v := range s.values {
+				if s.changedAt[k] > since && (client == "" || s.changedBy[k] != client) {
+					changes[k] = v
+				}
+			}
+			etag := strconv.Itoa(s.version)
+			s.mu.Unlock()
+
+			writeJSON(w, http.StatusOK, etag, changes)
+			return
+		}
+		wake := s.wake
+		s.mu.Unlock()
+
+		select {
+		case <-wake:
+		case <-timeout.C:
+			w.WriteHeader(http.StatusNotModified)
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, etag string, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if etag != "" {
+		w.Header().Set("ETag", etag)
+	}
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(v)
+}
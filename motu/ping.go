@@ -0,0 +1,30 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Ping reads a small property once, without retrying or relocating
+// and without using the mirror or the cache, and returns how long the interface
+// took to answer. Connections are reused as with any other request,
+// so the first ping also includes the time taken to connect.
+func (c *Client) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+	rsp, err := c.attempt(ctx, http.MethodGet, c.url("datastore/uid"), "", "", 0)
+	if err != nil {
+		return 0, err
+	}
+	defer rsp.Body.Close()
+
+	if rsp.StatusCode/100 != 2 {
+		return 0, newResponseError(rsp, "datastore/uid")
+	}
+	if _, err := io.ReadAll(rsp.Body); err != nil {
+		return 0, fmt.Errorf("failed to read body: %w", err)
+	}
+	return time.Since(start), nil
+}
@@ -0,0 +1,182 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Profile is a set of devices that make sense on a particular model,
+// for when none are configured
+type Profile struct {
+	// The model as the interface reports it, e.g. "828es"
+	Model string
+
+	Devices map[string]*ProfileDevice
+}
+
+// ProfileDevice is a device in a Profile. Output banks are numbered
+// differently on different models and firmware, so outputs are found
+// by the name of their bank instead of its index.
+type ProfileDevice struct {
+	// The output bank's name, e.g. "Main Out". Empty if
+	// Property is a path instead, e.g. to a mixer fader.
+	Bank string
+
+	// The channel within the bank, and the property of it that
+	// sets the level, e.g. "stereoTrim" for a stereo pair
+	Channel  int
+	Property string
+
+	MuteProperty string
+	Scale        Scale
+	Max          float64
+	Min          float64
+	ZeroVolume   float64
+}
+
+// The ranges of output trims and mixer faders. Trims go down to -127 dB,
+// but everything below -50 dB is inaudible, so that's where stepping
+// down skips to silence.
+const (
+	profileTrimMin       = -50
+	profileTrimZeroLevel = -127
+	profileFaderMin      = -64
+)
+
+// The main mix's fader and mute, which every model has
+var (
+	profileMainMix = &ProfileDevice{
+		Property:     "datastore/mix/main/0/matrix/fader",
+		MuteProperty: "datastore/mix/main/0/matrix/mute",
+		Scale:        ScaleLog,
+		Min:          profileFaderMin,
+	}
+
+	profileMainOut = &ProfileDevice{
+		Bank:         "Main Out",
+		Property:     "stereoTrim",
+		MuteProperty: "datastore/mix/main/0/matrix/mute",
+		Scale:        ScaleLinear,
+		Min:          profileTrimMin,
+		ZeroVolume:   profileTrimZeroLevel,
+	}
+
+	profilePhones = &ProfileDevice{
+		Bank:       "Phones",
+		Property:   "stereoTrim",
+		Scale:      ScaleLinear,
+		Min:        profileTrimMin,
+		ZeroVolume: profileTrimZeroLevel,
+	}
+)
+
+// Profiles are the built-in profiles. Models with a main output
+// control it with the output's trim; the rest, which only have line
+// outputs, use the main mix's fader.
+var Profiles = []*Profile{
+	{
+		Model: "828es",
+		Devices: map[string]*ProfileDevice{
+			"main":       profileMainOut,
+			"headphones": profilePhones,
+			"mix":        profileMainMix,
+		},
+	},
+	{
+		Model: "UltraLite-mk5",
+		Devices: map[string]*ProfileDevice{
+			"main":       profileMainOut,
+			"headphones": profilePhones,
+			"mix":        profileMainMix,
+		},
+	},
+	{
+		Model: "8A",
+		Devices: map[string]*ProfileDevice{
+			"main": profileMainMix,
+		},
+	},
+	{
+		Model: "16A",
+		Devices: map[string]*ProfileDevice{
+			"main": profileMainMix,
+		},
+	},
+}
+
+var notAlphanumeric = regexp.MustCompile(`[^a-z0-9]`)
+
+// FindProfile returns the built-in profile for a model, ignoring
+// case and punctuation, or nil if there isn't one
+func FindProfile(model string) *Profile {
+	normalise := func(s string) string {
+		return notAlphanumeric.ReplaceAllString(strings.ToLower(s), "")
+	}
+
+	for _, p := range Profiles {
+		if normalise(p.Model) == normalise(model) {
+			return p
+		}
+	}
+	return nil
+}
+
+var outputBankNameKey = regexp.MustCompile(`^ext/obank/(\d+)/name$`)
+
+// ProfileDevices returns the profile's devices as they are on this
+// interface, with outputs found by bank name. Devices whose bank or
+// property the interface doesn't have are left out.
+func (c *Client) ProfileDevices(p *Profile) (map[string]*Device, error) {
+	return c.ProfileDevicesContext(context.Background(), p)
+}
+
+// ProfileDevicesContext is like ProfileDevices but
+// the request is cancelled with ctx
+func (c *Client) ProfileDevicesContext(ctx context.Context, p *Profile) (map[string]*Device, error) {
+	tree, err := c.GetTreeContext(ctx, "datastore/ext/obank")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read output banks: %w", err)
+	}
+
+	banks := map[string]int{}
+	for k, v := range tree {
+		m := outputBankNameKey.FindStringSubmatch(k)
+		name, ok := v.(string)
+		if m == nil || !ok {
+			continue
+		}
+		index, _ := strconv.Atoi(m[1])
+		banks[strings.ToLower(name)] = index
+	}
+
+	devices := map[string]*Device{}
+	for name, pd := range p.Devices {
+		d := &Device{
+			Property:     pd.Property,
+			MuteProperty: pd.MuteProperty,
+			Scale:        pd.Scale,
+			Max:          pd.Max,
+			Min:          pd.Min,
+			ZeroVolume:   pd.ZeroVolume,
+		}
+
+		if pd.Bank != "" {
+			index, ok := banks[strings.ToLower(pd.Bank)]
+			if !ok {
+				continue
+			}
+			key := fmt.Sprintf("ext/obank/%d/ch/%d/%s", index, pd.Channel, pd.Property)
+			if _, ok := tree[key]; !ok {
+				continue
+			}
+			d.Property = Path(key)
+		}
+
+		devices[name] = d
+	}
+
+	return devices, nil
+}
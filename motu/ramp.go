@@ -0,0 +1,99 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+const (
+	// How often intermediate values are sent during a ramp. Faster
+	// than this and the device starts to fall behind.
+	rampInterval = 40 * time.Millisecond
+
+	// A logarithmic property at zero has no level in dB, so ramps
+	// to or from zero treat it as this instead. It's far enough below
+	// any device's usable range to be inaudible.
+	rampFloorDB = -96
+)
+
+// RampTarget is a numeric property to move during a ramp
+type RampTarget struct {
+	Property string
+
+	// The value to finish at
+	To float64
+
+	// How to interpolate between the current value and the target.
+	// Logarithmic properties are interpolated in dB so that the
+	// change in loudness is even over the duration of the ramp.
+	Scale Scale
+}
+
+// Ramp moves each property from its current value to its target over
+// the given duration, sending intermediate values at a steady rate.
+// The final values are always sent exactly, whatever the duration.
+func (c *Client) Ramp(targets []RampTarget, duration time.Duration) error {
+	return c.RampContext(context.Background(), targets, duration)
+}
+
+// RampContext is like Ramp but requests are cancelled with ctx, and
+// it stops where it is, returning ctx.Err(), if ctx is cancelled
+func (c *Client) RampContext(ctx context.Context, targets []RampTarget, duration time.Duration) error {
+	from := make([]float64, len(targets))
+	for i, t := range targets {
+		v, err := c.GetContext(ctx, t.Property)
+		if err != nil {
+			return fmt.Errorf("failed to get current value of %s: %w", t.Property, err)
+		}
+		from[i] = v
+	}
+
+	start := time.Now()
+	ticker := time.NewTicker(rampInterval)
+	defer ticker.Stop()
+
+	for {
+		progress := 1.0
+		if duration > 0 {
+			progress = math.Min(float64(time.Since(start))/float64(duration), 1)
+		}
+
+		values := make(map[string]any, len(targets))
+		for i, t := range targets {
+			values[Key(t.Property)] = interpolate(t.Scale, from[i], t.To, progress)
+		}
+
+		if err := c.SetValuesContext(ctx, values); err != nil {
+			return err
+		}
+
+		if progress >= 1 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// interpolate returns the value that is the given fraction of the
+// way from a to b. Values at the very end are exact.
+func interpolate(scale Scale, a, b, progress float64) float64 {
+	if progress >= 1 {
+		return b
+	}
+
+	if scale != ScaleLog {
+		return a + (b-a)*progress
+	}
+
+	aDB := math.Max(AmplitudeToDB(a), rampFloorDB)
+	bDB := math.Max(AmplitudeToDB(b), rampFloorDB)
+
+	return DBToAmplitude(aDB + (bDB-aDB)*progress)
+}
@@ -0,0 +1,100 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Route is what feeds an output channel
+type Route struct {
+	Bank    int
+	Channel int
+
+	// The input channel routed to the output, or nil if nothing is
+	Source *Input
+}
+
+var routeKey = regexp.MustCompile(`^ext/obank/(\d+)/ch/(\d+)/src$`)
+
+// Routes returns the source of every output channel,
+// in order of output bank and channel
+func (c *Client) Routes() ([]*Route, error) {
+	return c.RoutesContext(context.Background())
+}
+
+// RoutesContext is like Routes but the request is cancelled with ctx
+func (c *Client) RoutesContext(ctx context.Context) ([]*Route, error) {
+	tree, err := c.GetTreeContext(ctx, "datastore/ext")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	return RoutesFromTree(tree), nil
+}
+
+// RoutesFromTree is like Routes but reads from values
+// that have already been fetched, e.g. by GetTree
+func RoutesFromTree(tree map[string]any) []*Route {
+	var routes []*Route
+	for k, v := range tree {
+		m := routeKey.FindStringSubmatch(k)
+		if m == nil {
+			continue
+		}
+
+		r := &Route{}
+		r.Bank, _ = strconv.Atoi(m[1])
+		r.Channel, _ = strconv.Atoi(m[2])
+
+		// Sources are given as "<input bank>:<channel>", or
+		// an empty string when nothing is routed
+		if src, ok := v.(string); ok {
+			bank, ch, ok := strings.Cut(src, ":")
+			b, err1 := strconv.Atoi(bank)
+			c, err2 := strconv.Atoi(ch)
+			if ok && err1 == nil && err2 == nil {
+				r.Source = &Input{Bank: b, Channel: c}
+			}
+		}
+
+		routes = append(routes, r)
+	}
+
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Bank != routes[j].Bank {
+			return routes[i].Bank < routes[j].Bank
+		}
+		return routes[i].Channel < routes[j].Channel
+	})
+
+	return routes
+}
+
+// SetRoute routes an input channel to an output channel.
+// A nil source disconnects the output.
+func (c *Client) SetRoute(bank, channel int, source *Input) error {
+	return c.SetRouteContext(context.Background(), bank, channel, source)
+}
+
+// SetRouteContext is like SetRoute but requests are cancelled with ctx
+func (c *Client) SetRouteContext(ctx context.Context, bank, channel int, source *Input) error {
+	var src string
+	if source != nil {
+		src = fmt.Sprintf("%d:%d", source.Bank, source.Channel)
+	}
+
+	property := fmt.Sprintf("datastore/ext/obank/%d/ch/%d/src", bank, channel)
+	if err := c.RequireContext(ctx, property); err != nil {
+		return err
+	}
+
+	if err := c.SetValueContext(ctx, property, src); err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+
+	return nil
+}
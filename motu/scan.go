@@ -0,0 +1,128 @@
+package motu
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultScanTimeout is how long to wait for each host to respond
+	DefaultScanTimeout = time.Second
+
+	// How many hosts to probe at once
+	scanConcurrency = 64
+
+	// The most hosts a scan covers, i.e. a /16
+	maxScanHosts = 1 << 16
+)
+
+// ScanResult is an interface found by Scan
+type ScanResult struct {
+	// Host and port of the device's HTTP API
+	Address string
+
+	*Info
+}
+
+// Scan probes every host in prefix for a datastore on the given port,
+// for networks where mDNS doesn't get through. Hosts that don't answer
+// within the timeout, or answer without a datastore, are skipped. The
+// results are in order of address.
+func Scan(ctx context.Context, prefix netip.Prefix, port int, timeout time.Duration) ([]*ScanResult, error) {
+	hosts, err := scanHosts(prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		result []*ScanResult
+	)
+
+	addrs := make(chan netip.Addr)
+	for range min(scanConcurrency, len(hosts)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for addr := range addrs {
+				if r := probe(ctx, net.JoinHostPort(addr.String(), strconv.Itoa(port)), timeout); r != nil {
+					mu.Lock()
+					result = append(result, r)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+	for _, addr := range hosts {
+		if ctx.Err() != nil {
+			break
+		}
+		addrs <- addr
+	}
+	close(addrs)
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		a, _ := netip.ParseAddrPort(result[i].Address)
+		b, _ := netip.ParseAddrPort(result[j].Address)
+		return a.Addr().Less(b.Addr())
+	})
+
+	return result, nil
+}
+
+// scanHosts returns the host addresses in prefix, leaving out the
+// network and broadcast addresses of IPv4 networks bigger than a /31
+func scanHosts(prefix netip.Prefix) ([]netip.Addr, error) {
+	prefix = prefix.Masked()
+
+	bits := prefix.Addr().BitLen() - prefix.Bits()
+	if bits > 16 {
+		return nil, fmt.Errorf("%s has too many addresses to scan, the most is %d", prefix, maxScanHosts)
+	}
+
+	var hosts []netip.Addr
+	for addr := prefix.Addr(); prefix.Contains(addr); addr = addr.Next() {
+		hosts = append(hosts, addr)
+	}
+
+	if prefix.Addr().Is4() && bits > 1 {
+		hosts = hosts[1 : len(hosts)-1]
+	}
+	return hosts, nil
+}
+
+// probe returns the interface at address, or nil if there isn't one
+func probe(ctx context.Context, address string, timeout time.Duration) *ScanResult {
+	c := &Client{
+		Address:    &url.URL{Scheme: "http", Host: address},
+		HTTPClient: newHTTPClient(timeout),
+	}
+	defer c.HTTPClient.CloseIdleConnections()
+
+	// Plenty of things serve HTTP, but only a MOTU has a UID in a datastore
+	uid, err := c.GetStringContext(ctx, "datastore/uid")
+	if err != nil || uid == "" {
+		return nil
+	}
+
+	info, err := c.Info()
+	if err != nil {
+		info = &Info{UID: uid}
+	}
+
+	return &ScanResult{Address: address, Info: info}
+}
@@ -0,0 +1,215 @@
+package motu
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// RangeError is returned when a value is outside the range a property
+// allows. It's checked before the value is sent, as the interface would
+// otherwise clamp it, or reject it without saying why.
+type RangeError struct {
+	Property string
+	Value    float64
+	Min      float64
+	Max      float64
+}
+
+func (e *RangeError) Error() string {
+	return fmt.Sprintf("value %s out of range [%s, %s] for %s",
+		formatNumber(e.Value), formatNumber(e.Min), formatNumber(e.Max), Path(e.Property))
+}
+
+// Is makes errors.Is report a RangeError as ErrValueOutOfRange
+func (e *RangeError) Is(target error) bool {
+	return target == ErrValueOutOfRange
+}
+
+// propertyRule is what the datastore allows for properties
+// whose keys match a pattern, in which * matches one segment
+type propertyRule struct {
+	pattern  string
+	min, max float64
+
+	// Whether the value has to be a whole number
+	integer bool
+
+	// A sibling property that holds the range as [min, max], which
+	// newer firmware reports. min and max are used if it doesn't.
+	rangeProperty string
+}
+
+// propertyRules are the writable properties with a known range,
+// from MOTU's datastore API documentation. Fader and send levels are
+// amplitude ratios, so 4 is about +12 dB. Input trims differ between
+// preamps and line inputs, so only their reported range is checked.
+// Trims take fractional dB, e.g. from a taper's steps or a fade.
+var propertyRules = []*propertyRule{
+	{pattern: "ext/ibank/*/ch/*/trim", min: math.Inf(-1), max: math.Inf(1), rangeProperty: "trimRange"},
+	{pattern: "ext/obank/*/ch/*/trim", min: -127, max: 0, rangeProperty: "trimRange"},
+	{pattern: "ext/obank/*/ch/*/stereoTrim", min: -127, max: 0, rangeProperty: "stereoTrimRange"},
+	{pattern: "ext/ibank/*/ch/*/phase", max: 1, integer: true},
+	{pattern: "ext/ibank/*/ch/*/pad", max: 1, integer: true},
+	{pattern: "ext/ibank/*/ch/*/48V", max: 1, integer: true},
+	{pattern: "mix/*/*/matrix/fader", max: 4},
+	{pattern: "mix/*/*/matrix/pan", min: -1, max: 1},
+	{pattern: "mix/*/*/matrix/mute", max: 1},
+	{pattern: "mix/*/*/matrix/solo", max: 1},
+	{pattern: "mix/*/*/matrix/enable", max: 1},
+	{pattern: "mix/*/*/matrix/*/*/send", max: 4},
+	{pattern: "mix/*/*/matrix/*/*/pan", min: -1, max: 1},
+}
+
+// findRule returns the rule for a key, or nil if there isn't one
+func findRule(key string) *propertyRule {
+	segments := strings.Split(key, "/")
+	for _, r := range propertyRules {
+		pattern := strings.Split(r.pattern, "/")
+		if len(pattern) != len(segments) {
+			continue
+		}
+		match := true
+		for i, p := range pattern {
+			if p != "*" && p != segments[i] {
+				match = false
+				break
+			}
+		}
+		if match {
+			return r
+		}
+	}
+	return nil
+}
+
+// propertyRanges caches the ranges that the interface reports, keyed
+// by the range property. Ranges don't change while the interface is
+// on, so each one is only read once.
+type propertyRanges struct {
+	mu     sync.Mutex
+	ranges map[string]*[2]float64
+}
+
+// validate returns a *RangeError if any of the values, keyed by
+// path relative to the datastore root, is outside its property's
+// range. Properties without a known range aren't checked.
+func (c *Client) validate(ctx context.Context, values map[string]any) error {
+	if c.SkipValidation {
+		return nil
+	}
+
+	for _, key := range sortedValueKeys(values) {
+		r := findRule(key)
+		if r == nil {
+			continue
+		}
+
+		v, ok := toFloat(values[key])
+		if !ok {
+			continue
+		}
+
+		lo, hi := c.propertyRange(ctx, key, r)
+		if v < lo || v > hi {
+			return &RangeError{Property: key, Value: v, Min: lo, Max: hi}
+		}
+		if r.integer && v != math.Trunc(v) {
+			return fmt.Errorf("%w: %s must be a whole number, not %s", ErrValueOutOfRange, Path(key), formatNumber(v))
+		}
+	}
+
+	return nil
+}
+
+// propertyRange returns the range of the property at key, as the
+// interface reports it if it does, or else as the rule has it
+func (c *Client) propertyRange(ctx context.Context, key string, r *propertyRule) (float64, float64) {
+	if r.rangeProperty == "" {
+		return r.min, r.max
+	}
+
+	rangeKey := key[:strings.LastIndex(key, "/")+1] + r.rangeProperty
+
+	c.ranges.mu.Lock()
+	cached, ok := c.ranges.ranges[rangeKey]
+	c.ranges.mu.Unlock()
+	if ok {
+		if cached == nil {
+			return r.min, r.max
+		}
+		return cached[0], cached[1]
+	}
+
+	v, err := c.ValueContext(ctx, Path(rangeKey))
+	if err != nil && !errors.Is(err, ErrPropertyNotFound) {
+		// Try again next time, and let the write itself fail if
+		// the interface can't be reached
+		return r.min, r.max
+	}
+
+	var found *[2]float64
+	if pair, ok := v.([]any); ok && len(pair) == 2 {
+		lo, ok1 := toFloat(pair[0])
+		hi, ok2 := toFloat(pair[1])
+		if ok1 && ok2 && lo <= hi {
+			found = &[2]float64{lo, hi}
+		}
+	}
+
+	c.ranges.mu.Lock()
+	if c.ranges.ranges == nil {
+		c.ranges.ranges = map[string]*[2]float64{}
+	}
+	c.ranges.ranges[rangeKey] = found
+	c.ranges.mu.Unlock()
+
+	if found == nil {
+		return r.min, r.max
+	}
+	return found[0], found[1]
+}
+
+// sortedValueKeys returns the keys of values in order, so that
+// the same invalid batch always gets the same error
+func sortedValueKeys(values map[string]any) []string {
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// toFloat returns a numeric value as a float64
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case bool:
+		if n {
+			return 1, true
+		}
+		return 0, true
+	default:
+		return 0, false
+	}
+}
+
+// formatNumber formats n without trailing zeros
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
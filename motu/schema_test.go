@@ -0,0 +1,72 @@
+package motu_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+func TestValidateRange(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+
+	err := c.Set(trimProperty, -200)
+	var rerr *motu.RangeError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Set returned %v, want a *RangeError", err)
+	}
+	if want := "value -200 out of range [-127, 0] for " + trimProperty; err.Error() != want {
+		t.Errorf("got error %q, want %q", err, want)
+	}
+	if got, _ := s.Value(trimProperty); got != -20.0 {
+		t.Errorf("trim is %v after a rejected change, want -20", got)
+	}
+}
+
+// The trim's range comes from the interface when it reports one
+func TestValidateReportedRange(t *testing.T) {
+	c, _ := newTestClient(t, map[string]any{
+		"ext/obank/1/ch/0/stereoTrim":      -20.0,
+		"ext/obank/1/ch/0/stereoTrimRange": []any{-60.0, 0.0},
+	})
+
+	err := c.Set(trimProperty, -80)
+	var rerr *motu.RangeError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("Set returned %v, want a *RangeError", err)
+	}
+	if rerr.Min != -60 || rerr.Max != 0 {
+		t.Errorf("got range [%v, %v], want [-60, 0]", rerr.Min, rerr.Max)
+	}
+}
+
+func TestValidateFractions(t *testing.T) {
+	c, _ := newTestClient(t, map[string]any{
+		"ext/obank/1/ch/0/stereoTrim":      -20.0,
+		"ext/obank/1/ch/0/stereoTrimRange": []any{-127.0, 0.0},
+		"ext/ibank/0/ch/0/phase":           0.0,
+	})
+
+	for _, v := range []float64{-46.875, -26.875, -20.00007155} {
+		if err := c.Set(trimProperty, v); err != nil {
+			t.Errorf("Set(%v) failed: %v", v, err)
+		}
+	}
+
+	// Switches are still whole numbers
+	if err := c.Set("datastore/ext/ibank/0/ch/0/phase", 0.5); !errors.Is(err, motu.ErrValueOutOfRange) {
+		t.Errorf("Set(0.5) on a switch returned %v, want ErrValueOutOfRange", err)
+	}
+}
+
+func TestSkipValidation(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	c.SkipValidation = true
+
+	if err := c.Set(trimProperty, -200); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	if got, _ := s.Value(trimProperty); got != -200.0 {
+		t.Errorf("trim is %v, want -200", got)
+	}
+}
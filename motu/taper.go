@@ -0,0 +1,121 @@
+package motu
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Curves that a taper can follow
+const (
+	// Positions are spread evenly in dB between Min and Max
+	CurveDB = "db"
+
+	// The amplitude is the cube of the position, as with an
+	// analogue volume pot. Steps are bigger in dB towards the
+	// bottom, so the level fades smoothly into silence.
+	CurveCubic = "cubic"
+
+	// Positions are mapped through a table of breakpoints
+	CurvePoints = "points"
+)
+
+// Taper maps the position of a control, from 0 to 100, onto a level
+// in dB. Steps and percentages move evenly through the positions, so
+// the right taper makes every step sound like the same change in
+// loudness. Position 0 is always zero volume.
+//
+// In YAML, it's either the name of a curve or a list of breakpoints
+// as [position, dB] pairs, e.g. [[0, -80], [50, -30], [100, 0]].
+type Taper struct {
+	// One of the curves. Empty is CurveDB.
+	Curve string
+
+	// For CurvePoints, positions and their levels in dB, in order.
+	// Levels in between are interpolated in dB.
+	Points [][2]float64
+}
+
+// UnmarshalYAML reads a curve name or a list of breakpoints
+func (t *Taper) UnmarshalYAML(unmarshal func(any) error) error {
+	var curve string
+	if err := unmarshal(&curve); err == nil {
+		*t = Taper{Curve: curve}
+		return nil
+	}
+
+	var points [][2]float64
+	if err := unmarshal(&points); err != nil {
+		return fmt.Errorf("taper must be a curve or a list of [position, dB] pairs")
+	}
+
+	*t = Taper{Curve: CurvePoints, Points: points}
+	return nil
+}
+
+// validate returns an error if the taper can't be used
+func (t *Taper) validate() error {
+	switch t.Curve {
+	case "", CurveDB, CurveCubic:
+		return nil
+	case CurvePoints:
+	default:
+		return fmt.Errorf("unknown taper %q", t.Curve)
+	}
+
+	if len(t.Points) < 2 {
+		return fmt.Errorf("taper needs at least two points")
+	}
+	if first, last := t.Points[0][0], t.Points[len(t.Points)-1][0]; first != 0 || last != 100 {
+		return fmt.Errorf("taper points must go from position 0 to 100")
+	}
+	for i := 1; i < len(t.Points); i++ {
+		if t.Points[i][0] <= t.Points[i-1][0] || t.Points[i][1] <= t.Points[i-1][1] {
+			return fmt.Errorf("taper points must go up in both position and level")
+		}
+	}
+
+	return nil
+}
+
+// linear returns whether steps are even in dB
+func (t *Taper) linear() bool {
+	return t.Curve == "" || t.Curve == CurveDB
+}
+
+// toDB converts a position in (0, 100] to a level in dB
+func (t *Taper) toDB(d *Device, position float64) float64 {
+	switch t.Curve {
+	case CurveCubic:
+		return d.Max + 60*math.Log10(position/100)
+	case CurvePoints:
+		i := sort.Search(len(t.Points), func(i int) bool { return t.Points[i][0] >= position })
+		if i == 0 {
+			return t.Points[0][1]
+		}
+		lo, hi := t.Points[i-1], t.Points[i]
+		return lo[1] + (hi[1]-lo[1])*(position-lo[0])/(hi[0]-lo[0])
+	default:
+		return d.Min + (d.Max-d.Min)*position/100
+	}
+}
+
+// toPosition is the inverse of toDB
+func (t *Taper) toPosition(d *Device, db float64) float64 {
+	switch t.Curve {
+	case CurveCubic:
+		return 100 * math.Pow(10, (db-d.Max)/60)
+	case CurvePoints:
+		i := sort.Search(len(t.Points), func(i int) bool { return t.Points[i][1] >= db })
+		if i == 0 {
+			return 0
+		}
+		if i == len(t.Points) {
+			return 100
+		}
+		lo, hi := t.Points[i-1], t.Points[i]
+		return lo[0] + (hi[0]-lo[0])*(db-lo[1])/(hi[1]-lo[1])
+	default:
+		return 100 * (db - d.Min) / (d.Max - d.Min)
+	}
+}
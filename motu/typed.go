@@ -0,0 +1,105 @@
+package motu
+
+import (
+	"context"
+	"math"
+)
+
+// The types of the properties returned by code that "motu codegen"
+// generates from a datastore dump. Each is the property's path, so
+// they can also be used anywhere a path is taken.
+type (
+	// FloatProperty is a property with a fractional value, e.g. a fader
+	FloatProperty string
+
+	// IntProperty is a property with a whole number value, e.g. a trim
+	// or a switch that's 0 or 1
+	IntProperty string
+
+	// StringProperty is a property with a string value, e.g. a name
+	StringProperty string
+
+	// ValueProperty is a property with any other value, e.g. a range
+	ValueProperty string
+)
+
+// Get reads the property
+func (p FloatProperty) Get(c *Client) (float64, error) {
+	return p.GetContext(context.Background(), c)
+}
+
+// GetContext is like Get but the request is cancelled with ctx
+func (p FloatProperty) GetContext(ctx context.Context, c *Client) (float64, error) {
+	return c.GetContext(ctx, string(p))
+}
+
+// Set updates the property
+func (p FloatProperty) Set(c *Client, v float64) error {
+	return p.SetContext(context.Background(), c, v)
+}
+
+// SetContext is like Set but the request is cancelled with ctx
+func (p FloatProperty) SetContext(ctx context.Context, c *Client, v float64) error {
+	return c.SetContext(ctx, string(p), v)
+}
+
+// Get reads the property
+func (p IntProperty) Get(c *Client) (int, error) {
+	return p.GetContext(context.Background(), c)
+}
+
+// GetContext is like Get but the request is cancelled with ctx
+func (p IntProperty) GetContext(ctx context.Context, c *Client) (int, error) {
+	v, err := c.GetContext(ctx, string(p))
+	return int(math.Round(v)), err
+}
+
+// Set updates the property
+func (p IntProperty) Set(c *Client, v int) error {
+	return p.SetContext(context.Background(), c, v)
+}
+
+// SetContext is like Set but the request is cancelled with ctx
+func (p IntProperty) SetContext(ctx context.Context, c *Client, v int) error {
+	return c.SetValueContext(ctx, string(p), v)
+}
+
+// Get reads the property
+func (p StringProperty) Get(c *Client) (string, error) {
+	return p.GetContext(context.Background(), c)
+}
+
+// GetContext is like Get but the request is cancelled with ctx
+func (p StringProperty) GetContext(ctx context.Context, c *Client) (string, error) {
+	return c.GetStringContext(ctx, string(p))
+}
+
+// Set updates the property
+func (p StringProperty) Set(c *Client, v string) error {
+	return p.SetContext(context.Background(), c, v)
+}
+
+// SetContext is like Set but the request is cancelled with ctx
+func (p StringProperty) SetContext(ctx context.Context, c *Client, v string) error {
+	return c.SetValueContext(ctx, string(p), v)
+}
+
+// Get reads the property
+func (p ValueProperty) Get(c *Client) (any, error) {
+	return p.GetContext(context.Background(), c)
+}
+
+// GetContext is like Get but the request is cancelled with ctx
+func (p ValueProperty) GetContext(ctx context.Context, c *Client) (any, error) {
+	return c.ValueContext(ctx, string(p))
+}
+
+// Set updates the property. The value is encoded as JSON.
+func (p ValueProperty) Set(c *Client, v any) error {
+	return p.SetContext(context.Background(), c, v)
+}
+
+// SetContext is like Set but the request is cancelled with ctx
+func (p ValueProperty) SetContext(ctx context.Context, c *Client, v any) error {
+	return c.SetValueContext(ctx, string(p), v)
+}
@@ -0,0 +1,100 @@
+package motu
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxConflicts is how many times update starts again when
+// the datastore changes between reading and writing
+const maxConflicts = 3
+
+// update reads a numeric property, works out its new value with fn and
+// writes it, but only if the datastore hasn't changed in the meantime.
+// If it has, e.g. because a fader was moved in the web UI at the same
+// moment, the property is read again and fn is given the fresh value.
+// Interfaces that don't send an ETag always take the write.
+func (c *Client) update(ctx context.Context, property string, fn func(float64) (float64, error)) (float64, error) {
+	for attempt := 0; ; attempt++ {
+		// The mirror's or cache's ETag can lag behind writes,
+		// so after a conflict, read from the device instead
+		current, etag, err := c.getWithVersion(ctx, property, attempt > 0)
+		if err != nil {
+			return 0, fmt.Errorf("failed to get current value: %w", err)
+		}
+
+		newValue, err := fn(current)
+		if err != nil {
+			return 0, err
+		}
+
+		if err := c.validate(ctx, map[string]any{Key(property): newValue}); err != nil {
+			return 0, err
+		}
+
+		if c.deferred != nil {
+			values := map[string]any{Key(property): newValue}
+			c.deferred.hold(values, map[string]any{Key(property): current})
+			return newValue, nil
+		}
+
+		etag, err = c.patchIfMatch(ctx, property, fmt.Sprintf(`{"value": %f}`, newValue), etag)
+		if errors.Is(err, ErrConflict) && attempt < maxConflicts {
+			continue
+		} else if err != nil {
+			return 0, fmt.Errorf("failed to update property: %w", err)
+		}
+
+		values := map[string]any{Key(property): newValue}
+		c.remember(values, etag)
+		c.changed(map[string]any{Key(property): current}, values)
+
+		return newValue, nil
+	}
+}
+
+// getWithVersion returns the value of a numeric property and the ETag
+// of the datastore it was read from. Unless fresh is true, the value
+// comes from the mirror or the cache if possible, but only along with
+// an ETag, so that the write is rejected if the value is stale.
+func (c *Client) getWithVersion(ctx context.Context, property string, fresh bool) (float64, string, error) {
+	if !fresh {
+		// Changes being deferred aren't sent with an If-Match
+		if c.deferred != nil {
+			if v, ok := c.deferred.value(property); ok {
+				if v, ok := v.(float64); ok {
+					return v, "", nil
+				}
+			}
+		}
+
+		if cached, etag, ok := c.recall(property); ok && etag != "" {
+			if v, ok := cached.(float64); ok {
+				return v, etag, nil
+			}
+		}
+	}
+
+	body, etag, err := c.getWithETag(ctx, property)
+	if errors.Is(err, ErrPropertyNotFound) {
+		version, _ := c.APIVersionContext(ctx)
+		return 0, "", &UnsupportedError{Property: property, APIVersion: version}
+	} else if err != nil {
+		return 0, "", err
+	}
+
+	var parsed struct {
+		Value float64 `json:"value"`
+	}
+	if err := json.Unmarshal(body, &parsed); err != nil {
+		return 0, "", fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+
+	if c.cache != nil {
+		c.cache.Put(property, parsed.Value, etag)
+	}
+
+	return parsed.Value, etag, nil
+}
@@ -0,0 +1,208 @@
+package motu_test
+
+import (
+	"context"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const trimProperty = "datastore/ext/obank/1/ch/0/stereoTrim"
+
+// trimDevice is like the default main device: a trim from -50 dB to
+// 0 dB in 16 steps, which puts most steps on fractions of a dB
+func trimDevice(t *testing.T) *motu.Device {
+	t.Helper()
+
+	d := &motu.Device{
+		Property:     trimProperty,
+		MuteProperty: "datastore/mix/main/0/matrix/mute",
+		Scale:        motu.ScaleLinear,
+		Min:          -50,
+		Max:          0,
+		ZeroVolume:   -127,
+		Steps:        16,
+	}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("invalid device: %v", err)
+	}
+	return d
+}
+
+func trimValues(level float64) map[string]any {
+	return map[string]any{
+		"ext/obank/1/ch/0/stereoTrim":      level,
+		"ext/obank/1/ch/0/stereoTrimRange": []any{-127.0, 0.0},
+		"mix/main/0/matrix/mute":           0.0,
+	}
+}
+
+func TestIncDec(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	d := trimDevice(t)
+
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	if want := -20 + d.StepSize(); v != want {
+		t.Errorf("IncDec returned %v, want %v", v, want)
+	}
+	if got, _ := s.Value(trimProperty); got != v {
+		t.Errorf("trim is %v, want %v", got, v)
+	}
+
+	v, err = c.IncDec(d, false)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	// Levels are rounded to a tenth of a dB before stepping
+	if math.Abs(v - -20) > 0.1 {
+		t.Errorf("IncDec down returned %v, want about -20", v)
+	}
+}
+
+// A value read before someone else changed the datastore is
+// rejected with 412, and the change is made again from the new value
+func TestIncDecRetriesAfterConflict(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	d := trimDevice(t)
+	log := logRequests(c)
+
+	// Read the value and the ETag into the cache, then change the
+	// value behind the client's back
+	c.UseCache(motu.NewCache(time.Minute))
+	if _, err := c.Get(trimProperty); err != nil {
+		t.Fatalf("Get failed: %v", err)
+	}
+	s.Set(map[string]any{trimProperty: -40.0})
+
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+
+	if want := -40 + d.StepSize(); v != want {
+		t.Errorf("IncDec returned %v, want %v from the new value", v, want)
+	}
+	if n := log.count(http.StatusPreconditionFailed); n != 1 {
+		t.Errorf("got %d 412 responses, want 1", n)
+	}
+}
+
+func TestIncDecWithoutETag(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	d := trimDevice(t)
+
+	// Interfaces that don't send an ETag always take the write
+	log := logRequests(c)
+	log.modify = func(rsp *http.Response) { rsp.Header.Del("ETag") }
+
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	if got, _ := s.Value(trimProperty); got != v {
+		t.Errorf("trim is %v, want %v", got, v)
+	}
+}
+
+// The default main device's steps, dims and fades all land on
+// fractions of a dB, which the trim has to take
+func TestFractionalTrims(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-50))
+	d := trimDevice(t)
+
+	if _, err := c.SetLevel(d, -12.5); err != nil {
+		t.Errorf("SetLevel(-12.5) failed: %v", err)
+	}
+
+	if _, err := c.SetLevel(d, -50); err != nil {
+		t.Fatalf("SetLevel(-50) failed: %v", err)
+	}
+	v, err := c.IncDec(d, true)
+	if err != nil {
+		t.Fatalf("IncDec failed: %v", err)
+	}
+	if v != -46.875 {
+		t.Errorf("IncDec returned %v, want -46.875", v)
+	}
+
+	err = c.Ramp([]motu.RampTarget{{Property: trimProperty, To: -30, Scale: motu.ScaleLinear}}, 100*time.Millisecond)
+	if err != nil {
+		t.Errorf("Ramp failed: %v", err)
+	}
+	if got, _ := s.Value(trimProperty); got != -30.0 {
+		t.Errorf("trim is %v after the ramp, want -30", got)
+	}
+}
+
+// A cancelled ramp doesn't send anything more, including the
+// request it would otherwise be about to make
+func TestRampCancelled(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-50))
+	log := logRequests(c)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.RampContext(ctx, []motu.RampTarget{{Property: trimProperty, To: -30, Scale: motu.ScaleLinear}}, time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("RampContext returned %v, want context.Canceled", err)
+	}
+	if n := log.methods(http.MethodPatch); n != 0 {
+		t.Errorf("made %d PATCH requests, want none", n)
+	}
+	if got, _ := s.Value(trimProperty); got != -50.0 {
+		t.Errorf("trim is %v after a cancelled ramp, want -50", got)
+	}
+}
+
+func TestIncDecChecksRange(t *testing.T) {
+	c, s := newTestClient(t, map[string]any{
+		"ext/obank/1/ch/0/stereoTrim":      -0.5,
+		"ext/obank/1/ch/0/stereoTrimRange": []any{-127.0, 0.0},
+	})
+
+	// A device whose range goes past the trim's
+	d := &motu.Device{Property: trimProperty, Scale: motu.ScaleLinear, Min: -50, Max: 6, ZeroVolume: -127, Steps: 16, StepDB: 2}
+	if err := d.Validate(); err != nil {
+		t.Fatalf("invalid device: %v", err)
+	}
+
+	_, err := c.IncDec(d, true)
+	var rerr *motu.RangeError
+	if !errors.As(err, &rerr) {
+		t.Fatalf("IncDec returned %v, want a *RangeError", err)
+	}
+	if !errors.Is(err, motu.ErrValueOutOfRange) {
+		t.Errorf("IncDec returned %v, want ErrValueOutOfRange", err)
+	}
+	if got, _ := s.Value(trimProperty); got != -0.5 {
+		t.Errorf("trim is %v after a rejected change, want -0.5", got)
+	}
+}
+
+func TestMute(t *testing.T) {
+	c, s := newTestClient(t, trimValues(-20))
+	d := trimDevice(t)
+
+	muted, err := c.Mute(d)
+	if err != nil {
+		t.Fatalf("Mute failed: %v", err)
+	}
+	if !muted {
+		t.Error("Mute returned false, want true")
+	}
+	if got, _ := s.Value(d.MuteProperty); got != 1.0 {
+		t.Errorf("mute is %v, want 1", got)
+	}
+
+	if muted, err = c.Mute(d); err != nil || muted {
+		t.Errorf("Mute returned %v, %v, want false", muted, err)
+	}
+}
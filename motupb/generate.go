@@ -0,0 +1,6 @@
+// Package motupb is the generated code for the daemon's gRPC API,
+// described in motu.proto. Other tools can generate clients in their
+// own languages from the same file.
+package motupb
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative motu.proto
@@ -0,0 +1,949 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        (unknown)
+// source: motu.proto
+
+// The daemon's gRPC API, served by "motu serve --grpc". It covers the
+// same ground as the HTTP API, plus a stream of state changes.
+
+package motupb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	durationpb "google.golang.org/protobuf/types/known/durationpb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type StepRequest_Direction int32
+
+const (
+	StepRequest_DIRECTION_UNSPECIFIED StepRequest_Direction = 0
+	StepRequest_DIRECTION_UP          StepRequest_Direction = 1
+	StepRequest_DIRECTION_DOWN        StepRequest_Direction = 2
+)
+
+// Enum value maps for StepRequest_Direction.
+var (
+	StepRequest_Direction_name = map[int32]string{
+		0: "DIRECTION_UNSPECIFIED",
+		1: "DIRECTION_UP",
+		2: "DIRECTION_DOWN",
+	}
+	StepRequest_Direction_value = map[string]int32{
+		"DIRECTION_UNSPECIFIED": 0,
+		"DIRECTION_UP":          1,
+		"DIRECTION_DOWN":        2,
+	}
+)
+
+func (x StepRequest_Direction) Enum() *StepRequest_Direction {
+	p := new(StepRequest_Direction)
+	*p = x
+	return p
+}
+
+func (x StepRequest_Direction) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (StepRequest_Direction) Descriptor() protoreflect.EnumDescriptor {
+	return file_motu_proto_enumTypes[0].Descriptor()
+}
+
+func (StepRequest_Direction) Type() protoreflect.EnumType {
+	return &file_motu_proto_enumTypes[0]
+}
+
+func (x StepRequest_Direction) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use StepRequest_Direction.Descriptor instead.
+func (StepRequest_Direction) EnumDescriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{4, 0}
+}
+
+type SetMuteRequest_State int32
+
+const (
+	SetMuteRequest_STATE_UNSPECIFIED SetMuteRequest_State = 0
+	SetMuteRequest_STATE_ON          SetMuteRequest_State = 1
+	SetMuteRequest_STATE_OFF         SetMuteRequest_State = 2
+	SetMuteRequest_STATE_TOGGLE      SetMuteRequest_State = 3
+)
+
+// Enum value maps for SetMuteRequest_State.
+var (
+	SetMuteRequest_State_name = map[int32]string{
+		0: "STATE_UNSPECIFIED",
+		1: "STATE_ON",
+		2: "STATE_OFF",
+		3: "STATE_TOGGLE",
+	}
+	SetMuteRequest_State_value = map[string]int32{
+		"STATE_UNSPECIFIED": 0,
+		"STATE_ON":          1,
+		"STATE_OFF":         2,
+		"STATE_TOGGLE":      3,
+	}
+)
+
+func (x SetMuteRequest_State) Enum() *SetMuteRequest_State {
+	p := new(SetMuteRequest_State)
+	*p = x
+	return p
+}
+
+func (x SetMuteRequest_State) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (SetMuteRequest_State) Descriptor() protoreflect.EnumDescriptor {
+	return file_motu_proto_enumTypes[1].Descriptor()
+}
+
+func (SetMuteRequest_State) Type() protoreflect.EnumType {
+	return &file_motu_proto_enumTypes[1]
+}
+
+func (x SetMuteRequest_State) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use SetMuteRequest_State.Descriptor instead.
+func (SetMuteRequest_State) EnumDescriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{6, 0}
+}
+
+// The state of a device, as in the HTTP API
+type DeviceState struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Device string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// The raw value of the device's property
+	Value float64 `protobuf:"fixed64,2,opt,name=value,proto3" json:"value,omitempty"`
+	// Unset if the device is at zero volume, which
+	// can't be expressed in dB
+	LevelDb *float64 `protobuf:"fixed64,3,opt,name=level_db,json=levelDb,proto3,oneof" json:"level_db,omitempty"`
+	Percent float64  `protobuf:"fixed64,4,opt,name=percent,proto3" json:"percent,omitempty"`
+	Muted   bool     `protobuf:"varint,5,opt,name=muted,proto3" json:"muted,omitempty"`
+	Dimmed  bool     `protobuf:"varint,6,opt,name=dimmed,proto3" json:"dimmed,omitempty"`
+}
+
+func (x *DeviceState) Reset() {
+	*x = DeviceState{}
+	mi := &file_motu_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeviceState) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeviceState) ProtoMessage() {}
+
+func (x *DeviceState) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeviceState.ProtoReflect.Descriptor instead.
+func (*DeviceState) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *DeviceState) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *DeviceState) GetValue() float64 {
+	if x != nil {
+		return x.Value
+	}
+	return 0
+}
+
+func (x *DeviceState) GetLevelDb() float64 {
+	if x != nil && x.LevelDb != nil {
+		return *x.LevelDb
+	}
+	return 0
+}
+
+func (x *DeviceState) GetPercent() float64 {
+	if x != nil {
+		return x.Percent
+	}
+	return 0
+}
+
+func (x *DeviceState) GetMuted() bool {
+	if x != nil {
+		return x.Muted
+	}
+	return false
+}
+
+func (x *DeviceState) GetDimmed() bool {
+	if x != nil {
+		return x.Dimmed
+	}
+	return false
+}
+
+type ListDevicesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListDevicesRequest) Reset() {
+	*x = ListDevicesRequest{}
+	mi := &file_motu_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesRequest) ProtoMessage() {}
+
+func (x *ListDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesRequest.ProtoReflect.Descriptor instead.
+func (*ListDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{1}
+}
+
+type ListDevicesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// In order of name
+	Devices []*DeviceState `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+}
+
+func (x *ListDevicesResponse) Reset() {
+	*x = ListDevicesResponse{}
+	mi := &file_motu_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListDevicesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListDevicesResponse) ProtoMessage() {}
+
+func (x *ListDevicesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListDevicesResponse.ProtoReflect.Descriptor instead.
+func (*ListDevicesResponse) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListDevicesResponse) GetDevices() []*DeviceState {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+type GetDeviceRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Device string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+}
+
+func (x *GetDeviceRequest) Reset() {
+	*x = GetDeviceRequest{}
+	mi := &file_motu_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetDeviceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetDeviceRequest) ProtoMessage() {}
+
+func (x *GetDeviceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetDeviceRequest.ProtoReflect.Descriptor instead.
+func (*GetDeviceRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetDeviceRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+type StepRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Device    string                `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	Direction StepRequest_Direction `protobuf:"varint,2,opt,name=direction,proto3,enum=motu.v1.StepRequest_Direction" json:"direction,omitempty"`
+}
+
+func (x *StepRequest) Reset() {
+	*x = StepRequest{}
+	mi := &file_motu_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *StepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*StepRequest) ProtoMessage() {}
+
+func (x *StepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use StepRequest.ProtoReflect.Descriptor instead.
+func (*StepRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *StepRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *StepRequest) GetDirection() StepRequest_Direction {
+	if x != nil {
+		return x.Direction
+	}
+	return StepRequest_DIRECTION_UNSPECIFIED
+}
+
+type SetLevelRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Device string `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	// Types that are assignable to Level:
+	//	*SetLevelRequest_LevelDb
+	//	*SetLevelRequest_Percent
+	Level isSetLevelRequest_Level `protobuf_oneof:"level"`
+}
+
+func (x *SetLevelRequest) Reset() {
+	*x = SetLevelRequest{}
+	mi := &file_motu_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetLevelRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetLevelRequest) ProtoMessage() {}
+
+func (x *SetLevelRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetLevelRequest.ProtoReflect.Descriptor instead.
+func (*SetLevelRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *SetLevelRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (m *SetLevelRequest) GetLevel() isSetLevelRequest_Level {
+	if m != nil {
+		return m.Level
+	}
+	return nil
+}
+
+func (x *SetLevelRequest) GetLevelDb() float64 {
+	if x, ok := x.GetLevel().(*SetLevelRequest_LevelDb); ok {
+		return x.LevelDb
+	}
+	return 0
+}
+
+func (x *SetLevelRequest) GetPercent() float64 {
+	if x, ok := x.GetLevel().(*SetLevelRequest_Percent); ok {
+		return x.Percent
+	}
+	return 0
+}
+
+type isSetLevelRequest_Level interface {
+	isSetLevelRequest_Level()
+}
+
+type SetLevelRequest_LevelDb struct {
+	LevelDb float64 `protobuf:"fixed64,2,opt,name=level_db,json=levelDb,proto3,oneof"`
+}
+
+type SetLevelRequest_Percent struct {
+	Percent float64 `protobuf:"fixed64,3,opt,name=percent,proto3,oneof"`
+}
+
+func (*SetLevelRequest_LevelDb) isSetLevelRequest_Level() {}
+
+func (*SetLevelRequest_Percent) isSetLevelRequest_Level() {}
+
+type SetMuteRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Device string               `protobuf:"bytes,1,opt,name=device,proto3" json:"device,omitempty"`
+	State  SetMuteRequest_State `protobuf:"varint,2,opt,name=state,proto3,enum=motu.v1.SetMuteRequest_State" json:"state,omitempty"`
+}
+
+func (x *SetMuteRequest) Reset() {
+	*x = SetMuteRequest{}
+	mi := &file_motu_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *SetMuteRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SetMuteRequest) ProtoMessage() {}
+
+func (x *SetMuteRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SetMuteRequest.ProtoReflect.Descriptor instead.
+func (*SetMuteRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *SetMuteRequest) GetDevice() string {
+	if x != nil {
+		return x.Device
+	}
+	return ""
+}
+
+func (x *SetMuteRequest) GetState() SetMuteRequest_State {
+	if x != nil {
+		return x.State
+	}
+	return SetMuteRequest_STATE_UNSPECIFIED
+}
+
+type ListScenesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ListScenesRequest) Reset() {
+	*x = ListScenesRequest{}
+	mi := &file_motu_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScenesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScenesRequest) ProtoMessage() {}
+
+func (x *ListScenesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScenesRequest.ProtoReflect.Descriptor instead.
+func (*ListScenesRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{7}
+}
+
+type ListScenesResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Scenes []string `protobuf:"bytes,1,rep,name=scenes,proto3" json:"scenes,omitempty"`
+}
+
+func (x *ListScenesResponse) Reset() {
+	*x = ListScenesResponse{}
+	mi := &file_motu_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListScenesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListScenesResponse) ProtoMessage() {}
+
+func (x *ListScenesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListScenesResponse.ProtoReflect.Descriptor instead.
+func (*ListScenesResponse) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *ListScenesResponse) GetScenes() []string {
+	if x != nil {
+		return x.Scenes
+	}
+	return nil
+}
+
+type RecallSceneRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Scene string `protobuf:"bytes,1,opt,name=scene,proto3" json:"scene,omitempty"`
+	// Ramp levels over this long instead of jumping to them
+	Fade *durationpb.Duration `protobuf:"bytes,2,opt,name=fade,proto3" json:"fade,omitempty"`
+}
+
+func (x *RecallSceneRequest) Reset() {
+	*x = RecallSceneRequest{}
+	mi := &file_motu_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallSceneRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallSceneRequest) ProtoMessage() {}
+
+func (x *RecallSceneRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallSceneRequest.ProtoReflect.Descriptor instead.
+func (*RecallSceneRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RecallSceneRequest) GetScene() string {
+	if x != nil {
+		return x.Scene
+	}
+	return ""
+}
+
+func (x *RecallSceneRequest) GetFade() *durationpb.Duration {
+	if x != nil {
+		return x.Fade
+	}
+	return nil
+}
+
+type RecallSceneResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *RecallSceneResponse) Reset() {
+	*x = RecallSceneResponse{}
+	mi := &file_motu_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RecallSceneResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RecallSceneResponse) ProtoMessage() {}
+
+func (x *RecallSceneResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RecallSceneResponse.ProtoReflect.Descriptor instead.
+func (*RecallSceneResponse) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{10}
+}
+
+type WatchDevicesRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Only watch these devices. Empty means every device.
+	Devices []string `protobuf:"bytes,1,rep,name=devices,proto3" json:"devices,omitempty"`
+}
+
+func (x *WatchDevicesRequest) Reset() {
+	*x = WatchDevicesRequest{}
+	mi := &file_motu_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchDevicesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchDevicesRequest) ProtoMessage() {}
+
+func (x *WatchDevicesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_motu_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchDevicesRequest.ProtoReflect.Descriptor instead.
+func (*WatchDevicesRequest) Descriptor() ([]byte, []int) {
+	return file_motu_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *WatchDevicesRequest) GetDevices() []string {
+	if x != nil {
+		return x.Devices
+	}
+	return nil
+}
+
+var File_motu_proto protoreflect.FileDescriptor
+
+var file_motu_proto_rawDesc = []byte{
+	0x0a, 0x0a, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x07, 0x6d, 0x6f,
+	0x74, 0x75, 0x2e, 0x76, 0x31, 0x1a, 0x1e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x64, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x2e,
+	0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xb0, 0x01, 0x0a, 0x0b, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x14, 0x0a,
+	0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x01, 0x52, 0x05, 0x76, 0x61,
+	0x6c, 0x75, 0x65, 0x12, 0x1e, 0x0a, 0x08, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x5f, 0x64, 0x62, 0x18,
+	0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00, 0x52, 0x07, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x44, 0x62,
+	0x88, 0x01, 0x01, 0x12, 0x18, 0x0a, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x04,
+	0x20, 0x01, 0x28, 0x01, 0x52, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x12, 0x14, 0x0a,
+	0x05, 0x6d, 0x75, 0x74, 0x65, 0x64, 0x18, 0x05, 0x20, 0x01, 0x28, 0x08, 0x52, 0x05, 0x6d, 0x75,
+	0x74, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x08, 0x52, 0x06, 0x64, 0x69, 0x6d, 0x6d, 0x65, 0x64, 0x42, 0x0b, 0x0a, 0x09, 0x5f,
+	0x6c, 0x65, 0x76, 0x65, 0x6c, 0x5f, 0x64, 0x62, 0x22, 0x14, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74,
+	0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x45,
+	0x0a, 0x13, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x73,
+	0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x2e, 0x0a, 0x07, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73,
+	0x18, 0x01, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31,
+	0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x07, 0x64, 0x65,
+	0x76, 0x69, 0x63, 0x65, 0x73, 0x22, 0x2a, 0x0a, 0x10, 0x47, 0x65, 0x74, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63,
+	0x65, 0x22, 0xb1, 0x01, 0x0a, 0x0b, 0x53, 0x74, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x3c, 0x0a, 0x09, 0x64, 0x69, 0x72,
+	0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1e, 0x2e, 0x6d,
+	0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x2e, 0x44, 0x69, 0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x09, 0x64, 0x69,
+	0x72, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x22, 0x4c, 0x0a, 0x09, 0x44, 0x69, 0x72, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x19, 0x0a, 0x15, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f,
+	0x4e, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44, 0x10, 0x00, 0x12,
+	0x10, 0x0a, 0x0c, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x55, 0x50, 0x10,
+	0x01, 0x12, 0x12, 0x0a, 0x0e, 0x44, 0x49, 0x52, 0x45, 0x43, 0x54, 0x49, 0x4f, 0x4e, 0x5f, 0x44,
+	0x4f, 0x57, 0x4e, 0x10, 0x02, 0x22, 0x6b, 0x0a, 0x0f, 0x53, 0x65, 0x74, 0x4c, 0x65, 0x76, 0x65,
+	0x6c, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65,
+	0x12, 0x1b, 0x0a, 0x08, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x5f, 0x64, 0x62, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x01, 0x48, 0x00, 0x52, 0x07, 0x6c, 0x65, 0x76, 0x65, 0x6c, 0x44, 0x62, 0x12, 0x1a, 0x0a,
+	0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x01, 0x48, 0x00,
+	0x52, 0x07, 0x70, 0x65, 0x72, 0x63, 0x65, 0x6e, 0x74, 0x42, 0x07, 0x0a, 0x05, 0x6c, 0x65, 0x76,
+	0x65, 0x6c, 0x22, 0xac, 0x01, 0x0a, 0x0e, 0x53, 0x65, 0x74, 0x4d, 0x75, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x16, 0x0a, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x33, 0x0a,
+	0x05, 0x73, 0x74, 0x61, 0x74, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0e, 0x32, 0x1d, 0x2e, 0x6d,
+	0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65, 0x74, 0x4d, 0x75, 0x74, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x2e, 0x53, 0x74, 0x61, 0x74, 0x65, 0x52, 0x05, 0x73, 0x74, 0x61,
+	0x74, 0x65, 0x22, 0x4d, 0x0a, 0x05, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x15, 0x0a, 0x11, 0x53,
+	0x54, 0x41, 0x54, 0x45, 0x5f, 0x55, 0x4e, 0x53, 0x50, 0x45, 0x43, 0x49, 0x46, 0x49, 0x45, 0x44,
+	0x10, 0x00, 0x12, 0x0c, 0x0a, 0x08, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x4f, 0x4e, 0x10, 0x01,
+	0x12, 0x0d, 0x0a, 0x09, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x4f, 0x46, 0x46, 0x10, 0x02, 0x12,
+	0x10, 0x0a, 0x0c, 0x53, 0x54, 0x41, 0x54, 0x45, 0x5f, 0x54, 0x4f, 0x47, 0x47, 0x4c, 0x45, 0x10,
+	0x03, 0x22, 0x13, 0x0a, 0x11, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x73, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x22, 0x2c, 0x0a, 0x12, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63,
+	0x65, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x16, 0x0a, 0x06,
+	0x73, 0x63, 0x65, 0x6e, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x06, 0x73, 0x63,
+	0x65, 0x6e, 0x65, 0x73, 0x22, 0x59, 0x0a, 0x12, 0x52, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x53, 0x63,
+	0x65, 0x6e, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x63,
+	0x65, 0x6e, 0x65, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x73, 0x63, 0x65, 0x6e, 0x65,
+	0x12, 0x2d, 0x0a, 0x04, 0x66, 0x61, 0x64, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x19,
+	0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66,
+	0x2e, 0x44, 0x75, 0x72, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x52, 0x04, 0x66, 0x61, 0x64, 0x65, 0x22,
+	0x15, 0x0a, 0x13, 0x52, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x52, 0x65,
+	0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22, 0x2f, 0x0a, 0x13, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44,
+	0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x18, 0x0a,
+	0x07, 0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x07,
+	0x64, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x32, 0x8f, 0x04, 0x0a, 0x04, 0x4d, 0x6f, 0x74, 0x75,
+	0x12, 0x48, 0x0a, 0x0b, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x12,
+	0x1b, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65,
+	0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6d,
+	0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x44, 0x65, 0x76, 0x69, 0x63,
+	0x65, 0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x3c, 0x0a, 0x09, 0x47, 0x65,
+	0x74, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x12, 0x19, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x32, 0x0a, 0x04, 0x53, 0x74, 0x65, 0x70,
+	0x12, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x74, 0x65, 0x70, 0x52,
+	0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31,
+	0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x3a, 0x0a, 0x08,
+	0x53, 0x65, 0x74, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x12, 0x18, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e,
+	0x76, 0x31, 0x2e, 0x53, 0x65, 0x74, 0x4c, 0x65, 0x76, 0x65, 0x6c, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x76,
+	0x69, 0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x12, 0x38, 0x0a, 0x07, 0x53, 0x65, 0x74, 0x4d,
+	0x75, 0x74, 0x65, 0x12, 0x17, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x53, 0x65,
+	0x74, 0x4d, 0x75, 0x74, 0x65, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x14, 0x2e, 0x6d,
+	0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x53, 0x74, 0x61,
+	0x74, 0x65, 0x12, 0x45, 0x0a, 0x0a, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x73,
+	0x12, 0x1a, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53,
+	0x63, 0x65, 0x6e, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1b, 0x2e, 0x6d,
+	0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x4c, 0x69, 0x73, 0x74, 0x53, 0x63, 0x65, 0x6e, 0x65,
+	0x73, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x48, 0x0a, 0x0b, 0x52, 0x65, 0x63,
+	0x61, 0x6c, 0x6c, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x12, 0x1b, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e,
+	0x76, 0x31, 0x2e, 0x52, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x52, 0x65,
+	0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e,
+	0x52, 0x65, 0x63, 0x61, 0x6c, 0x6c, 0x53, 0x63, 0x65, 0x6e, 0x65, 0x52, 0x65, 0x73, 0x70, 0x6f,
+	0x6e, 0x73, 0x65, 0x12, 0x44, 0x0a, 0x0c, 0x57, 0x61, 0x74, 0x63, 0x68, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x73, 0x12, 0x1c, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x57, 0x61,
+	0x74, 0x63, 0x68, 0x44, 0x65, 0x76, 0x69, 0x63, 0x65, 0x73, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73,
+	0x74, 0x1a, 0x14, 0x2e, 0x6d, 0x6f, 0x74, 0x75, 0x2e, 0x76, 0x31, 0x2e, 0x44, 0x65, 0x76, 0x69,
+	0x63, 0x65, 0x53, 0x74, 0x61, 0x74, 0x65, 0x30, 0x01, 0x42, 0x29, 0x5a, 0x27, 0x67, 0x69, 0x74,
+	0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x6a, 0x61, 0x6b, 0x65, 0x77, 0x72, 0x69, 0x67,
+	0x68, 0x74, 0x2f, 0x6d, 0x6f, 0x74, 0x75, 0x2d, 0x74, 0x6f, 0x6f, 0x6c, 0x73, 0x2f, 0x6d, 0x6f,
+	0x74, 0x75, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_motu_proto_rawDescOnce sync.Once
+	file_motu_proto_rawDescData = file_motu_proto_rawDesc
+)
+
+func file_motu_proto_rawDescGZIP() []byte {
+	file_motu_proto_rawDescOnce.Do(func() {
+		file_motu_proto_rawDescData = protoimpl.X.CompressGZIP(file_motu_proto_rawDescData)
+	})
+	return file_motu_proto_rawDescData
+}
+
+var file_motu_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
+var file_motu_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_motu_proto_goTypes = []any{
+	(StepRequest_Direction)(0),  // 0: motu.v1.StepRequest.Direction
+	(SetMuteRequest_State)(0),   // 1: motu.v1.SetMuteRequest.State
+	(*DeviceState)(nil),         // 2: motu.v1.DeviceState
+	(*ListDevicesRequest)(nil),  // 3: motu.v1.ListDevicesRequest
+	(*ListDevicesResponse)(nil), // 4: motu.v1.ListDevicesResponse
+	(*GetDeviceRequest)(nil),    // 5: motu.v1.GetDeviceRequest
+	(*StepRequest)(nil),         // 6: motu.v1.StepRequest
+	(*SetLevelRequest)(nil),     // 7: motu.v1.SetLevelRequest
+	(*SetMuteRequest)(nil),      // 8: motu.v1.SetMuteRequest
+	(*ListScenesRequest)(nil),   // 9: motu.v1.ListScenesRequest
+	(*ListScenesResponse)(nil),  // 10: motu.v1.ListScenesResponse
+	(*RecallSceneRequest)(nil),  // 11: motu.v1.RecallSceneRequest
+	(*RecallSceneResponse)(nil), // 12: motu.v1.RecallSceneResponse
+	(*WatchDevicesRequest)(nil), // 13: motu.v1.WatchDevicesRequest
+	(*durationpb.Duration)(nil), // 14: google.protobuf.Duration
+}
+var file_motu_proto_depIdxs = []int32{
+	2,  // 0: motu.v1.ListDevicesResponse.devices:type_name -> motu.v1.DeviceState
+	0,  // 1: motu.v1.StepRequest.direction:type_name -> motu.v1.StepRequest.Direction
+	1,  // 2: motu.v1.SetMuteRequest.state:type_name -> motu.v1.SetMuteRequest.State
+	14, // 3: motu.v1.RecallSceneRequest.fade:type_name -> google.protobuf.Duration
+	3,  // 4: motu.v1.Motu.ListDevices:input_type -> motu.v1.ListDevicesRequest
+	5,  // 5: motu.v1.Motu.GetDevice:input_type -> motu.v1.GetDeviceRequest
+	6,  // 6: motu.v1.Motu.Step:input_type -> motu.v1.StepRequest
+	7,  // 7: motu.v1.Motu.SetLevel:input_type -> motu.v1.SetLevelRequest
+	8,  // 8: motu.v1.Motu.SetMute:input_type -> motu.v1.SetMuteRequest
+	9,  // 9: motu.v1.Motu.ListScenes:input_type -> motu.v1.ListScenesRequest
+	11, // 10: motu.v1.Motu.RecallScene:input_type -> motu.v1.RecallSceneRequest
+	13, // 11: motu.v1.Motu.WatchDevices:input_type -> motu.v1.WatchDevicesRequest
+	4,  // 12: motu.v1.Motu.ListDevices:output_type -> motu.v1.ListDevicesResponse
+	2,  // 13: motu.v1.Motu.GetDevice:output_type -> motu.v1.DeviceState
+	2,  // 14: motu.v1.Motu.Step:output_type -> motu.v1.DeviceState
+	2,  // 15: motu.v1.Motu.SetLevel:output_type -> motu.v1.DeviceState
+	2,  // 16: motu.v1.Motu.SetMute:output_type -> motu.v1.DeviceState
+	10, // 17: motu.v1.Motu.ListScenes:output_type -> motu.v1.ListScenesResponse
+	12, // 18: motu.v1.Motu.RecallScene:output_type -> motu.v1.RecallSceneResponse
+	2,  // 19: motu.v1.Motu.WatchDevices:output_type -> motu.v1.DeviceState
+	12, // [12:20] is the sub-list for method output_type
+	4,  // [4:12] is the sub-list for method input_type
+	4,  // [4:4] is the sub-list for extension type_name
+	4,  // [4:4] is the sub-list for extension extendee
+	0,  // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_motu_proto_init() }
+func file_motu_proto_init() {
+	if File_motu_proto != nil {
+		return
+	}
+	file_motu_proto_msgTypes[0].OneofWrappers = []any{}
+	file_motu_proto_msgTypes[5].OneofWrappers = []any{
+		(*SetLevelRequest_LevelDb)(nil),
+		(*SetLevelRequest_Percent)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_motu_proto_rawDesc,
+			NumEnums:      2,
+			NumMessages:   12,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_motu_proto_goTypes,
+		DependencyIndexes: file_motu_proto_depIdxs,
+		EnumInfos:         file_motu_proto_enumTypes,
+		MessageInfos:      file_motu_proto_msgTypes,
+	}.Build()
+	File_motu_proto = out.File
+	file_motu_proto_rawDesc = nil
+	file_motu_proto_goTypes = nil
+	file_motu_proto_depIdxs = nil
+}
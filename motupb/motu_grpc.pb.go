@@ -0,0 +1,412 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.2
+// - protoc             (unknown)
+// source: motu.proto
+
+// The daemon's gRPC API, served by "motu serve --grpc". It covers the
+// same ground as the HTTP API, plus a stream of state changes.
+
+package motupb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Motu_ListDevices_FullMethodName  = "/motu.v1.Motu/ListDevices"
+	Motu_GetDevice_FullMethodName    = "/motu.v1.Motu/GetDevice"
+	Motu_Step_FullMethodName         = "/motu.v1.Motu/Step"
+	Motu_SetLevel_FullMethodName     = "/motu.v1.Motu/SetLevel"
+	Motu_SetMute_FullMethodName      = "/motu.v1.Motu/SetMute"
+	Motu_ListScenes_FullMethodName   = "/motu.v1.Motu/ListScenes"
+	Motu_RecallScene_FullMethodName  = "/motu.v1.Motu/RecallScene"
+	Motu_WatchDevices_FullMethodName = "/motu.v1.Motu/WatchDevices"
+)
+
+// MotuClient is the client API for Motu service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type MotuClient interface {
+	// Returns the state of every configured device
+	ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error)
+	// Returns the state of one device
+	GetDevice(ctx context.Context, in *GetDeviceRequest, opts ...grpc.CallOption) (*DeviceState, error)
+	// Moves a device's level up or down by one step
+	Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*DeviceState, error)
+	// Sets a device's level in dB or as a percentage
+	SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*DeviceState, error)
+	// Mutes, unmutes or toggles a device
+	SetMute(ctx context.Context, in *SetMuteRequest, opts ...grpc.CallOption) (*DeviceState, error)
+	// Returns the names of the saved scenes
+	ListScenes(ctx context.Context, in *ListScenesRequest, opts ...grpc.CallOption) (*ListScenesResponse, error)
+	// Recalls a saved scene
+	RecallScene(ctx context.Context, in *RecallSceneRequest, opts ...grpc.CallOption) (*RecallSceneResponse, error)
+	// Sends the state of every device, then the state of
+	// each device again whenever it changes
+	WatchDevices(ctx context.Context, in *WatchDevicesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceState], error)
+}
+
+type motuClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMotuClient(cc grpc.ClientConnInterface) MotuClient {
+	return &motuClient{cc}
+}
+
+func (c *motuClient) ListDevices(ctx context.Context, in *ListDevicesRequest, opts ...grpc.CallOption) (*ListDevicesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListDevicesResponse)
+	err := c.cc.Invoke(ctx, Motu_ListDevices_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) GetDevice(ctx context.Context, in *GetDeviceRequest, opts ...grpc.CallOption) (*DeviceState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceState)
+	err := c.cc.Invoke(ctx, Motu_GetDevice_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) Step(ctx context.Context, in *StepRequest, opts ...grpc.CallOption) (*DeviceState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceState)
+	err := c.cc.Invoke(ctx, Motu_Step_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) SetLevel(ctx context.Context, in *SetLevelRequest, opts ...grpc.CallOption) (*DeviceState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceState)
+	err := c.cc.Invoke(ctx, Motu_SetLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) SetMute(ctx context.Context, in *SetMuteRequest, opts ...grpc.CallOption) (*DeviceState, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeviceState)
+	err := c.cc.Invoke(ctx, Motu_SetMute_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) ListScenes(ctx context.Context, in *ListScenesRequest, opts ...grpc.CallOption) (*ListScenesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListScenesResponse)
+	err := c.cc.Invoke(ctx, Motu_ListScenes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) RecallScene(ctx context.Context, in *RecallSceneRequest, opts ...grpc.CallOption) (*RecallSceneResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RecallSceneResponse)
+	err := c.cc.Invoke(ctx, Motu_RecallScene_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *motuClient) WatchDevices(ctx context.Context, in *WatchDevicesRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[DeviceState], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Motu_ServiceDesc.Streams[0], Motu_WatchDevices_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[WatchDevicesRequest, DeviceState]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Motu_WatchDevicesClient = grpc.ServerStreamingClient[DeviceState]
+
+// MotuServer is the server API for Motu service.
+// All implementations must embed UnimplementedMotuServer
+// for forward compatibility.
+type MotuServer interface {
+	// Returns the state of every configured device
+	ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error)
+	// Returns the state of one device
+	GetDevice(context.Context, *GetDeviceRequest) (*DeviceState, error)
+	// Moves a device's level up or down by one step
+	Step(context.Context, *StepRequest) (*DeviceState, error)
+	// Sets a device's level in dB or as a percentage
+	SetLevel(context.Context, *SetLevelRequest) (*DeviceState, error)
+	// Mutes, unmutes or toggles a device
+	SetMute(context.Context, *SetMuteRequest) (*DeviceState, error)
+	// Returns the names of the saved scenes
+	ListScenes(context.Context, *ListScenesRequest) (*ListScenesResponse, error)
+	// Recalls a saved scene
+	RecallScene(context.Context, *RecallSceneRequest) (*RecallSceneResponse, error)
+	// Sends the state of every device, then the state of
+	// each device again whenever it changes
+	WatchDevices(*WatchDevicesRequest, grpc.ServerStreamingServer[DeviceState]) error
+	mustEmbedUnimplementedMotuServer()
+}
+
+// UnimplementedMotuServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMotuServer struct{}
+
+func (UnimplementedMotuServer) ListDevices(context.Context, *ListDevicesRequest) (*ListDevicesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListDevices not implemented")
+}
+func (UnimplementedMotuServer) GetDevice(context.Context, *GetDeviceRequest) (*DeviceState, error) {
+	return nil, status.Error(codes.Unimplemented, "method GetDevice not implemented")
+}
+func (UnimplementedMotuServer) Step(context.Context, *StepRequest) (*DeviceState, error) {
+	return nil, status.Error(codes.Unimplemented, "method Step not implemented")
+}
+func (UnimplementedMotuServer) SetLevel(context.Context, *SetLevelRequest) (*DeviceState, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetLevel not implemented")
+}
+func (UnimplementedMotuServer) SetMute(context.Context, *SetMuteRequest) (*DeviceState, error) {
+	return nil, status.Error(codes.Unimplemented, "method SetMute not implemented")
+}
+func (UnimplementedMotuServer) ListScenes(context.Context, *ListScenesRequest) (*ListScenesResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method ListScenes not implemented")
+}
+func (UnimplementedMotuServer) RecallScene(context.Context, *RecallSceneRequest) (*RecallSceneResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method RecallScene not implemented")
+}
+func (UnimplementedMotuServer) WatchDevices(*WatchDevicesRequest, grpc.ServerStreamingServer[DeviceState]) error {
+	return status.Error(codes.Unimplemented, "method WatchDevices not implemented")
+}
+func (UnimplementedMotuServer) mustEmbedUnimplementedMotuServer() {}
+func (UnimplementedMotuServer) testEmbeddedByValue()              {}
+
+// UnsafeMotuServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MotuServer will
+// result in compilation errors.
+type UnsafeMotuServer interface {
+	mustEmbedUnimplementedMotuServer()
+}
+
+func RegisterMotuServer(s grpc.ServiceRegistrar, srv MotuServer) {
+	// If the following call panics, it indicates UnimplementedMotuServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Motu_ServiceDesc, srv)
+}
+
+func _Motu_ListDevices_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDevicesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).ListDevices(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_ListDevices_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).ListDevices(ctx, req.(*ListDevicesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_GetDevice_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDeviceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).GetDevice(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_GetDevice_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).GetDevice(ctx, req.(*GetDeviceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_Step_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(StepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).Step(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_Step_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).Step(ctx, req.(*StepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_SetLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).SetLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_SetLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).SetLevel(ctx, req.(*SetLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_SetMute_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMuteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).SetMute(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_SetMute_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).SetMute(ctx, req.(*SetMuteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_ListScenes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListScenesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).ListScenes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_ListScenes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).ListScenes(ctx, req.(*ListScenesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_RecallScene_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RecallSceneRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MotuServer).RecallScene(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Motu_RecallScene_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MotuServer).RecallScene(ctx, req.(*RecallSceneRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Motu_WatchDevices_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchDevicesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(MotuServer).WatchDevices(m, &grpc.GenericServerStream[WatchDevicesRequest, DeviceState]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Motu_WatchDevicesServer = grpc.ServerStreamingServer[DeviceState]
+
+// Motu_ServiceDesc is the grpc.ServiceDesc for Motu service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Motu_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "motu.v1.Motu",
+	HandlerType: (*MotuServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListDevices",
+			Handler:    _Motu_ListDevices_Handler,
+		},
+		{
+			MethodName: "GetDevice",
+			Handler:    _Motu_GetDevice_Handler,
+		},
+		{
+			MethodName: "Step",
+			Handler:    _Motu_Step_Handler,
+		},
+		{
+			MethodName: "SetLevel",
+			Handler:    _Motu_SetLevel_Handler,
+		},
+		{
+			MethodName: "SetMute",
+			Handler:    _Motu_SetMute_Handler,
+		},
+		{
+			MethodName: "ListScenes",
+			Handler:    _Motu_ListScenes_Handler,
+		},
+		{
+			MethodName: "RecallScene",
+			Handler:    _Motu_RecallScene_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "WatchDevices",
+			Handler:       _Motu_WatchDevices_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "motu.proto",
+}
@@ -0,0 +1,280 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const (
+	defaultMQTTTopicPrefix     = "motu"
+	defaultMQTTDiscoveryPrefix = "homeassistant"
+
+	mqttOn  = "ON"
+	mqttOff = "OFF"
+)
+
+// MQTTConfig configures the MQTT bridge
+type MQTTConfig struct {
+	// URL of the broker, e.g. tcp://homeassistant.local:1883
+	Broker   string `yaml:"broker"`
+	Username string `yaml:"username"`
+	Password string `yaml:"password"`
+
+	// All state and command topics sit under this prefix
+	TopicPrefix string `yaml:"topic_prefix"`
+
+	// Where Home Assistant looks for discovery
+	// payloads. Set to "-" to disable discovery.
+	DiscoveryPrefix string `yaml:"discovery_prefix"`
+}
+
+// mqttBridge publishes the state of each device and applies commands
+// received on these topics:
+//
+//	<prefix>/<device>/level        state, in dB
+//	<prefix>/<device>/level/set    command, in dB
+//	<prefix>/<device>/percent      state, 0 to 100
+//	<prefix>/<device>/percent/set  command, 0 to 100
+//	<prefix>/<device>/mute         state, ON or OFF
+//	<prefix>/<device>/mute/set     command, ON or OFF
+//	<prefix>/status                online or offline
+type mqttBridge struct {
+	client  *motu.Client
+	devices map[string]*motu.Device
+	cfg     *MQTTConfig
+	mqtt    mqtt.Client
+}
+
+func mqttCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.MQTT == nil || cfg.MQTT.Broker == "" {
+		return fmt.Errorf("no MQTT broker is configured")
+	}
+
+	mqttCfg := cfg.MQTT
+	if mqttCfg.TopicPrefix == "" {
+		mqttCfg.TopicPrefix = defaultMQTTTopicPrefix
+	}
+	if mqttCfg.DiscoveryPrefix == "" {
+		mqttCfg.DiscoveryPrefix = defaultMQTTDiscoveryPrefix
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	mirror := m.Sync(ctx)
+
+	b := &mqttBridge{
+		client:  m,
+		devices: cfg.Devices,
+		cfg:     mqttCfg,
+	}
+
+	opts := mqtt.NewClientOptions().
+		AddBroker(mqttCfg.Broker).
+		SetClientID(mqttCfg.TopicPrefix).
+		SetUsername(mqttCfg.Username).
+		SetPassword(mqttCfg.Password).
+		SetAutoReconnect(true).
+		SetWill(b.topic("status"), "offline", 1, true).
+		SetOnConnectHandler(b.onConnect)
+
+	b.mqtt = mqtt.NewClient(opts)
+	if token := b.mqtt.Connect(); token.Wait() && token.Error() != nil {
+		return fmt.Errorf("failed to connect to broker: %w", token.Error())
+	}
+	defer b.mqtt.Disconnect(250)
+
+	slog.Info("Connected to MQTT broker", "broker", mqttCfg.Broker)
+
+	changes, unsubscribe := mirror.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			b.publish(b.topic("status"), "offline")
+			return nil
+		case batch := <-changes:
+			for name, d := range b.devices {
+				_, levelChanged := batch[motu.Key(d.Property)]
+				_, muteChanged := batch[motu.Key(d.MuteProperty)]
+				if levelChanged || (muteChanged && d.MuteProperty != "") {
+					b.publishState(name, d)
+				}
+			}
+		}
+	}
+}
+
+// onConnect runs on every (re)connection to the broker
+func (b *mqttBridge) onConnect(c mqtt.Client) {
+	for name, d := range b.devices {
+		c.Subscribe(b.topic(name, "level", "set"), 1, func(_ mqtt.Client, msg mqtt.Message) {
+			db, err := strconv.ParseFloat(strings.TrimSpace(string(msg.Payload())), 64)
+			if err != nil {
+				slog.Warn("Invalid level", "device", name, "payload", string(msg.Payload()))
+				return
+			}
+
+			if _, err := b.client.SetLevel(d, db); err != nil {
+				slog.Error("Failed to set level", "device", name, "err", err)
+			}
+		})
+
+		c.Subscribe(b.topic(name, "percent", "set"), 1, func(_ mqtt.Client, msg mqtt.Message) {
+			percent, err := strconv.ParseFloat(strings.TrimSpace(string(msg.Payload())), 64)
+			if err != nil {
+				slog.Warn("Invalid percent", "device", name, "payload", string(msg.Payload()))
+				return
+			}
+
+			if _, err := b.client.SetPercent(d, percent); err != nil {
+				slog.Error("Failed to set level", "device", name, "err", err)
+			}
+		})
+
+		if d.MuteProperty != "" {
+			c.Subscribe(b.topic(name, "mute", "set"), 1, func(_ mqtt.Client, msg mqtt.Message) {
+				var err error
+				switch strings.ToUpper(strings.TrimSpace(string(msg.Payload()))) {
+				case mqttOn:
+					err = b.client.SetMute(d, true)
+				case mqttOff:
+					err = b.client.SetMute(d, false)
+				default:
+					slog.Warn("Invalid mute state", "device", name, "payload", string(msg.Payload()))
+					return
+				}
+				if err != nil {
+					slog.Error("Failed to set mute", "device", name, "err", err)
+				}
+			})
+		}
+
+		if b.cfg.DiscoveryPrefix != "-" {
+			b.publishDiscovery(name, d)
+		}
+
+		b.publishState(name, d)
+	}
+
+	b.publish(b.topic("status"), "online")
+}
+
+func (b *mqttBridge) publishState(name string, d *motu.Device) {
+	level, err := b.client.Level(d)
+	if err != nil {
+		slog.Error("Failed to read level", "device", name, "err", err)
+		return
+	}
+
+	b.publish(b.topic(name, "percent"), strconv.FormatFloat(d.DBToPercent(level), 'f', 0, 64))
+
+	// Home Assistant rejects states outside the range
+	// it was given, so zero volume is reported as Min
+	level = math.Max(level, d.Min)
+	b.publish(b.topic(name, "level"), strconv.FormatFloat(level, 'f', 1, 64))
+
+	if d.MuteProperty == "" {
+		return
+	}
+
+	muted, err := b.client.Muted(d)
+	if err != nil {
+		slog.Error("Failed to read mute", "device", name, "err", err)
+		return
+	}
+
+	state := mqttOff
+	if muted {
+		state = mqttOn
+	}
+	b.publish(b.topic(name, "mute"), state)
+}
+
+// publishDiscovery announces a device's entities to Home Assistant:
+// a number for the level and a switch for the mute.
+// See https://www.home-assistant.io/integrations/mqtt/#mqtt-discovery
+func (b *mqttBridge) publishDiscovery(name string, d *motu.Device) {
+	device := map[string]any{
+		"identifiers":  []string{b.cfg.TopicPrefix},
+		"name":         "MOTU",
+		"manufacturer": "MOTU",
+	}
+
+	id := b.cfg.TopicPrefix + "_" + name
+
+	b.publishJSON(strings.Join([]string{b.cfg.DiscoveryPrefix, "number", id, "level", "config"}, "/"), map[string]any{
+		"name":                name + " level",
+		"unique_id":           id + "_level",
+		"state_topic":         b.topic(name, "level"),
+		"command_topic":       b.topic(name, "level", "set"),
+		"availability_topic":  b.topic("status"),
+		"min":                 d.Min,
+		"max":                 d.Max,
+		"step":                1,
+		"unit_of_measurement": "dB",
+		"icon":                "mdi:volume-high",
+		"device":              device,
+	})
+
+	if d.MuteProperty == "" {
+		return
+	}
+
+	b.publishJSON(strings.Join([]string{b.cfg.DiscoveryPrefix, "switch", id, "mute", "config"}, "/"), map[string]any{
+		"name":               name + " mute",
+		"unique_id":          id + "_mute",
+		"state_topic":        b.topic(name, "mute"),
+		"command_topic":      b.topic(name, "mute", "set"),
+		"availability_topic": b.topic("status"),
+		"payload_on":         mqttOn,
+		"payload_off":        mqttOff,
+		"icon":               "mdi:volume-off",
+		"device":             device,
+	})
+}
+
+func (b *mqttBridge) publishJSON(topic string, v any) {
+	payload, err := json.Marshal(v)
+	if err != nil {
+		slog.Error("Failed to marshal message", "topic", topic, "err", err)
+		return
+	}
+
+	b.publish(topic, string(payload))
+}
+
+// publish sends a retained message so that new subscribers
+// (and Home Assistant after a restart) get the latest state
+func (b *mqttBridge) publish(topic, payload string) {
+	token := b.mqtt.Publish(topic, 1, true, payload)
+	if token.Wait() && token.Error() != nil {
+		slog.Error("Failed to publish", "topic", topic, "err", token.Error())
+	}
+}
+
+func (b *mqttBridge) topic(parts ...string) string {
+	return b.cfg.TopicPrefix + "/" + strings.Join(parts, "/")
+}
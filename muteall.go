@@ -0,0 +1,150 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Matches the mute of a mixer channel, relative to the datastore root
+var mixerMuteKey = regexp.MustCompile(`^mix/chan/\d+/matrix/mute$`)
+
+// muteAll mutes every configured device, and every mixer channel if
+// --mix is given, in a single request. The previous mute states are
+// saved so that unmuteAll can put them back. They're read in one
+// request, and if that fails the mute is sent anyway, as it's for
+// emergencies, but there's nothing for unmuteAll to put back.
+func muteAll(args []string) error {
+	flags := flag.NewFlagSet("mute-all", flag.ContinueOnError)
+	mix := flags.Bool("mix", false, "mute every mixer channel as well")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// The mixer's tree has the mixer channels, and usually the
+	// devices' mutes too. Otherwise the whole datastore is read.
+	treePath := "datastore/mix"
+	values := map[string]any{}
+	for _, d := range cfg.Devices {
+		if d.MuteProperty == "" {
+			continue
+		}
+		key := motu.Key(d.MuteProperty)
+		values[key] = 1.0
+		if !strings.HasPrefix(key, "mix/") {
+			treePath = "datastore"
+		}
+	}
+
+	var tree map[string]any
+	if *mix || len(values) > 0 {
+		tree, err = m.GetTree(treePath)
+	}
+	for key := range tree {
+		if *mix && mixerMuteKey.MatchString(key) {
+			values[key] = 1.0
+		}
+	}
+
+	// Without the mixer channels, still mute the devices
+	var mixErr error
+	if err != nil && *mix {
+		mixErr = fmt.Errorf("failed to read mixer channels: %w", err)
+	}
+	if len(values) == 0 {
+		if mixErr != nil {
+			return mixErr
+		}
+		return printResult(map[string]any{"muted": []string{}}, "Nothing to mute")
+	}
+
+	if err != nil {
+		slog.Warn("Failed to read the mute states, so unmute-all won't be able to restore them", "err", err)
+	} else if err := saveMuteStates(tree, values); err != nil {
+		slog.Warn("Failed to save the mute states, so unmute-all won't be able to restore them", "err", err)
+	}
+
+	if err := m.SetValues(values); err != nil {
+		return fmt.Errorf("failed to mute: %w", err)
+	}
+
+	if err := printResult(map[string]any{"muted": sortedKeys(values)}, fmt.Sprintf("Muted %d properties", len(values))); err != nil {
+		return err
+	}
+	return mixErr
+}
+
+// saveMuteStates saves the states that muting the keys of values
+// will change, taken from tree, for unmuteAll. It's saved before
+// muting so that the states aren't lost if the command is interrupted.
+func saveMuteStates(tree, values map[string]any) error {
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	// If mute-all has already been run, the states saved then are the
+	// ones to go back to. Only add properties that weren't saved before.
+	if st.Muted == nil {
+		st.Muted = &Snapshot{Created: time.Now(), Values: map[string]any{}}
+	}
+	for key := range values {
+		if _, ok := st.Muted.Values[key]; ok {
+			continue
+		}
+		if v, ok := tree[key]; ok {
+			st.Muted.Values[key] = v
+		}
+	}
+
+	return st.save()
+}
+
+// unmuteAll restores the mute states saved by muteAll
+func unmuteAll() error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	if st.Muted == nil {
+		return fmt.Errorf("nothing to restore, mute-all has not been run")
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if err := m.SetValues(st.Muted.Values); err != nil {
+		return fmt.Errorf("failed to restore mute states: %w", err)
+	}
+
+	restored := sortedKeys(st.Muted.Values)
+	st.Muted = nil
+	if err := st.save(); err != nil {
+		return err
+	}
+
+	return printResult(map[string]any{"restored": restored}, fmt.Sprintf("Restored %d properties", len(restored)))
+}
@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+
+	"github.com/jakewright/motu-tools/internal/osc"
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const defaultOSCListenAddress = ":9000"
+
+// OSCConfig configures the OSC bridge
+type OSCConfig struct {
+	// Address to listen on, e.g. ":9000"
+	Listen string `yaml:"listen"`
+
+	// Addresses to send feedback to as well as
+	// any client that has sent us a message
+	Feedback []string `yaml:"feedback"`
+}
+
+// oscBridge translates OSC messages into datastore changes. For each
+// device it understands:
+//
+//	/motu/<device>/volume <0..1>
+//	/motu/<device>/level  <dB>
+//	/motu/<device>/mute   [0|1]  (toggles with no argument)
+//	/motu/<device>/inc
+//	/motu/<device>/dec
+//
+// Any other datastore property can be set with /datastore/<path> <value>.
+// Changes to device levels and mutes are sent back as /volume, /level
+// and /mute messages to every known client.
+type oscBridge struct {
+	client  *motu.Client
+	devices map[string]*motu.Device
+	conn    *net.UDPConn
+
+	mu      sync.Mutex
+	clients map[string]*net.UDPAddr
+}
+
+func oscCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	oscCfg := cfg.OSC
+	if oscCfg == nil {
+		oscCfg = &OSCConfig{}
+	}
+	if oscCfg.Listen == "" {
+		oscCfg.Listen = defaultOSCListenAddress
+	}
+
+	flags := flag.NewFlagSet("osc", flag.ContinueOnError)
+	listen := flags.String("listen", oscCfg.Listen, "address to listen on")
+	feedback := flags.String("feedback", "", "comma separated addresses to send feedback to")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	if *feedback != "" {
+		oscCfg.Feedback = strings.Split(*feedback, ",")
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	addr, err := net.ResolveUDPAddr("udp", *listen)
+	if err != nil {
+		return fmt.Errorf("invalid listen address: %w", err)
+	}
+
+	conn, err := net.ListenUDP("udp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen: %w", err)
+	}
+	defer conn.Close()
+
+	b := &oscBridge{
+		client:  m,
+		devices: cfg.Devices,
+		conn:    conn,
+		clients: map[string]*net.UDPAddr{},
+	}
+
+	for _, f := range oscCfg.Feedback {
+		a, err := net.ResolveUDPAddr("udp", f)
+		if err != nil {
+			return fmt.Errorf("invalid feedback address %q: %w", f, err)
+		}
+		b.clients[a.String()] = a
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	go b.feedback(ctx, m.Sync(ctx))
+
+	go func() {
+		<-ctx.Done()
+		_ = conn.Close()
+	}()
+
+	slog.Info("Listening for OSC", "address", conn.LocalAddr().String())
+
+	buf := make([]byte, 65535)
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if ctx.Err() != nil {
+			return nil
+		} else if err != nil {
+			return fmt.Errorf("failed to read: %w", err)
+		}
+
+		b.mu.Lock()
+		b.clients[from.String()] = from
+		b.mu.Unlock()
+
+		messages, err := osc.Parse(buf[:n])
+		if err != nil {
+			slog.Warn("Invalid OSC packet", "from", from.String(), "err", err)
+			continue
+		}
+
+		for _, msg := range messages {
+			if err := b.handle(msg); err != nil {
+				slog.Error("Failed to handle OSC message", "address", msg.Address, "err", err)
+			}
+		}
+	}
+}
+
+func (b *oscBridge) handle(msg *osc.Message) error {
+	if strings.HasPrefix(msg.Address, "/datastore/") {
+		v, ok := msg.Float(0)
+		if !ok {
+			return fmt.Errorf("expected a numeric argument")
+		}
+		return b.client.Set(strings.TrimPrefix(msg.Address, "/"), v)
+	}
+
+	parts := strings.Split(strings.TrimPrefix(msg.Address, "/"), "/")
+	if len(parts) != 3 || parts[0] != "motu" {
+		return fmt.Errorf("unknown address")
+	}
+
+	d, ok := b.devices[parts[1]]
+	if !ok {
+		return fmt.Errorf("unknown device: %s", parts[1])
+	}
+
+	switch parts[2] {
+	case "volume":
+		v, ok := msg.Float(0)
+		if !ok {
+			return fmt.Errorf("expected a numeric argument")
+		}
+		_, err := b.client.SetPercent(d, 100*v)
+		return err
+
+	case "level":
+		v, ok := msg.Float(0)
+		if !ok {
+			return fmt.Errorf("expected a numeric argument")
+		}
+		_, err := b.client.SetLevel(d, v)
+		return err
+
+	case "mute":
+		v, ok := msg.Float(0)
+		if !ok {
+			_, err := b.client.Mute(d)
+			return err
+		}
+		return b.client.SetMute(d, v != 0)
+
+	case "inc", "dec":
+		// Buttons send 1 on press and 0 on release
+		if v, ok := msg.Float(0); ok && v == 0 {
+			return nil
+		}
+		_, err := b.client.IncDec(d, parts[2] == "inc")
+		return err
+
+	default:
+		return fmt.Errorf("unknown command: %s", parts[2])
+	}
+}
+
+// feedback sends device state to every known client whenever it changes
+func (b *oscBridge) feedback(ctx context.Context, mirror *motu.Mirror) {
+	changes, unsubscribe := mirror.Subscribe()
+	defer unsubscribe()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case batch := <-changes:
+			for name, d := range b.devices {
+				if _, ok := batch[motu.Key(d.Property)]; ok {
+					b.sendLevel(name, d)
+				}
+				if _, ok := batch[motu.Key(d.MuteProperty)]; ok && d.MuteProperty != "" {
+					b.sendMute(name, d)
+				}
+			}
+		}
+	}
+}
+
+func (b *oscBridge) sendLevel(name string, d *motu.Device) {
+	level, err := b.client.Level(d)
+	if err != nil {
+		slog.Error("Failed to read level", "device", name, "err", err)
+		return
+	}
+
+	b.send(&osc.Message{
+		Address: "/motu/" + name + "/volume",
+		Args:    []any{float32(d.DBToPercent(level) / 100)},
+	})
+
+	if !math.IsInf(level, 0) {
+		b.send(&osc.Message{
+			Address: "/motu/" + name + "/level",
+			Args:    []any{float32(level)},
+		})
+	}
+}
+
+func (b *oscBridge) sendMute(name string, d *motu.Device) {
+	muted, err := b.client.Muted(d)
+	if err != nil {
+		slog.Error("Failed to read mute", "device", name, "err", err)
+		return
+	}
+
+	var v int32
+	if muted {
+		v = 1
+	}
+
+	b.send(&osc.Message{
+		Address: "/motu/" + name + "/mute",
+		Args:    []any{v},
+	})
+}
+
+func (b *oscBridge) send(msg *osc.Message) {
+	packet, err := msg.Marshal()
+	if err != nil {
+		slog.Error("Failed to encode OSC message", "address", msg.Address, "err", err)
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, addr := range b.clients {
+		if _, err := b.conn.WriteToUDP(packet, addr); err != nil {
+			slog.Error("Failed to send OSC feedback", "to", addr.String(), "err", err)
+		}
+	}
+}
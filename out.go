@@ -0,0 +1,125 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const outUsage = "usage: out list | out <bank>/<channel> trim [inc|dec|set <dB>] [--step 1] [--stereo]"
+
+var outputTrimKey = regexp.MustCompile(`^ext/obank/(\d+)/ch/(\d+)/trim$`)
+
+// outCommand lists output channels and controls their trims
+func outCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef(outUsage)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if args[0] == "list" {
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		return listOutputs(m)
+	}
+
+	flags := flag.NewFlagSet("out", flag.ContinueOnError)
+	step := flags.Float64("step", 1, "dB to change the trim by for inc and dec")
+	stereo := flags.Bool("stereo", false, "use the trim of the stereo pair starting at this channel")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) < 2 || positional[1] != "trim" {
+		return usagef(outUsage)
+	}
+
+	bank, ch, err := parseChannel(positional[0])
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	output := motu.Output{Bank: bank, Channel: ch, Stereo: *stereo}
+	return adjustTrim(m, output, positional[2:], *step)
+}
+
+func listOutputs(m *motu.Client) error {
+	tree, err := m.GetTree("datastore/ext/obank")
+	if err != nil {
+		return fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	var outputs []motu.Output
+	for k := range tree {
+		if match := outputTrimKey.FindStringSubmatch(k); match != nil {
+			bank, _ := strconv.Atoi(match[1])
+			ch, _ := strconv.Atoi(match[2])
+			outputs = append(outputs, motu.Output{Bank: bank, Channel: ch})
+		}
+	}
+
+	sort.Slice(outputs, func(i, j int) bool {
+		if outputs[i].Bank != outputs[j].Bank {
+			return outputs[i].Bank < outputs[j].Bank
+		}
+		return outputs[i].Channel < outputs[j].Channel
+	})
+
+	value := func(o motu.Output, name string) any {
+		return tree[motu.Key(o.Property(name))]
+	}
+
+	var result []*outputJSON
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "OUTPUT\tBANK\tNAME\tTRIM\tSTEREO TRIM\n")
+	for _, o := range outputs {
+		j := &outputJSON{Output: fmt.Sprintf("%d/%d", o.Bank, o.Channel)}
+		j.Bank, _ = tree[fmt.Sprintf("ext/obank/%d/name", o.Bank)].(string)
+		j.Name, _ = value(o, "name").(string)
+
+		trim, stereoTrim := "-", "-"
+		if v, ok := toFloat(value(o, "trim")); ok {
+			trim = formatDB(v)
+			j.TrimDB = &v
+		}
+		if v, ok := toFloat(value(o, "stereoTrim")); ok {
+			stereoTrim = formatDB(v)
+			j.StereoTrimDB = &v
+		}
+		result = append(result, j)
+
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", j.Output, j.Bank, j.Name, trim, stereoTrim)
+	}
+
+	if jsonOutput {
+		return printJSON(result)
+	}
+	return w.Flush()
+}
+
+// outputJSON is the JSON representation of an output channel
+type outputJSON struct {
+	Output       string   `json:"output"`
+	Bank         string   `json:"bank"`
+	Name         string   `json:"name"`
+	TrimDB       *float64 `json:"trim_db"`
+	StereoTrimDB *float64 `json:"stereo_trim_db"`
+}
@@ -0,0 +1,119 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// PhonesConfig describes the headphone output and what can feed it.
+// The output and every source are stereo pairs, given by their first
+// channel as "bank/channel".
+type PhonesConfig struct {
+	Output string `yaml:"output"`
+
+	// Input channels that can be routed to the phones, by name.
+	// The mixer's buses show up in the routing as inputs.
+	Sources map[string]string `yaml:"sources"`
+}
+
+func (c *PhonesConfig) validate() error {
+	if _, _, err := parseChannel(c.Output); err != nil {
+		return fmt.Errorf("invalid output: %w", err)
+	}
+
+	if len(c.Sources) == 0 {
+		return fmt.Errorf("no sources")
+	}
+
+	for name, src := range c.Sources {
+		if _, _, err := parseChannel(src); err != nil {
+			return fmt.Errorf("invalid source %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
+
+func phonesCommand(args []string) error {
+	if len(args) < 1 || len(args) > 2 || args[0] != "source" {
+		return usagef("usage: phones source [<name>]")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Phones == nil {
+		return fmt.Errorf("no phones configured")
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	outBank, outCh, _ := parseChannel(cfg.Phones.Output)
+
+	if len(args) == 1 {
+		name, err := phonesSource(m, cfg.Phones, outBank, outCh)
+		if err != nil {
+			return err
+		}
+		return printResult(map[string]string{"source": name}, name)
+	}
+
+	src, ok := cfg.Phones.Sources[args[1]]
+	if !ok {
+		return unknownf("unknown source: %s", args[1])
+	}
+	srcBank, srcCh, _ := parseChannel(src)
+
+	// Route both channels of the pair
+	for i := 0; i < 2; i++ {
+		if err := m.SetRoute(outBank, outCh+i, &motu.Input{Bank: srcBank, Channel: srcCh + i}); err != nil {
+			return err
+		}
+	}
+
+	return printResult(map[string]string{"source": args[1]}, args[1])
+}
+
+// phonesSource returns the name of the source that's routed
+// to the phones, or a description of the route if it isn't
+// one of the configured sources
+func phonesSource(m *motu.Client, cfg *PhonesConfig, bank, ch int) (string, error) {
+	routes, err := m.Routes()
+	if err != nil {
+		return "", err
+	}
+
+	for _, r := range routes {
+		if r.Bank != bank || r.Channel != ch {
+			continue
+		}
+
+		if r.Source == nil {
+			return "none", nil
+		}
+
+		names := make([]string, 0, len(cfg.Sources))
+		for name := range cfg.Sources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		current := fmt.Sprintf("%d/%d", r.Source.Bank, r.Source.Channel)
+		for _, name := range names {
+			if b, c, _ := parseChannel(cfg.Sources[name]); b == r.Source.Bank && c == r.Source.Channel {
+				return name, nil
+			}
+		}
+
+		return current, nil
+	}
+
+	return "", fmt.Errorf("output %d/%d not found", bank, ch)
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// pingStats is the JSON representation of a ping run. Times are in
+// milliseconds, and are null if no requests were answered.
+type pingStats struct {
+	Address  string   `json:"address"`
+	Sent     int      `json:"sent"`
+	Received int      `json:"received"`
+	Loss     float64  `json:"loss_percent"`
+	Min      *float64 `json:"min_ms"`
+	Avg      *float64 `json:"avg_ms"`
+	Max      *float64 `json:"max_ms"`
+}
+
+// pingCommand times requests to the datastore, to
+// show whether the interface or the network is slow
+func pingCommand(args []string) error {
+	flags := flag.NewFlagSet("ping", flag.ContinueOnError)
+	count := flags.Int("c", 10, "number of requests to make")
+	interval := flags.Duration("i", 200*time.Millisecond, "time between requests")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+	if *count < 1 {
+		return usagef("the count must be at least 1")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	// Interrupting stops early but still prints the summary
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	stats := &pingStats{Address: m.Host()}
+	var total, lowest, highest time.Duration
+
+	if !jsonOutput {
+		fmt.Printf("PING %s\n", m.Host())
+	}
+
+	for seq := 1; seq <= *count; seq++ {
+		if seq > 1 {
+			select {
+			case <-ctx.Done():
+			case <-time.After(*interval):
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		stats.Sent++
+		rtt, err := m.Ping(ctx)
+		if errors.Is(err, context.Canceled) {
+			stats.Sent--
+			break
+		}
+
+		if err != nil {
+			// Lost requests are counted rather than ending the run,
+			// unless the interface is answering with an error
+			if !errors.Is(err, motu.ErrDeviceUnreachable) {
+				return err
+			}
+			if !jsonOutput {
+				fmt.Printf("seq=%d lost\n", seq)
+			}
+			continue
+		}
+
+		stats.Received++
+		total += rtt
+		if stats.Received == 1 || rtt < lowest {
+			lowest = rtt
+		}
+		if rtt > highest {
+			highest = rtt
+		}
+		if !jsonOutput {
+			fmt.Printf("seq=%d time=%.1f ms\n", seq, ms(rtt))
+		}
+	}
+
+	if stats.Sent > 0 {
+		stats.Loss = 100 * float64(stats.Sent-stats.Received) / float64(stats.Sent)
+	}
+	if stats.Received > 0 {
+		lo, avg, hi := ms(lowest), ms(total/time.Duration(stats.Received)), ms(highest)
+		stats.Min, stats.Avg, stats.Max = &lo, &avg, &hi
+	}
+
+	if jsonOutput {
+		if err := printJSON(stats); err != nil {
+			return err
+		}
+	} else {
+		fmt.Printf("\n--- %s ping statistics ---\n", m.Host())
+		fmt.Printf("%d requests, %d answered, %.0f%% loss\n", stats.Sent, stats.Received, stats.Loss)
+		if stats.Received > 0 {
+			fmt.Printf("round trip min/avg/max = %.1f/%.1f/%.1f ms\n", *stats.Min, *stats.Avg, *stats.Max)
+		}
+	}
+
+	if stats.Sent > 0 && stats.Received == 0 {
+		return fmt.Errorf("%w at %s: no requests were answered", motu.ErrDeviceUnreachable, m.Host())
+	}
+	return nil
+}
+
+// ms returns a duration in milliseconds
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// profileState is the built-in profile found for the interface, kept
+// in the state file so that the model is only looked up once
+type profileState struct {
+	// The UID, discover name or address it was looked up for
+	Interface string `json:"interface"`
+
+	// The model the interface reported, or the configured model
+	Model string `json:"model"`
+
+	// Empty if there's no built-in profile for the model
+	Profile string                  `json:"profile,omitempty"`
+	Devices map[string]*motu.Device `json:"devices,omitempty"`
+}
+
+// How long to wait for the interface when looking up its model.
+// A command that doesn't need the interface shouldn't have to
+// wait long if it's off.
+const profileTimeout = time.Second
+
+// profileID identifies the interface that a profile was
+// looked up for, so that it's looked up again if that changes
+func profileID(cfg *Config) string {
+	switch {
+	case cfg.UID != "":
+		return cfg.UID
+	case cfg.Discover != "":
+		return cfg.Discover
+	default:
+		return cfg.Address
+	}
+}
+
+// cachedProfile returns the profile saved in the target's state
+// file, or nil if it hasn't been looked up for this interface
+func cachedProfile(cfg *Config, t string) *profileState {
+	st, err := loadStateFor(t)
+	if err != nil || st.Profile == nil {
+		return nil
+	}
+
+	p := st.Profile
+	if p.Interface != profileID(cfg) || (cfg.Model != "" && cfg.Model != p.Model) {
+		return nil
+	}
+	return p
+}
+
+// detectProfile reads the interface's model, unless it's configured,
+// and finds its devices from the built-in profile. It only fails if
+// the interface couldn't be asked.
+func detectProfile(cfg *Config) (*profileState, error) {
+	p := &profileState{Interface: profileID(cfg), Model: cfg.Model}
+
+	var m *motu.Client
+	var err error
+	if cfg.Discover != "" {
+		m, err = motu.NewFromDiscovery(cfg.Discover)
+	} else {
+		m, err = motu.NewFromAddress(interfaceAddress(cfg))
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	m.HTTPClient.Timeout = profileTimeout
+	m.Retries = 0
+	if password != "" {
+		m.SetPassword(cfg.Username, password)
+	} else if cfg.Password != "" {
+		m.SetPassword(cfg.Username, cfg.Password)
+	}
+
+	if p.Model == "" {
+		info, err := m.Info()
+		if err != nil {
+			return nil, err
+		}
+		p.Model = info.Model
+	}
+
+	profile := motu.FindProfile(p.Model)
+	if profile == nil {
+		return p, nil
+	}
+
+	devices, err := m.ProfileDevices(profile)
+	if err != nil {
+		return nil, err
+	}
+	if len(devices) > 0 {
+		p.Profile = profile.Model
+		p.Devices = devices
+	}
+
+	return p, nil
+}
+
+// rememberProfile saves the profile in the state file
+func rememberProfile(p *profileState) error {
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	st.Profile = p
+	return st.save()
+}
+
+// useProfile looks up the built-in profile for a config without
+// devices, the first time it's used with an interface. It returns the
+// config to use, reloaded with the profile's devices if there is one.
+func useProfile(path string, cfg *Config) (*Config, error) {
+	if cfg.configuredDevices || cfg.profile != nil || record != "" || replay != "" {
+		return cfg, nil
+	}
+
+	p, err := detectProfile(cfg)
+	if err != nil {
+		// The default devices do for now, and
+		// it's tried again on the next run
+		slog.Debug("Failed to look up the interface's model", "err", err)
+		return cfg, nil
+	}
+
+	if err := rememberProfile(p); err != nil {
+		slog.Warn("Failed to remember the interface's profile", "err", err)
+		return cfg, nil
+	}
+
+	return loadConfig(path, target)
+}
+
+// profileCommand prints which built-in profile is in use and its
+// devices. With --refresh, the model is looked up again first.
+func profileCommand(args []string) error {
+	flags := flag.NewFlagSet("profile", flag.ContinueOnError)
+	refresh := flags.Bool("refresh", false, "look up the interface's model again")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.configuredDevices {
+		return printResult(map[string]any{"profile": nil}, "Not using a built-in profile, as the config file has devices")
+	}
+
+	p := cfg.profile
+	if *refresh || p == nil {
+		if p, err = detectProfile(cfg); err != nil {
+			return fmt.Errorf("failed to look up the interface's model: %w", err)
+		}
+		if err := rememberProfile(p); err != nil {
+			return err
+		}
+	}
+
+	if p.Profile == "" {
+		return printResult(map[string]any{"model": p.Model, "profile": nil},
+			fmt.Sprintf("No built-in profile for %s, so the default devices are used", p.Model))
+	}
+
+	if jsonOutput {
+		type deviceJSON struct {
+			Property     string `json:"property"`
+			MuteProperty string `json:"mute_property"`
+		}
+		devices := map[string]*deviceJSON{}
+		for name, d := range p.Devices {
+			devices[name] = &deviceJSON{Property: d.Property, MuteProperty: d.MuteProperty}
+		}
+		return printJSON(map[string]any{"model": p.Model, "profile": p.Profile, "devices": devices})
+	}
+
+	fmt.Printf("Model: %s\n\n", p.Model)
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "DEVICE\tPROPERTY\tMUTE PROPERTY\n")
+	for _, name := range sortedKeys(p.Devices) {
+		d := p.Devices[name]
+		mute := d.MuteProperty
+		if mute == "" {
+			mute = "-"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", name, d.Property, mute)
+	}
+	return w.Flush()
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"strconv"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// rawCommand reads and writes any datastore property by its path
+func rawCommand(args []string) error {
+	if len(args) < 2 {
+		return usagef("usage: raw get <path> | raw set <path> <value>")
+	}
+
+	flags := flag.NewFlagSet("raw", flag.ContinueOnError)
+	asJSON := flags.Bool("json", jsonOutput, "print or parse the value as JSON")
+	asString := flags.Bool("string", false, "set the value as a string even if it looks like a number")
+	force := flags.Bool("force", false, "set the value even if it's outside the property's known range")
+	positional, err := parseFlags(flags, args[1:])
+	if err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch args[0] {
+	case "get":
+		if len(positional) != 1 {
+			return usagef("usage: raw get <path> [--json]")
+		}
+
+		// Raw reads are for seeing what the interface has right
+		// now, so they always go to it rather than the cache
+		m.UseCache(nil)
+
+		v, err := m.Value(motu.Path(positional[0]))
+		if err != nil {
+			return err
+		}
+
+		if *asJSON {
+			b, err := json.Marshal(v)
+			if err != nil {
+				return fmt.Errorf("failed to marshal value: %w", err)
+			}
+			fmt.Println(string(b))
+		} else {
+			fmt.Println(v)
+		}
+
+	case "set":
+		if len(positional) != 2 {
+			return usagef("usage: raw set <path> <value> [--json|--string] [--force]")
+		}
+
+		var v any
+		switch {
+		case *asJSON:
+			if err := json.Unmarshal([]byte(positional[1]), &v); err != nil {
+				return fmt.Errorf("invalid JSON value: %w", err)
+			}
+		case *asString:
+			v = positional[1]
+		default:
+			v = parseRawValue(positional[1])
+		}
+
+		m.SkipValidation = *force
+		if err := m.SetValue(motu.Path(positional[0]), v); err != nil {
+			return err
+		}
+
+	default:
+		return usagef("unrecognised raw command: %s", args[0])
+	}
+
+	return nil
+}
+
+// parseRawValue treats s as an integer or float if it
+// looks like one, and as a string otherwise
+func parseRawValue(s string) any {
+	if i, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return i
+	}
+
+	if f, err := strconv.ParseFloat(s, 64); err == nil {
+		return f
+	}
+
+	return s
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"strings"
+	"time"
+
+	"golang.org/x/term"
+)
+
+const replPrompt = "motu> "
+
+// How long to wait for the mirror before showing
+// the status header, the first time round
+const replSyncTimeout = 2 * time.Second
+
+// replCommand reads commands at a prompt and runs them, sharing the
+// config and connection like a batch. Before each prompt, it prints
+// the state of every device, which is kept up to date by mirroring
+// the datastore.
+func replCommand() error {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		return errors.New("repl needs a terminal; use \"motu batch -\" to run commands from a pipe")
+	}
+
+	batch = &batchState{}
+	defer func() { batch = nil }()
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	mirror := m.Sync(ctx)
+	synced, unsubscribe := mirror.SubscribeSynced()
+	select {
+	case <-synced:
+	case <-time.After(replSyncTimeout):
+	}
+	unsubscribe()
+
+	// Ctrl-C stops the command that's running, if it handles it,
+	// rather than the whole REPL
+	interrupts := make(chan os.Signal, 1)
+	signal.Notify(interrupts, os.Interrupt)
+	defer signal.Stop(interrupts)
+
+	t := term.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{os.Stdin, os.Stdout}, replPrompt)
+	t.AutoCompleteCallback = func(line string, pos int, key rune) (string, int, bool) {
+		if key != '\t' {
+			return "", 0, false
+		}
+		return completeLine(t, line, pos)
+	}
+
+	fmt.Println("Type a command, \"help\" for a list, or Ctrl-D to quit.")
+
+	for {
+		printHeader(ctx, cfg)
+
+		line, err := readLine(fd, t)
+		if err == io.EOF {
+			fmt.Println()
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		line = strings.TrimSpace(line)
+		switch line {
+		case "":
+			continue
+		case "exit", "quit":
+			return nil
+		}
+
+		if err := runReplLine(line); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		}
+	}
+}
+
+// runReplLine runs a command typed at the prompt. Mistakes, including
+// bad flags, are returned to be printed at the prompt, and asking a
+// command for help shows it as it would on the command line.
+func runReplLine(line string) error {
+	args, err := splitArgs(line)
+	if err != nil {
+		return err
+	}
+	if len(args) > 1 && wantsHelp(args[1:]) && findCommand(args[0]) != nil {
+		return helpCommand(args[:1])
+	}
+
+	return runBatchLine(line)
+}
+
+// readLine reads a line at the prompt. The terminal is only in raw mode
+// while reading, so that commands can print to it as they normally do.
+func readLine(fd int, t *term.Terminal) (string, error) {
+	state, err := term.MakeRaw(fd)
+	if err != nil {
+		return "", fmt.Errorf("failed to set up terminal: %w", err)
+	}
+	defer term.Restore(fd, state)
+
+	return t.ReadLine()
+}
+
+// printHeader prints a line with the level and mute state of each device
+func printHeader(ctx context.Context, cfg *Config) {
+	names := make([]string, 0, len(cfg.Devices))
+	for name := range cfg.Devices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, 0, len(names))
+	for _, name := range names {
+		s, err := batch.client.StatusContext(ctx, cfg.Devices[name])
+		if err != nil {
+			parts = append(parts, name+" ?")
+			continue
+		}
+
+		part := name + " " + formatDB(s.LevelDB)
+		if s.Muted {
+			part += " (muted)"
+		}
+		parts = append(parts, part)
+	}
+
+	fmt.Printf("\033[7m %s \033[0m\n", strings.Join(parts, " │ "))
+}
+
+// completeLine completes the word before the cursor. If there's more
+// than one candidate, it's completed as far as they agree and the
+// candidates are listed.
+func completeLine(t *term.Terminal, line string, pos int) (string, int, bool) {
+	words, err := splitArgs(line[:pos])
+	if err != nil {
+		return "", 0, false
+	}
+	if len(words) == 0 || strings.HasSuffix(line[:pos], " ") {
+		words = append(words, "")
+	}
+
+	candidates := complete(words)
+	if len(candidates) == 0 {
+		return "", 0, false
+	}
+
+	current := words[len(words)-1]
+	completion := candidates[0]
+	for _, c := range candidates[1:] {
+		for !strings.HasPrefix(c, completion) {
+			completion = completion[:len(completion)-1]
+		}
+	}
+	if len(candidates) > 1 {
+		fmt.Fprintln(t, strings.Join(candidates, "  "))
+	} else if strings.ContainsAny(completion, " \t") {
+		completion = fmt.Sprintf("%q ", completion)
+	} else {
+		completion += " "
+	}
+
+	// Include the opening quote of a quoted word
+	start := pos - len(current)
+	if start > 0 && (line[start-1] == '"' || line[start-1] == '\'') {
+		start--
+		if !strings.HasPrefix(completion, `"`) {
+			completion = line[start:start+1] + completion
+		}
+	}
+	newLine := line[:start] + completion + line[pos:]
+	return newLine, start + len(completion), true
+}
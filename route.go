@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const routeUsage = "usage: route list | route set <input bank/channel|none> <output bank/channel>"
+
+// routeCommand shows and changes which input feeds each output
+func routeCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef(routeUsage)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "list":
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+		return listRoutes(m)
+
+	case "set":
+		if len(args) != 3 {
+			return usagef(routeUsage)
+		}
+
+		var source *motu.Input
+		if args[1] != "none" {
+			input, err := findInput(cfg, args[1])
+			if err != nil {
+				return err
+			}
+			source = &input
+		}
+
+		bank, ch, err := parseChannel(args[2])
+		if err != nil {
+			return err
+		}
+
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		if err := m.SetRoute(bank, ch, source); err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(&routeJSON{Output: fmt.Sprintf("%d/%d", bank, ch), Source: inputName(source)})
+		}
+		return nil
+
+	default:
+		return usagef("unrecognised route command: %s", args[0])
+	}
+}
+
+func listRoutes(m *motu.Client) error {
+	tree, err := m.GetTree("datastore/ext")
+	if err != nil {
+		return fmt.Errorf("failed to read datastore: %w", err)
+	}
+
+	// Channels are shown with their names from the web UI if
+	// they have them, e.g. "Analog 3" instead of just "0/2"
+	name := func(kind string, bank, ch int) string {
+		prefix := fmt.Sprintf("ext/%s/%d", kind, bank)
+		chName, _ := tree[fmt.Sprintf("%s/ch/%d/name", prefix, ch)].(string)
+		if chName == "" {
+			bankName, _ := tree[prefix+"/name"].(string)
+			chName = fmt.Sprintf("%s %d", bankName, ch+1)
+		}
+		return fmt.Sprintf("%d/%d (%s)", bank, ch, chName)
+	}
+
+	routes := motu.RoutesFromTree(tree)
+	if jsonOutput {
+		result := make([]*routeJSON, 0, len(routes))
+		for _, r := range routes {
+			result = append(result, &routeJSON{Output: fmt.Sprintf("%d/%d", r.Bank, r.Channel), Source: inputName(r.Source)})
+		}
+		return printJSON(result)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "OUTPUT\tSOURCE\n")
+	for _, r := range routes {
+		src := "-"
+		if r.Source != nil {
+			src = name(motu.BankInput, r.Source.Bank, r.Source.Channel)
+		}
+		fmt.Fprintf(w, "%s\t%s\n", name(motu.BankOutput, r.Bank, r.Channel), src)
+	}
+
+	return w.Flush()
+}
+
+// routeJSON is the JSON representation of a route, with channels
+// as "bank/channel". Source is null if nothing is routed.
+type routeJSON struct {
+	Output string  `json:"output"`
+	Source *string `json:"source"`
+}
+
+func inputName(i *motu.Input) *string {
+	if i == nil {
+		return nil
+	}
+	s := fmt.Sprintf("%d/%d", i.Bank, i.Channel)
+	return &s
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/netip"
+	"os"
+	"os/signal"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// scanCommand probes a subnet for interfaces, for
+// networks where discover can't find them
+func scanCommand(args []string) error {
+	flags := flag.NewFlagSet("scan", flag.ContinueOnError)
+	port := flags.Int("port", 80, "port of the datastore API")
+	timeout := flags.Duration("timeout", motu.DefaultScanTimeout, "how long to wait for each host")
+	rest, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(rest) != 1 {
+		return usagef("usage: scan <subnet>, e.g. scan 192.168.88.0/24")
+	}
+
+	prefix, err := netip.ParsePrefix(rest[0])
+	if err != nil {
+		// A single address is a subnet of one
+		addr, aerr := netip.ParseAddr(rest[0])
+		if aerr != nil {
+			return usagef("invalid subnet: %s", rest[0])
+		}
+		prefix = netip.PrefixFrom(addr, addr.BitLen())
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	results, err := motu.Scan(ctx, prefix, *port, *timeout)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		out := make([]map[string]string, 0, len(results))
+		for _, r := range results {
+			out = append(out, map[string]string{
+				"address":     r.Address,
+				"name":        r.Name,
+				"model":       r.Model,
+				"uid":         r.UID,
+				"firmware":    r.Firmware,
+				"api_version": r.APIVersion,
+			})
+		}
+		return printJSON(out)
+	}
+
+	if len(results) == 0 {
+		fmt.Printf("No devices found\n")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "ADDRESS\tNAME\tMODEL\tUID\n")
+	for _, r := range results {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Address, r.Name, r.Model, r.UID)
+	}
+
+	return w.Flush()
+}
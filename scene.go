@@ -0,0 +1,316 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Scene is a named set of property values that are recalled together
+type Scene struct {
+	// Keys are property paths, e.g. datastore/mix/chan/10/matrix/fader
+	Values map[string]any `yaml:"values" json:"values"`
+}
+
+// Scenes can be written by hand in either format
+var sceneExtensions = []string{".yaml", ".yml", ".json"}
+
+func scene(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: scene list | scene recall <name> [--fade 2s] | scene save <name> <device|property>...")
+	}
+
+	dir, err := scenesDir()
+	if err != nil {
+		return fmt.Errorf("failed to find scenes directory: %w", err)
+	}
+
+	switch args[0] {
+	case "list":
+		names, err := listScenes(dir)
+		if err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			if names == nil {
+				names = []string{}
+			}
+			return printJSON(names)
+		}
+
+		for _, name := range names {
+			fmt.Println(name)
+		}
+
+		return nil
+
+	case "recall":
+		flags := flag.NewFlagSet("scene recall", flag.ContinueOnError)
+		fade := flags.Duration("fade", 0, "time to fade levels from their current values")
+		positional, err := parseFlags(flags, args[1:])
+		if err != nil {
+			return err
+		}
+		if len(positional) < 1 {
+			return usagef("usage: scene recall <name> [--fade 2s]")
+		}
+
+		s, err := loadScene(dir, positional[0])
+		if err != nil {
+			return err
+		}
+
+		cfg, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		if err := recallScene(m, cfg, s, *fade); err != nil {
+			return err
+		}
+
+		if jsonOutput {
+			return printJSON(map[string]any{"scene": positional[0], "values": s.Values})
+		}
+		return nil
+
+	case "save":
+		if len(args) < 3 {
+			return usagef("usage: scene save <name> <device|property>...")
+		}
+
+		cfg, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		s, err := captureScene(m, cfg, args[2:])
+		if err != nil {
+			return err
+		}
+
+		if err := saveScene(dir, args[1], s); err != nil {
+			return err
+		}
+		commitToGit(cfg, "Save scene "+args[1], filepath.Join(dir, args[1]+".yaml"))
+
+		return printResult(map[string]any{"scene": args[1], "values": s.Values}, fmt.Sprintf("Saved %d values to scene %s", len(s.Values), args[1]))
+
+	default:
+		return usagef("unrecognised scene command: %s", args[0])
+	}
+}
+
+// captureScene reads the current value of each property. Device
+// names can be given instead of properties to capture a device's
+// level and mute state.
+func captureScene(m *motu.Client, cfg *Config, targets []string) (*Scene, error) {
+	var properties []string
+	for _, t := range targets {
+		d, ok := cfg.Devices[t]
+		if !ok {
+			properties = append(properties, motu.Path(t))
+			continue
+		}
+
+		properties = append(properties, d.Property)
+		if d.MuteProperty != "" {
+			properties = append(properties, d.MuteProperty)
+		}
+	}
+
+	s := &Scene{Values: map[string]any{}}
+	for _, p := range properties {
+		v, err := m.Value(p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", p, err)
+		}
+		s.Values[p] = v
+	}
+
+	return s, nil
+}
+
+// recallScene applies the scene's values. If fade is non-zero, levels
+// are ramped from their current values instead of jumping straight to
+// the new ones. Anything being unmuted is unmuted before the fade so
+// that it fades in, and anything being muted is muted after it.
+func recallScene(m *motu.Client, cfg *Config, s *Scene, fade time.Duration) error {
+	before := map[string]any{}
+	after := map[string]any{}
+	var ramp []motu.RampTarget
+
+	for p, v := range s.Values {
+		key := motu.Key(p)
+
+		if fade == 0 {
+			before[key] = v
+			continue
+		}
+
+		f, isNumber := toFloat(v)
+		switch {
+		case isNumber && isMuteProperty(key):
+			if f == 0 {
+				before[key] = v
+			} else {
+				after[key] = v
+			}
+		case isNumber:
+			if scale, ok := rampScale(cfg, key); ok {
+				ramp = append(ramp, motu.RampTarget{Property: p, To: f, Scale: scale})
+			} else {
+				before[key] = v
+			}
+		default:
+			before[key] = v
+		}
+	}
+
+	if len(before) > 0 {
+		if err := m.SetValues(before); err != nil {
+			return fmt.Errorf("failed to recall scene: %w", err)
+		}
+	}
+
+	if len(ramp) > 0 {
+		if err := m.Ramp(ramp, fade); err != nil {
+			return fmt.Errorf("failed to fade scene: %w", err)
+		}
+	}
+
+	if len(after) > 0 {
+		if err := m.SetValues(after); err != nil {
+			return fmt.Errorf("failed to recall scene: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// rampScale returns how a property should be interpolated during
+// a fade, or false if it isn't a level that can be faded. Configured
+// devices know their own scale. Otherwise faders are logarithmic
+// and trims are in dB.
+func rampScale(cfg *Config, key string) (motu.Scale, bool) {
+	for _, d := range cfg.Devices {
+		if motu.Key(d.Property) == key {
+			return d.Scale, true
+		}
+	}
+
+	name := strings.ToLower(path.Base(key))
+	switch {
+	case name == "fader":
+		return motu.ScaleLog, true
+	case strings.HasSuffix(name, "trim"):
+		return motu.ScaleLinear, true
+	default:
+		return "", false
+	}
+}
+
+func isMuteProperty(key string) bool {
+	return path.Base(key) == "mute"
+}
+
+// toFloat returns the value as a float64 if it is a number. Values
+// decoded from YAML may be ints as well as floats.
+func toFloat(v any) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int:
+		return float64(n), true
+	default:
+		return 0, false
+	}
+}
+
+func listScenes(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read scenes directory: %w", err)
+	}
+
+	var names []string
+	for _, e := range entries {
+		ext := filepath.Ext(e.Name())
+		for _, known := range sceneExtensions {
+			if ext == known {
+				names = append(names, strings.TrimSuffix(e.Name(), ext))
+			}
+		}
+	}
+
+	sort.Strings(names)
+	return names, nil
+}
+
+func loadScene(dir, name string) (*Scene, error) {
+	for _, ext := range sceneExtensions {
+		path := filepath.Join(dir, name+ext)
+
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("failed to read scene: %w", err)
+		}
+
+		s := &Scene{}
+		if ext == ".json" {
+			err = json.Unmarshal(b, s)
+		} else {
+			err = yaml.Unmarshal(b, s)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse scene %s: %w", path, err)
+		}
+
+		return s, nil
+	}
+
+	return nil, unknownf("unknown scene: %s", name)
+}
+
+func saveScene(dir, name string, s *Scene) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("failed to create scenes directory: %w", err)
+	}
+
+	b, err := yaml.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("failed to marshal scene: %w", err)
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, name+".yaml"), b, 0o644); err != nil {
+		return fmt.Errorf("failed to write scene: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/jakewright/motu-tools/internal/cron"
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// ScheduleRule is something the daemon does at certain times.
+// Each rule can do any combination of its actions.
+type ScheduleRule struct {
+	// When to run, as a five field cron expression,
+	// e.g. "0 22 * * *" for 10pm every day
+	Cron string `yaml:"cron"`
+
+	// Scene to recall
+	Scene string `yaml:"scene"`
+
+	// Device that Level, Mute and Max apply to
+	Device string `yaml:"device"`
+
+	// Level to set, in dB or as a percentage, e.g. "-30" or "40%"
+	Level string `yaml:"level"`
+
+	Mute *bool `yaml:"mute"`
+
+	// Cap the device's level in dB, until another rule changes
+	// it. If the device is above the cap, it's brought down to it.
+	// Percentages still map onto the device's whole range.
+	Max *float64 `yaml:"max"`
+
+	schedule *cron.Schedule
+}
+
+func (r *ScheduleRule) validate(cfg *Config) error {
+	var err error
+	if r.schedule, err = cron.Parse(r.Cron); err != nil {
+		return fmt.Errorf("invalid cron expression %q: %w", r.Cron, err)
+	}
+
+	if r.Level == "" && r.Mute == nil && r.Max == nil {
+		if r.Scene == "" {
+			return fmt.Errorf("rule %q does nothing", r.Cron)
+		}
+		return nil
+	}
+
+	d, ok := cfg.Devices[r.Device]
+	if !ok {
+		return fmt.Errorf("unknown device: %q", r.Device)
+	}
+
+	if r.Level != "" {
+		if _, err := parseLevel(d, r.Level); err != nil {
+			return err
+		}
+	}
+
+	if r.Mute != nil && d.MuteProperty == "" {
+		return fmt.Errorf("device %s has no mute property", r.Device)
+	}
+
+	if r.Max != nil && *r.Max <= d.Min {
+		return fmt.Errorf("max must be greater than the device's min")
+	}
+
+	return nil
+}
+
+// runSchedule runs the configured rules at the start of every
+// minute that they match, until ctx is cancelled
+func (s *server) runSchedule(ctx context.Context) {
+	for {
+		next := time.Now().Truncate(time.Minute).Add(time.Minute)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(next)):
+		}
+
+		for _, r := range s.cfg.Schedule {
+			if !r.schedule.Matches(next) {
+				continue
+			}
+
+			if err := s.runRule(r); err != nil {
+				slog.Error("Failed to run schedule", "cron", r.Cron, "err", err)
+			}
+		}
+	}
+}
+
+func (s *server) runRule(r *ScheduleRule) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if r.Scene != "" {
+		scene, err := loadScene(s.scenes, r.Scene)
+		if err != nil {
+			return err
+		}
+
+		if err := recallScene(s.client, s.cfg, scene, 0); err != nil {
+			return err
+		}
+	}
+
+	if r.Device == "" {
+		return nil
+	}
+	d := s.devices[r.Device]
+
+	if r.Max != nil {
+		s.caps.set(r.Device, d, *r.Max)
+
+		level, err := s.client.Level(d)
+		if err != nil {
+			return err
+		}
+		if level > *r.Max && !math.IsInf(level, 0) {
+			if err := s.setLevel(context.Background(), r.Device, d, *r.Max); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Level != "" {
+		v, err := parseLevel(d, r.Level)
+		if err != nil {
+			return err
+		}
+		if err := s.setValue(context.Background(), r.Device, d, v); err != nil {
+			return err
+		}
+	}
+
+	if r.Mute != nil {
+		if err := s.client.SetMute(d, *r.Mute); err != nil {
+			return err
+		}
+	}
+
+	slog.Info("Ran schedule", "cron", r.Cron)
+	return nil
+}
+
+// levelCaps are the caps that schedule rules have put on devices'
+// levels, in dB by device name. They're kept apart from the devices,
+// which everything in the daemon shares, so that a cap only limits
+// the levels that are set rather than changing what percentages and
+// steps mean.
+type levelCaps struct {
+	mu   sync.RWMutex
+	caps map[string]float64
+}
+
+// set caps the level of the named device. A cap at or above
+// the device's maximum removes it.
+func (lc *levelCaps) set(name string, d *motu.Device, db float64) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	if db >= d.Max {
+		delete(lc.caps, name)
+		return
+	}
+	if lc.caps == nil {
+		lc.caps = map[string]float64{}
+	}
+	lc.caps[name] = db
+}
+
+// limit returns value, a value of the named device's property,
+// brought down to the device's cap if it's above it
+func (lc *levelCaps) limit(name string, d *motu.Device, value float64) float64 {
+	lc.mu.RLock()
+	db, ok := lc.caps[name]
+	lc.mu.RUnlock()
+
+	if !ok || d.ToDB(value) <= db {
+		return value
+	}
+	return d.FromDB(db)
+}
@@ -0,0 +1,466 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const defaultListenAddress = "127.0.0.1:4747"
+
+// server exposes the configured devices over a small HTTP API. It
+// holds on to a single client so that connections to the interface
+// are reused between requests, which makes key presses much snappier.
+type server struct {
+	client  *motu.Client
+	cfg     *Config
+	devices map[string]*motu.Device
+	sleep   *SleepConfig
+
+	// The local copy of the datastore
+	mirror *motu.Mirror
+
+	// Coalesce inc and dec presses on each device
+	steppers map[string]*stepper
+
+	// Set by schedule rules
+	caps levelCaps
+
+	scenes string
+
+	// Serialises changes so that concurrent
+	// requests don't race each other
+	mu sync.Mutex
+
+	// The running sleep timer, if any
+	sleepMu     sync.Mutex
+	sleepUntil  time.Time
+	sleepGen    int
+	cancelSleep context.CancelFunc
+}
+
+func serve(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	listen := flags.String("listen", defaultListenAddress, "address to listen on")
+	grpcAddress := flags.String("grpc", "", "address to serve the gRPC API on, e.g. 127.0.0.1:4749")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Mirror != nil && cfg.Mirror.From == target {
+		return fmt.Errorf("can't mirror %s onto itself", target)
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	dir, err := scenesDir()
+	if err != nil {
+		return fmt.Errorf("failed to find scenes directory: %w", err)
+	}
+
+	// Errors for unsupported features say which API version the
+	// interface has. It's read in the background so that an
+	// interface that's off doesn't hold up the daemon.
+	go func() {
+		if version, err := m.APIVersion(); err != nil {
+			slog.Warn("Failed to read the interface's API version", "err", err)
+		} else {
+			slog.Info("Connected to the interface", "api_version", version)
+		}
+	}()
+
+	// Keep a local copy of the datastore so that
+	// status requests don't have to hit the device
+	mirror := m.Sync(context.Background())
+
+	if cfg.Hooks != nil {
+		go cfg.Hooks.watch(context.Background(), m, mirror, cfg.Devices)
+	}
+
+	s := &server{
+		client:   m,
+		cfg:      cfg,
+		devices:  cfg.Devices,
+		sleep:    cfg.Sleep,
+		mirror:   mirror,
+		scenes:   dir,
+		steppers: map[string]*stepper{},
+	}
+
+	for name, d := range cfg.Devices {
+		s.steppers[name] = newStepper(m, d, cfg.Acceleration, func(v float64) float64 {
+			return s.caps.limit(name, d, v)
+		})
+	}
+
+	go refreshCache(context.Background(), mirror, cfg.Devices)
+
+	if audit != nil {
+		go audit.watch(context.Background(), mirror)
+	}
+	go s.runSchedule(context.Background())
+	if cfg.MediaKeys != nil {
+		go s.runMediaKeys(context.Background())
+	}
+	if cfg.SystemVolume != nil {
+		go s.runSystemVolume(context.Background())
+	}
+	if cfg.SystemSleep != nil {
+		go s.runSystemSleep(context.Background())
+	}
+	go s.runTriggers(context.Background(), mirror)
+
+	if cfg.Mirror != nil {
+		go cfg.Mirror.run(context.Background(), m, mirror)
+	}
+	if cfg.Backups != nil {
+		go cfg.Backups.run(context.Background(), mirror)
+	}
+
+	tlsCfg, err := cfg.Server.tlsConfig(*listen)
+	if err != nil {
+		return err
+	}
+
+	for _, address := range []string{*listen, *grpcAddress} {
+		if address != "" && !isLoopback(address) && cfg.Server.Token == "" {
+			slog.Warn("Listening on a network address without a token, so anyone who can reach it can use it", "address", address)
+		}
+	}
+
+	if *grpcAddress != "" {
+		go func() {
+			if err := serveGRPC(*grpcAddress, s, tlsCfg); err != nil {
+				slog.Error("gRPC server failed", "err", err)
+			}
+		}()
+	}
+
+	srv := &http.Server{
+		Addr:      *listen,
+		Handler:   cfg.Server.requireToken(s.routes()),
+		TLSConfig: tlsCfg,
+	}
+
+	slog.Info("Listening", "address", *listen, "tls", tlsCfg != nil)
+	if tlsCfg != nil {
+		// The certificate is already in TLSConfig
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+func (s *server) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatusAll)
+	mux.HandleFunc("GET /devices/{device}", s.handleStatus)
+	mux.HandleFunc("POST /devices/{device}/inc", s.handleIncDec(true))
+	mux.HandleFunc("POST /devices/{device}/dec", s.handleIncDec(false))
+	mux.HandleFunc("POST /devices/{device}/mute", s.handleMute)
+	mux.HandleFunc("PUT /devices/{device}/level", s.handleSetLevel)
+	mux.HandleFunc("GET /sleep", s.handleGetSleep)
+	mux.HandleFunc("PUT /sleep", s.handleSetSleep)
+	mux.HandleFunc("DELETE /sleep", s.handleCancelSleep)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return mux
+}
+
+func (s *server) handleStatusAll(w http.ResponseWriter, r *http.Request) {
+	result := map[string]*deviceStatus{}
+	for name, d := range s.devices {
+		status, err := s.status(r.Context(), name, d)
+		if err != nil {
+			writeError(w, clientErrorStatus(err), fmt.Errorf("failed to get status of %s: %w", name, err))
+			return
+		}
+		result[name] = status
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func (s *server) handleStatus(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+
+	s.respondWithStatus(w, r, d)
+}
+
+func (s *server) handleIncDec(inc bool) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		d, ok := s.device(w, r)
+		if !ok {
+			return
+		}
+
+		// Rapid presses are coalesced rather than
+		// waiting for each other one at a time
+		value, err := s.steppers[r.PathValue("device")].step(r.Context(), inc)
+		if err != nil {
+			writeError(w, clientErrorStatus(err), err)
+			return
+		}
+
+		go feedback(s.cfg.Feedback, r.PathValue("device"), d, value)
+
+		s.respondWithStatus(w, r, d)
+	}
+}
+
+func (s *server) handleMute(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	s.mu.Lock()
+	switch r.URL.Query().Get("state") {
+	case "", "toggle":
+		_, err = s.client.MuteContext(r.Context(), d)
+	case "on":
+		err = s.client.SetMuteContext(r.Context(), d, true)
+	case "off":
+		err = s.client.SetMuteContext(r.Context(), d, false)
+	default:
+		s.mu.Unlock()
+		writeError(w, http.StatusBadRequest, errors.New("state must be on, off or toggle"))
+		return
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, clientErrorStatus(err), err)
+		return
+	}
+
+	s.respondWithStatus(w, r, d)
+}
+
+func (s *server) handleSetLevel(w http.ResponseWriter, r *http.Request) {
+	d, ok := s.device(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		LevelDB *float64 `json:"level_db"`
+		Percent *float64 `json:"percent"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err))
+		return
+	}
+	if (body.LevelDB == nil) == (body.Percent == nil) {
+		writeError(w, http.StatusBadRequest, errors.New("exactly one of level_db or percent is required"))
+		return
+	}
+
+	s.mu.Lock()
+	var err error
+	if body.LevelDB != nil {
+		err = s.setLevel(r.Context(), r.PathValue("device"), d, *body.LevelDB)
+	} else {
+		err = s.setPercent(r.Context(), r.PathValue("device"), d, *body.Percent)
+	}
+	s.mu.Unlock()
+	if err != nil {
+		writeError(w, clientErrorStatus(err), err)
+		return
+	}
+
+	s.respondWithStatus(w, r, d)
+}
+
+type sleepStatus struct {
+	// Null when no timer is running
+	Until *time.Time `json:"until"`
+}
+
+func (s *server) handleGetSleep(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.sleepStatus())
+}
+
+// handleSetSleep starts a sleep timer, replacing any that is running
+func (s *server) handleSetSleep(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Duration string `json:"duration"`
+		Fade     string `json:"fade"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("failed to decode body: %w", err))
+		return
+	}
+
+	after, err := time.ParseDuration(body.Duration)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration: %w", err))
+		return
+	}
+
+	fade := s.sleep.Fade
+	if body.Fade != "" {
+		if fade, err = time.ParseDuration(body.Fade); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid fade: %w", err))
+			return
+		}
+	}
+
+	d, ok := s.devices[s.sleep.Device]
+	if !ok {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("unknown sleep device: %s", s.sleep.Device))
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	s.sleepMu.Lock()
+	if s.cancelSleep != nil {
+		s.cancelSleep()
+	}
+	s.sleepUntil = time.Now().Add(after)
+	s.sleepGen++
+	s.cancelSleep = cancel
+	gen := s.sleepGen
+	s.sleepMu.Unlock()
+
+	go func() {
+		defer cancel()
+
+		err := sleepTimer(ctx, s.client, d, after, fade)
+		if err != nil && err != context.Canceled {
+			slog.Error("Sleep timer failed", "err", err)
+		}
+
+		// Clear the timer unless it has already been replaced
+		s.sleepMu.Lock()
+		if s.sleepGen == gen {
+			s.cancelSleep = nil
+		}
+		s.sleepMu.Unlock()
+	}()
+
+	writeJSON(w, http.StatusOK, s.sleepStatus())
+}
+
+func (s *server) handleCancelSleep(w http.ResponseWriter, r *http.Request) {
+	s.sleepMu.Lock()
+	if s.cancelSleep != nil {
+		s.cancelSleep()
+		s.cancelSleep = nil
+	}
+	s.sleepMu.Unlock()
+
+	writeJSON(w, http.StatusOK, s.sleepStatus())
+}
+
+func (s *server) sleepStatus() *sleepStatus {
+	s.sleepMu.Lock()
+	defer s.sleepMu.Unlock()
+
+	if s.cancelSleep == nil {
+		return &sleepStatus{}
+	}
+
+	until := s.sleepUntil
+	return &sleepStatus{Until: &until}
+}
+
+// device looks up the device named in the request path. If
+// it doesn't exist, an error is written to the response.
+func (s *server) device(w http.ResponseWriter, r *http.Request) (*motu.Device, bool) {
+	name := r.PathValue("device")
+	d, ok := s.devices[name]
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("unknown device: %s", name))
+	}
+	return d, ok
+}
+
+// setLevel sets a device's level in dB, like SetLevel,
+// but within any cap that a schedule rule has put on it
+func (s *server) setLevel(ctx context.Context, name string, d *motu.Device, db float64) error {
+	return s.setValue(ctx, name, d, d.FromLevel(db))
+}
+
+// setPercent is like setLevel but with a percentage, like SetPercent
+func (s *server) setPercent(ctx context.Context, name string, d *motu.Device, percent float64) error {
+	return s.setValue(ctx, name, d, d.FromPercent(percent))
+}
+
+// setValue sets a device's property within its cap
+func (s *server) setValue(ctx context.Context, name string, d *motu.Device, value float64) error {
+	if err := s.client.SetContext(ctx, d.Property, s.caps.limit(name, d, value)); err != nil {
+		return fmt.Errorf("failed to update property: %w", err)
+	}
+	return nil
+}
+
+func (s *server) respondWithStatus(w http.ResponseWriter, r *http.Request, d *motu.Device) {
+	status, err := s.status(r.Context(), r.PathValue("device"), d)
+	if err != nil {
+		writeError(w, clientErrorStatus(err), err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, status)
+}
+
+func (s *server) status(ctx context.Context, name string, d *motu.Device) (*deviceStatus, error) {
+	status, err := s.client.StatusContext(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the CLI dims devices, so the state file is read every time
+	st, err := loadState()
+	if err != nil {
+		return nil, err
+	}
+
+	return newDeviceStatus(name, status, st), nil
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", "err", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
+
+// clientErrorStatus returns the status code to respond
+// with when a request to the interface fails
+func clientErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, motu.ErrDeviceUnreachable):
+		return http.StatusGatewayTimeout
+	case errors.Is(err, motu.ErrPropertyNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, motu.ErrValueOutOfRange):
+		return http.StatusBadRequest
+	default:
+		return http.StatusBadGateway
+	}
+}
@@ -0,0 +1,287 @@
+package main
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// ServerConfig protects the daemon when it listens on an address that
+// other machines can reach
+type ServerConfig struct {
+	// Clients must send this as a bearer token. Empty lets
+	// anyone who can reach the daemon use it.
+	Token string `yaml:"token"`
+
+	// Serve the HTTP and gRPC APIs over TLS
+	TLS *TLSConfig `yaml:"tls"`
+}
+
+// TLSConfig is the certificate for the daemon to serve. Without a cert
+// and key, a self-signed certificate is generated and kept next to the
+// config file.
+type TLSConfig struct {
+	// PEM files. Relative paths are relative to the config file.
+	Cert string `yaml:"cert"`
+	Key  string `yaml:"key"`
+
+	// Extra host names and IP addresses for a generated certificate
+	Hosts []string `yaml:"hosts"`
+}
+
+// Where a generated certificate is kept, in the config file's directory
+const (
+	selfSignedCertFile = "server-cert.pem"
+	selfSignedKeyFile  = "server-key.pem"
+)
+
+// How long a generated certificate is valid for
+const selfSignedValidity = 2 * 365 * 24 * time.Hour
+
+func (sc *ServerConfig) validate() error {
+	if sc.TLS == nil {
+		return nil
+	}
+
+	if (sc.TLS.Cert == "") != (sc.TLS.Key == "") {
+		return fmt.Errorf("tls needs both a cert and a key, or neither")
+	}
+	if sc.TLS.Cert != "" && len(sc.TLS.Hosts) > 0 {
+		return fmt.Errorf("tls hosts are only used for a generated certificate")
+	}
+	return nil
+}
+
+// authorized returns whether r carries the token. Browsers can't set
+// headers when opening a WebSocket, so those can give it as ?token=.
+func (sc *ServerConfig) authorized(r *http.Request) bool {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok && websocket.IsWebSocketUpgrade(r) {
+		token = r.URL.Query().Get("token")
+	}
+	return sc.checkToken(token)
+}
+
+func (sc *ServerConfig) checkToken(token string) bool {
+	return subtle.ConstantTimeCompare([]byte(token), []byte(sc.Token)) == 1
+}
+
+// requireToken rejects requests that don't carry the token
+func (sc *ServerConfig) requireToken(next http.Handler) http.Handler {
+	if sc.Token == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !sc.authorized(r) {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="motu"`)
+			writeError(w, http.StatusUnauthorized, errors.New("missing or invalid token"))
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// grpcOptions returns the interceptors that check the token
+// from the "authorization" metadata of each call
+func (sc *ServerConfig) grpcOptions() []grpc.ServerOption {
+	if sc.Token == "" {
+		return nil
+	}
+
+	check := func(ctx context.Context) error {
+		md, _ := metadata.FromIncomingContext(ctx)
+		for _, v := range md.Get("authorization") {
+			if token, ok := strings.CutPrefix(v, "Bearer "); ok && sc.checkToken(token) {
+				return nil
+			}
+		}
+		return status.Error(codes.Unauthenticated, "missing or invalid token")
+	}
+
+	return []grpc.ServerOption{
+		grpc.UnaryInterceptor(func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+			if err := check(ctx); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.StreamInterceptor(func(srv any, ss grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := check(ss.Context()); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}
+}
+
+// tlsConfig loads the certificate, generating one first if needed.
+// It returns nil if TLS is off.
+func (sc *ServerConfig) tlsConfig(listen string) (*tls.Config, error) {
+	if sc.TLS == nil {
+		return nil, nil
+	}
+
+	cfgPath, err := configPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config: %w", err)
+	}
+	dir := filepath.Dir(cfgPath)
+
+	certFile, keyFile := sc.TLS.Cert, sc.TLS.Key
+	if certFile == "" {
+		certFile = filepath.Join(dir, selfSignedCertFile)
+		keyFile = filepath.Join(dir, selfSignedKeyFile)
+		if err := ensureSelfSigned(certFile, keyFile, certHosts(listen, sc.TLS.Hosts)); err != nil {
+			return nil, err
+		}
+	} else {
+		if !filepath.IsAbs(certFile) {
+			certFile = filepath.Join(dir, certFile)
+		}
+		if !filepath.IsAbs(keyFile) {
+			keyFile = filepath.Join(dir, keyFile)
+		}
+	}
+
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load certificate: %w", err)
+	}
+
+	// Clients that pin the certificate can check it against this
+	sum := sha256.Sum256(cert.Certificate[0])
+	slog.Info("Serving over TLS", "cert", certFile, "sha256", hex.EncodeToString(sum[:]))
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// certHosts returns the names and addresses that a generated
+// certificate should cover: this machine, the listen address and
+// any extra hosts from the config
+func certHosts(listen string, extra []string) []string {
+	hosts := []string{"localhost", "127.0.0.1", "::1"}
+	if name, err := os.Hostname(); err == nil {
+		hosts = append(hosts, name)
+		if !strings.Contains(name, ".") {
+			hosts = append(hosts, name+".local")
+		}
+	}
+	if host, _, err := net.SplitHostPort(listen); err == nil && host != "" {
+		if ip := net.ParseIP(host); ip == nil || !ip.IsUnspecified() {
+			hosts = append(hosts, host)
+		}
+	}
+	return append(hosts, extra...)
+}
+
+// ensureSelfSigned generates a certificate for hosts unless there's
+// already one that covers them and hasn't expired
+func ensureSelfSigned(certFile, keyFile string, hosts []string) error {
+	if cert, err := tls.LoadX509KeyPair(certFile, keyFile); err == nil {
+		if leaf, err := x509.ParseCertificate(cert.Certificate[0]); err == nil && time.Now().Before(leaf.NotAfter) && coversHosts(leaf, hosts) {
+			return nil
+		}
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key: %w", err)
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return fmt.Errorf("failed to generate serial number: %w", err)
+	}
+
+	tmpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: "motu serve"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(selfSignedValidity),
+		KeyUsage:              x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return fmt.Errorf("failed to generate certificate: %w", err)
+	}
+
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return fmt.Errorf("failed to encode key: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(certFile), 0o755); err != nil {
+		return fmt.Errorf("failed to create certificate directory: %w", err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		return fmt.Errorf("failed to write key: %w", err)
+	}
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o644); err != nil {
+		return fmt.Errorf("failed to write certificate: %w", err)
+	}
+
+	slog.Info("Generated a self-signed certificate", "cert", certFile, "hosts", hosts)
+	return nil
+}
+
+// coversHosts returns whether the certificate is valid for every host
+func coversHosts(cert *x509.Certificate, hosts []string) bool {
+	for _, h := range hosts {
+		if cert.VerifyHostname(h) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// isLoopback returns whether address only accepts
+// connections from this machine
+func isLoopback(address string) bool {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
@@ -0,0 +1,330 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// The name the daemon is installed under
+const (
+	launchAgentLabel = "com.github.jakewright.motu"
+	systemdUnitName  = "motu.service"
+)
+
+// service is how the daemon is run at login on this platform
+type service struct {
+	// Where the LaunchAgent plist or systemd unit goes
+	path string
+
+	// Where the daemon's output goes
+	logPath string
+}
+
+// serviceStatus is the JSON representation of the service
+type serviceStatus struct {
+	Installed bool   `json:"installed"`
+	Running   bool   `json:"running"`
+	Path      string `json:"path"`
+	Log       string `json:"log"`
+}
+
+func serviceCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: service install [--listen <address>] [--grpc <address>] | uninstall | status")
+	}
+
+	s, err := newService()
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "install":
+		flags := flag.NewFlagSet("service install", flag.ContinueOnError)
+		listen := flags.String("listen", defaultListenAddress, "address for the daemon to listen on")
+		grpcAddress := flags.String("grpc", "", "address for the daemon to serve the gRPC API on")
+		if _, err := parseFlags(flags, args[1:]); err != nil {
+			return err
+		}
+		return s.install(*listen, *grpcAddress)
+	case "uninstall":
+		return s.uninstall()
+	case "status":
+		return s.status()
+	default:
+		return usagef("unrecognised service command: %s", args[0])
+	}
+}
+
+func newService() (*service, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find home directory: %w", err)
+	}
+
+	switch runtime.GOOS {
+	case "darwin":
+		return &service{
+			path:    filepath.Join(home, "Library", "LaunchAgents", launchAgentLabel+".plist"),
+			logPath: filepath.Join(home, "Library", "Logs", "motu", "motu.log"),
+		}, nil
+
+	case "linux":
+		config := os.Getenv("XDG_CONFIG_HOME")
+		if config == "" {
+			config = filepath.Join(home, ".config")
+		}
+		state := os.Getenv("XDG_STATE_HOME")
+		if state == "" {
+			state = filepath.Join(home, ".local", "state")
+		}
+		return &service{
+			path:    filepath.Join(config, "systemd", "user", systemdUnitName),
+			logPath: filepath.Join(state, "motu", "motu.log"),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("installing the daemon is not supported on %s", runtime.GOOS)
+	}
+}
+
+// install writes the service file and starts the daemon. The daemon
+// runs with the executable, config file and target used to install it.
+// A password given with --password isn't written to the service file,
+// so it should go in the config file instead.
+func (s *service) install(listen, grpcAddress string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+	if exe, err = filepath.EvalSymlinks(exe); err != nil {
+		return fmt.Errorf("failed to find executable: %w", err)
+	}
+
+	path, err := configPath()
+	if err != nil {
+		return fmt.Errorf("failed to find config: %w", err)
+	}
+	if path, err = filepath.Abs(path); err != nil {
+		return fmt.Errorf("failed to find config: %w", err)
+	}
+
+	// Check that the daemon will be able to start
+	if _, err := readConfig(); err != nil {
+		return err
+	}
+
+	args := []string{exe, "--config", path}
+	if target != "" {
+		args = append(args, "--target", target)
+	}
+	if logFormat != "" {
+		args = append(args, "--log-format", logFormat)
+	}
+	if logLevel != "" {
+		args = append(args, "--log-level", logLevel)
+	}
+	args = append(args, "serve", "--listen", listen)
+	if grpcAddress != "" {
+		args = append(args, "--grpc", grpcAddress)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.logPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create log directory: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("failed to create service directory: %w", err)
+	}
+
+	var b bytes.Buffer
+	tmpl := systemdUnit
+	if runtime.GOOS == "darwin" {
+		tmpl = launchAgent
+	}
+	if err := tmpl.Execute(&b, map[string]any{
+		"Label": launchAgentLabel,
+		"Args":  args,
+		"Log":   s.logPath,
+	}); err != nil {
+		return fmt.Errorf("failed to write service file: %w", err)
+	}
+
+	// Stop the old one first if it's being reinstalled
+	if _, err := os.Stat(s.path); err == nil {
+		_ = s.stop()
+	}
+
+	if err := os.WriteFile(s.path, b.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("failed to write service file: %w", err)
+	}
+
+	if err := s.start(); err != nil {
+		return err
+	}
+
+	return printResult(map[string]any{"path": s.path, "log": s.logPath},
+		fmt.Sprintf("Installed %s\nLogging to %s", s.path, s.logPath))
+}
+
+// uninstall stops the daemon and removes the service file
+func (s *service) uninstall() error {
+	if _, err := os.Stat(s.path); errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("the daemon isn't installed")
+	}
+
+	if err := s.stop(); err != nil {
+		return err
+	}
+
+	if err := os.Remove(s.path); err != nil {
+		return fmt.Errorf("failed to remove service file: %w", err)
+	}
+
+	if runtime.GOOS == "linux" {
+		if err := systemctl("daemon-reload"); err != nil {
+			return err
+		}
+	}
+
+	return printResult(map[string]any{"path": s.path}, "Uninstalled "+s.path)
+}
+
+// status prints whether the daemon is installed and running
+func (s *service) status() error {
+	st := &serviceStatus{Path: s.path, Log: s.logPath}
+
+	if _, err := os.Stat(s.path); err == nil {
+		st.Installed = true
+	}
+
+	if runtime.GOOS == "darwin" {
+		st.Running = launchctl("print", launchdService()) == nil
+	} else {
+		st.Running = exec.Command("systemctl", "--user", "is-active", "--quiet", systemdUnitName).Run() == nil
+	}
+
+	var text string
+	switch {
+	case !st.Installed:
+		text = "Not installed"
+	case st.Running:
+		text = fmt.Sprintf("Running\nService: %s\nLog: %s", s.path, s.logPath)
+	default:
+		text = fmt.Sprintf("Installed but not running\nService: %s\nLog: %s", s.path, s.logPath)
+	}
+
+	return printResult(st, text)
+}
+
+func (s *service) start() error {
+	if runtime.GOOS == "darwin" {
+		return launchctl("bootstrap", launchdDomain(), s.path)
+	}
+
+	if err := systemctl("daemon-reload"); err != nil {
+		return err
+	}
+	return systemctl("enable", "--now", systemdUnitName)
+}
+
+func (s *service) stop() error {
+	if runtime.GOOS == "darwin" {
+		// It's fine for it not to be loaded
+		_ = launchctl("bootout", launchdService())
+		return nil
+	}
+
+	return systemctl("disable", "--now", systemdUnitName)
+}
+
+// launchdDomain is the domain of the logged in user's agents
+func launchdDomain() string {
+	return "gui/" + strconv.Itoa(os.Getuid())
+}
+
+func launchdService() string {
+	return launchdDomain() + "/" + launchAgentLabel
+}
+
+func launchctl(args ...string) error {
+	return runServiceTool("launchctl", args...)
+}
+
+func systemctl(args ...string) error {
+	return runServiceTool("systemctl", append([]string{"--user"}, args...)...)
+}
+
+// runServiceTool runs launchctl or systemctl, including
+// what it printed in the error if it fails
+func runServiceTool(name string, args ...string) error {
+	out, err := exec.Command(name, args...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("failed to run %s %s: %s", name, strings.Join(args, " "), msg)
+		}
+		return fmt.Errorf("failed to run %s %s: %w", name, strings.Join(args, " "), err)
+	}
+	return nil
+}
+
+var templateFuncs = template.FuncMap{
+	// Escapes text for a plist
+	"xml": func(s string) (string, error) {
+		var b strings.Builder
+		err := xml.EscapeText(&b, []byte(s))
+		return b.String(), err
+	},
+
+	// Quotes an argument for a systemd unit
+	"systemdQuote": func(s string) string {
+		return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "%", "%%").Replace(s) + `"`
+	},
+}
+
+var launchAgent = template.Must(template.New("plist").Funcs(templateFuncs).Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{xml .Label}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{xml .}}</string>
+{{- end}}
+	</array>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>{{xml .Log}}</string>
+	<key>StandardErrorPath</key>
+	<string>{{xml .Log}}</string>
+</dict>
+</plist>
+`))
+
+var systemdUnit = template.Must(template.New("unit").Funcs(templateFuncs).Parse(`[Unit]
+Description=MOTU daemon
+
+[Service]
+ExecStart={{range $i, $a := .Args}}{{if $i}} {{end}}{{systemdQuote $a}}{{end}}
+Restart=on-failure
+RestartSec=5
+StandardOutput=append:{{.Log}}
+StandardError=append:{{.Log}}
+
+[Install]
+WantedBy=default.target
+`))
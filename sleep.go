@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// SleepConfig configures the sleep timer
+type SleepConfig struct {
+	// The device to fade out
+	Device string `yaml:"device"`
+
+	// How long the fade out at the end of the timer lasts
+	Fade time.Duration `yaml:"fade"`
+}
+
+const (
+	defaultSleepDevice = "main"
+	defaultSleepFade   = 5 * time.Minute
+)
+
+func sleepCommand(args []string) error {
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("sleep", flag.ContinueOnError)
+	fade := flags.Duration("fade", cfg.Sleep.Fade, "how long to fade out for at the end")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		return usagef("usage: sleep <duration> [--fade 5m]")
+	}
+
+	after, err := time.ParseDuration(positional[0])
+	if err != nil {
+		return usagef("invalid duration: %s", positional[0])
+	}
+
+	d, ok := cfg.Devices[cfg.Sleep.Device]
+	if !ok {
+		return unknownf("unknown device: %s", cfg.Sleep.Device)
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	slog.Info("Sleep timer started", "at", time.Now().Add(after).Format(time.Kitchen))
+
+	if err := sleepTimer(ctx, m, d, after, *fade); err == context.Canceled {
+		slog.Info("Sleep timer cancelled")
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	slog.Info("Goodnight")
+	return nil
+}
+
+// sleepTimer waits until the timer is nearly up and then fades the
+// device out, finishing when the timer expires. Once silent, the device
+// is muted and its level put back so that unmuting it the next day
+// brings back the usual volume. If ctx is cancelled during the fade,
+// the level is put back straight away.
+func sleepTimer(ctx context.Context, m *motu.Client, d *motu.Device, after, fade time.Duration) error {
+	fade = min(fade, after)
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(after - fade):
+	}
+
+	original, err := m.Get(d.Property)
+	if err != nil {
+		return fmt.Errorf("failed to get current value: %w", err)
+	}
+
+	target := []motu.RampTarget{{Property: d.Property, To: d.ZeroVolume, Scale: d.Scale}}
+	if err := m.RampContext(ctx, target, fade); err != nil {
+		if ctx.Err() != nil {
+			if err := m.Set(d.Property, original); err != nil {
+				return fmt.Errorf("failed to restore level: %w", err)
+			}
+		}
+		return err
+	}
+
+	// Without a mute, leave the level at zero
+	if d.MuteProperty == "" {
+		return nil
+	}
+
+	if err := m.SetMute(d, true); err != nil {
+		return err
+	}
+
+	if err := m.Set(d.Property, original); err != nil {
+		return fmt.Errorf("failed to restore level: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,515 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Snapshot is a copy of some or all of the datastore
+type Snapshot struct {
+	Created time.Time `json:"created"`
+
+	// The subtree that was saved, relative to the
+	// datastore root. Empty means everything.
+	Prefix string `json:"prefix,omitempty"`
+
+	// Keys are paths relative to the datastore root
+	Values map[string]any `json:"values"`
+}
+
+func snapshot(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: snapshot save <file> [prefix] | snapshot restore <file> [--include glob,...] [--exclude glob,...] | snapshot diff <a> <b> | snapshot diff --live <file> | snapshot log [name] | snapshot checkout <rev> [name]")
+	}
+
+	switch args[0] {
+	case "restore":
+		return snapshotRestore(args[1:])
+	case "diff":
+		return snapshotDiff(args[1:])
+	case "log":
+		return snapshotLog(args[1:])
+	case "checkout":
+		return snapshotCheckout(args[1:])
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch args[0] {
+	case "save":
+		if len(args) < 2 {
+			return usagef("usage: snapshot save <file> [prefix]")
+		}
+
+		var prefix string
+		if len(args) > 2 {
+			prefix = args[2]
+		}
+
+		s := &Snapshot{
+			Created: time.Now(),
+			Prefix:  motu.Key(prefix),
+		}
+
+		s.Values, err = m.GetTree(motu.Path(s.Prefix))
+		if err != nil {
+			return fmt.Errorf("failed to read datastore: %w", err)
+		}
+
+		b, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to marshal snapshot: %w", err)
+		}
+
+		// With git, a name without a path is kept in the repository
+		file := args[1]
+		if cfg.Git && isSnapshotName(file) {
+			dir, err := snapshotsDir()
+			if err != nil {
+				return fmt.Errorf("failed to find snapshots directory: %w", err)
+			}
+			if err := os.MkdirAll(dir, 0o755); err != nil {
+				return fmt.Errorf("failed to create snapshots directory: %w", err)
+			}
+			file = filepath.Join(dir, file+".json")
+		}
+
+		if err := os.WriteFile(file, b, 0o644); err != nil {
+			return fmt.Errorf("failed to write snapshot: %w", err)
+		}
+		if abs, err := filepath.Abs(file); err == nil {
+			commitToGit(cfg, "Save snapshot "+args[1], abs)
+		}
+
+		return printResult(map[string]any{"file": file, "saved": len(s.Values)}, fmt.Sprintf("Saved %d values to %s", len(s.Values), file))
+
+	default:
+		return usagef("unrecognised snapshot command: %s", args[0])
+	}
+}
+
+// snapshotRestore writes a snapshot's values back to the interface,
+// or just the ones that the --include and --exclude globs select
+func snapshotRestore(args []string) error {
+	flags, filter := restoreFlags("snapshot restore")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) != 1 {
+		return usagef("usage: snapshot restore <file> [--include glob,...] [--exclude glob,...]")
+	}
+
+	s, err := readSnapshot(positional[0])
+	if err != nil {
+		return err
+	}
+
+	return restoreSnapshot(s, positional[0], filter)
+}
+
+// restoreFlags returns the flags for choosing what to restore
+func restoreFlags(name string) (*flag.FlagSet, *pathFilter) {
+	filter := &pathFilter{}
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.Func("include", "only restore paths matching these comma-separated globs, e.g. mix/chan/10/**", filter.add(&filter.include))
+	flags.Func("exclude", "don't restore paths matching these comma-separated globs, e.g. **/mute", filter.add(&filter.exclude))
+	return flags, filter
+}
+
+// restoreSnapshot writes the values that the filter selects from a
+// snapshot to the interface. Source is where the snapshot came from.
+func restoreSnapshot(s *Snapshot, source string, filter *pathFilter) error {
+	values := map[string]any{}
+	for k, v := range s.Values {
+		if filter.match(k) {
+			values[k] = v
+		}
+	}
+	if len(values) == 0 {
+		return fmt.Errorf("nothing in %s matches the filters", source)
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	if err := m.SetValues(values); err != nil {
+		return fmt.Errorf("failed to restore snapshot: %w", err)
+	}
+
+	return printResult(map[string]any{"file": source, "restored": len(values)}, fmt.Sprintf("Restored %d values from %s", len(values), source))
+}
+
+// snapshotLog lists the commits that changed a named snapshot,
+// or any snapshot or scene
+func snapshotLog(args []string) error {
+	if len(args) > 1 {
+		return usagef("usage: snapshot log [name]")
+	}
+
+	r, err := snapshotRepo()
+	if err != nil {
+		return err
+	}
+
+	paths := []string{"snapshots", "scenes"}
+	if len(args) == 1 {
+		paths = []string{"snapshots/" + args[0] + ".json"}
+	}
+
+	commits, err := r.log(paths...)
+	if err != nil {
+		return err
+	}
+
+	if jsonOutput {
+		if commits == nil {
+			commits = []*gitCommit{}
+		}
+		return printJSON(commits)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, c := range commits {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", c.Rev, c.Time.Local().Format("2006-01-02 15:04"), c.Message)
+	}
+	return w.Flush()
+}
+
+// snapshotCheckout restores a named snapshot as it was at a revision.
+// The name can be left out if there was only one snapshot then.
+func snapshotCheckout(args []string) error {
+	flags, filter := restoreFlags("snapshot checkout")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+	if len(positional) < 1 || len(positional) > 2 {
+		return usagef("usage: snapshot checkout <rev> [name] [--include glob,...] [--exclude glob,...]")
+	}
+
+	r, err := snapshotRepo()
+	if err != nil {
+		return err
+	}
+
+	rev := positional[0]
+	var name string
+	if len(positional) == 2 {
+		name = positional[1]
+	} else {
+		files, err := r.files(rev, "snapshots")
+		if err != nil {
+			return err
+		}
+		if len(files) != 1 {
+			return fmt.Errorf("there were %d snapshots at %s, so give the name of one", len(files), rev)
+		}
+		name = strings.TrimSuffix(path.Base(files[0]), ".json")
+	}
+
+	b, err := r.show(rev, "snapshots/"+name+".json")
+	if err != nil {
+		return err
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return fmt.Errorf("failed to parse snapshot %s at %s: %w", name, rev, err)
+	}
+
+	return restoreSnapshot(s, rev+":"+name, filter)
+}
+
+// snapshotRepo returns the git repository, if versioning is on
+func snapshotRepo() (*gitRepo, error) {
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.Git {
+		return nil, fmt.Errorf("snapshots aren't versioned; set git: true in the config file")
+	}
+	return openGitRepo()
+}
+
+// isSnapshotName returns whether a snapshot was given by name,
+// e.g. "studio", rather than as a path to a file
+func isSnapshotName(s string) bool {
+	return s != "" && !strings.ContainsAny(s, `/\`) && filepath.Ext(s) == ""
+}
+
+// pathFilter selects datastore keys with globs, in which * matches
+// within a segment and ** matches any number of segments. A glob also
+// matches everything under the paths it matches, so "mix/chan/10" is
+// the same as "mix/chan/10/**".
+type pathFilter struct {
+	include []string
+	exclude []string
+}
+
+// add returns a flag.Func that adds comma-separated globs to list
+func (f *pathFilter) add(list *[]string) func(string) error {
+	return func(s string) error {
+		for _, g := range strings.Split(s, ",") {
+			g = motu.Key(strings.TrimSpace(g))
+			if g == "" {
+				continue
+			}
+			if _, err := path.Match(g, ""); err != nil {
+				return fmt.Errorf("invalid glob %q: %w", g, err)
+			}
+			*list = append(*list, g)
+		}
+		return nil
+	}
+}
+
+// match returns whether the key matches an include glob,
+// or there aren't any, and doesn't match an exclude glob
+func (f *pathFilter) match(key string) bool {
+	included := len(f.include) == 0
+	for _, g := range f.include {
+		if globMatch(strings.Split(g, "/"), strings.Split(key, "/")) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, g := range f.exclude {
+		if globMatch(strings.Split(g, "/"), strings.Split(key, "/")) {
+			return false
+		}
+	}
+	return true
+}
+
+// globMatch returns whether the glob's segments match the
+// key's segments, or the segments of one of its parents
+func globMatch(glob, key []string) bool {
+	if len(glob) == 0 {
+		return true
+	}
+
+	if glob[0] == "**" {
+		for i := 0; i <= len(key); i++ {
+			if globMatch(glob[1:], key[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(key) == 0 {
+		return false
+	}
+	if ok, _ := path.Match(glob[0], key[0]); !ok {
+		return false
+	}
+	return globMatch(glob[1:], key[1:])
+}
+
+// readSnapshot reads a snapshot file, or a named snapshot
+// from the snapshots directory if there's no such file
+func readSnapshot(path string) (*Snapshot, error) {
+	if _, err := os.Stat(path); os.IsNotExist(err) && isSnapshotName(path) {
+		if dir, err := snapshotsDir(); err == nil {
+			path = filepath.Join(dir, path+".json")
+		}
+	}
+
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+
+	s := &Snapshot{}
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+// snapshotDiff prints what changed between two snapshots, or
+// with --live, between a snapshot and the datastore as it is now
+func snapshotDiff(args []string) error {
+	flags := flag.NewFlagSet("snapshot diff", flag.ContinueOnError)
+	live := flags.Bool("live", false, "compare the snapshot with the datastore as it is now")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if *live && len(positional) != 1 || !*live && len(positional) != 2 {
+		return usagef("usage: snapshot diff <a> <b> | snapshot diff --live <file>")
+	}
+
+	a, err := readSnapshot(positional[0])
+	if err != nil {
+		return err
+	}
+
+	var b *Snapshot
+	if *live {
+		cfg, err := readConfig()
+		if err != nil {
+			return err
+		}
+
+		m, err := newClient(cfg)
+		if err != nil {
+			return fmt.Errorf("failed to create client: %w", err)
+		}
+
+		b = &Snapshot{Created: time.Now(), Prefix: a.Prefix}
+		if b.Values, err = m.GetTree(motu.Path(a.Prefix)); err != nil {
+			return fmt.Errorf("failed to read datastore: %w", err)
+		}
+	} else if b, err = readSnapshot(positional[1]); err != nil {
+		return err
+	}
+
+	changes := diffValues(a.Values, b.Values)
+	sort.SliceStable(changes, func(i, j int) bool {
+		return diffSection(changes[i].Path) < diffSection(changes[j].Path)
+	})
+
+	if jsonOutput {
+		return printJSON(changes)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("No differences")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	var section string
+	for i, c := range changes {
+		if s := diffSection(c.Path); i == 0 || s != section {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			section = s
+			fmt.Fprintln(w, sectionTitle(section, a.Values, b.Values))
+		}
+		fmt.Fprintf(w, "  %s\t%s\t→ %s\n", strings.TrimPrefix(c.Path, section+"/"), diffValue(c.Old), diffValue(c.New))
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\n%d changed\n", len(changes))
+	return nil
+}
+
+// snapshotChange is a property that differs between two snapshots.
+// Old or New is missing if the property is only in one of them.
+type snapshotChange struct {
+	Path string `json:"path"`
+	Old  any    `json:"old,omitempty"`
+	New  any    `json:"new,omitempty"`
+}
+
+// diffValues returns the properties that differ, in order of path
+func diffValues(a, b map[string]any) []*snapshotChange {
+	keys := map[string]bool{}
+	for k := range a {
+		keys[k] = true
+	}
+	for k := range b {
+		keys[k] = true
+	}
+
+	var changes []*snapshotChange
+	for _, k := range sortedKeys(keys) {
+		old, inA := a[k]
+		v, inB := b[k]
+		if inA && inB && reflect.DeepEqual(old, v) {
+			continue
+		}
+		changes = append(changes, &snapshotChange{Path: k, Old: old, New: v})
+	}
+	return changes
+}
+
+// diffSection returns the part of a path that changes are grouped
+// by: up to the first index, e.g. "mix/chan/3" for a channel's
+// properties, or the first segment if there isn't one
+func diffSection(path string) string {
+	segs := strings.Split(path, "/")
+	for i, seg := range segs[:len(segs)-1] {
+		if _, err := strconv.Atoi(seg); err == nil {
+			return strings.Join(segs[:i+1], "/")
+		}
+	}
+	if len(segs) == 1 {
+		return ""
+	}
+	return segs[0]
+}
+
+// sectionTitle is the section's path, with its name from
+// the web UI if either snapshot has it
+func sectionTitle(section string, a, b map[string]any) string {
+	if section == "" {
+		return "datastore"
+	}
+
+	for _, values := range []map[string]any{b, a} {
+		for _, k := range []string{section + "/name", section + "/matrix/name"} {
+			if name, ok := values[k].(string); ok && name != "" {
+				return fmt.Sprintf("%s (%s)", section, name)
+			}
+		}
+	}
+	return section
+}
+
+// diffValue formats a value for the diff, with strings
+// quoted so that empty ones can be seen
+func diffValue(v any) string {
+	switch v := v.(type) {
+	case nil:
+		return "(none)"
+	case string:
+		return strconv.Quote(v)
+	default:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return fmt.Sprint(v)
+		}
+		return string(b)
+	}
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// SpeakersConfig defines two sets of outputs
+// that can be switched between
+type SpeakersConfig struct {
+	A *SpeakerSet `yaml:"a"`
+	B *SpeakerSet `yaml:"b"`
+
+	// Give the set being switched to the same level as the
+	// set being switched from, adjusted by their trims
+	MatchLevels bool `yaml:"match_levels"`
+}
+
+// SpeakerSet is a group of devices that are switched together
+type SpeakerSet struct {
+	Devices []string `yaml:"devices"`
+
+	// Offset in dB applied to the set's level when levels are matched,
+	// to make up for differences in the sensitivity of the speakers
+	Trim float64 `yaml:"trim"`
+}
+
+func (c *SpeakersConfig) validate(devices map[string]*motu.Device) error {
+	for name, set := range map[string]*SpeakerSet{"a": c.A, "b": c.B} {
+		if set == nil || len(set.Devices) == 0 {
+			return fmt.Errorf("set %s has no devices", name)
+		}
+
+		for _, dev := range set.Devices {
+			d, ok := devices[dev]
+			if !ok {
+				return fmt.Errorf("set %s: unknown device: %s", name, dev)
+			}
+			if d.MuteProperty == "" {
+				return fmt.Errorf("set %s: device %s has no mute property", name, dev)
+			}
+		}
+	}
+
+	return nil
+}
+
+func speakersCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: speakers a|b|toggle")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Speakers == nil {
+		return fmt.Errorf("no speakers configured")
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	var to string
+	switch args[0] {
+	case "a", "b":
+		to = args[0]
+	case "toggle":
+		// Whichever set is playing at the moment is switched away from
+		muted, err := m.Muted(cfg.Devices[cfg.Speakers.A.Devices[0]])
+		if err != nil {
+			return err
+		}
+		to = "b"
+		if muted {
+			to = "a"
+		}
+	default:
+		return usagef("usage: speakers a|b|toggle")
+	}
+
+	if err := switchSpeakers(m, cfg, to); err != nil {
+		return err
+	}
+
+	return printResult(map[string]string{"speakers": to}, to)
+}
+
+// switchSpeakers mutes the other set and unmutes the named set
+func switchSpeakers(m *motu.Client, cfg *Config, to string) error {
+	from, next := cfg.Speakers.A, cfg.Speakers.B
+	if to == "a" {
+		from, next = next, from
+	}
+
+	var level float64
+	if cfg.Speakers.MatchLevels {
+		var err error
+		level, err = m.Level(cfg.Devices[from.Devices[0]])
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, name := range from.Devices {
+		if err := m.SetMute(cfg.Devices[name], true); err != nil {
+			return fmt.Errorf("failed to mute %s: %w", name, err)
+		}
+	}
+
+	// A set at zero volume has no level to match
+	if cfg.Speakers.MatchLevels && !math.IsInf(level, 0) {
+		for _, name := range next.Devices {
+			if _, err := m.SetLevel(cfg.Devices[name], level-from.Trim+next.Trim); err != nil {
+				return fmt.Errorf("failed to set level of %s: %w", name, err)
+			}
+		}
+	}
+
+	for _, name := range next.Devices {
+		if err := m.SetMute(cfg.Devices[name], false); err != nil {
+			return fmt.Errorf("failed to unmute %s: %w", name, err)
+		}
+	}
+
+	return nil
+}
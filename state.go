@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// State is remembered between invocations so that commands
+// like dim can undo what they did last time they ran
+type State struct {
+	// Raw property value of each dimmed device before it was dimmed
+	Dimmed map[string]float64 `json:"dimmed,omitempty"`
+
+	// Values the mono properties had before the mix was collapsed
+	// to mono. Keys are paths relative to the datastore root. Nil
+	// when the mix is in stereo.
+	Mono map[string]any `json:"mono,omitempty"`
+
+	// Mute states from before mute-all, restored by unmute-all
+	Muted *Snapshot `json:"muted,omitempty"`
+
+	// Values from before the computer went to sleep, which
+	// the daemon restores when it wakes
+	Asleep *Snapshot `json:"asleep,omitempty"`
+
+	// Where the interface with the configured UID was last
+	// found, if it has moved from the configured address
+	Address string `json:"address,omitempty"`
+
+	// The built-in profile for the interface's model, once it's been
+	// looked up. Only used when the config file has no devices.
+	Profile *profileState `json:"profile,omitempty"`
+}
+
+// statePath returns the location of the state file, which sits
+// alongside the config file. Each target has its own state.
+func statePath() (string, error) {
+	return statePathFor(target)
+}
+
+// statePathFor is like statePath but for the given target
+func statePathFor(t string) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+
+	name := "state.json"
+	if t != "" {
+		name = "state-" + t + ".json"
+	}
+
+	return filepath.Join(filepath.Dir(path), name), nil
+}
+
+// loadState reads the state file. If the file
+// does not exist, an empty state is returned.
+func loadState() (*State, error) {
+	return loadStateFor(target)
+}
+
+// loadStateFor is like loadState but for the given target
+func loadStateFor(t string) (*State, error) {
+	path, err := statePathFor(t)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find state file: %w", err)
+	}
+
+	s := &State{}
+
+	b, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("failed to read state file: %w", err)
+	}
+
+	if err := json.Unmarshal(b, s); err != nil {
+		return nil, fmt.Errorf("failed to parse state file %s: %w", path, err)
+	}
+
+	return s, nil
+}
+
+func (s *State) save() error {
+	path, err := statePath()
+	if err != nil {
+		return fmt.Errorf("failed to find state file: %w", err)
+	}
+
+	b, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal state: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, b, 0o644); err != nil {
+		return fmt.Errorf("failed to write state file: %w", err)
+	}
+
+	return nil
+}
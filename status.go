@@ -0,0 +1,135 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// deviceStatus is the JSON representation of a device's state
+type deviceStatus struct {
+	Device string `json:"device"`
+
+	// The raw value of the device's property
+	Value float64 `json:"value"`
+
+	// Level in dB, or null if the device is at zero volume
+	// and the level can't be expressed in dB
+	LevelDB *float64 `json:"level_db"`
+
+	Percent float64 `json:"percent"`
+	Muted   bool    `json:"muted"`
+	Dimmed  bool    `json:"dimmed"`
+}
+
+func newDeviceStatus(name string, s *motu.Status, st *State) *deviceStatus {
+	_, dimmed := st.Dimmed[name]
+	ds := &deviceStatus{
+		Device:  name,
+		Value:   s.Value,
+		Percent: s.Percent,
+		Muted:   s.Muted,
+		Dimmed:  dimmed,
+	}
+
+	if !math.IsInf(s.LevelDB, 0) {
+		level := s.LevelDB
+		ds.LevelDB = &level
+	}
+
+	return ds
+}
+
+// statusCommand prints the state of the named device, or
+// of every configured device if name is empty
+func statusCommand(name string, args []string) error {
+	flags := flag.NewFlagSet("status", flag.ContinueOnError)
+	asJSON := flags.Bool("json", jsonOutput, "print the status as JSON")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	var names []string
+	if name != "" {
+		if _, ok := cfg.Devices[name]; !ok {
+			return unknownf("unknown device: %s", name)
+		}
+		names = []string{name}
+	} else {
+		for n := range cfg.Devices {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+	}
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	var statuses []*deviceStatus
+	for _, n := range names {
+		s, err := m.Status(cfg.Devices[n])
+		if err != nil {
+			return fmt.Errorf("failed to get status of %s: %w", n, err)
+		}
+		statuses = append(statuses, newDeviceStatus(n, s, st))
+	}
+
+	if *asJSON {
+		// A single device is printed as an object rather than a list
+		if name != "" {
+			return printJSON(statuses[0])
+		}
+		return printJSON(statuses)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "DEVICE\tLEVEL\tPERCENT\tMUTE\tVALUE\n")
+	for _, s := range statuses {
+		level := "-inf dB"
+		if s.LevelDB != nil {
+			level = fmt.Sprintf("%.1f dB", *s.LevelDB)
+		}
+
+		mute := "unmuted"
+		if s.Muted {
+			mute = "muted"
+		}
+
+		fmt.Fprintf(w, "%s\t%s\t%.0f%%\t%s\t%g\n", s.Device, level, s.Percent, mute, s.Value)
+	}
+
+	return w.Flush()
+}
+
+// printDeviceStatus prints the state of a device as JSON,
+// for --json after a command has changed it
+func printDeviceStatus(m *motu.Client, name string, d *motu.Device) error {
+	s, err := m.Status(d)
+	if err != nil {
+		return fmt.Errorf("failed to get status of %s: %w", name, err)
+	}
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	return printJSON(newDeviceStatus(name, s, st))
+}
@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Defaults for acceleration
+const (
+	defaultAccelerationWindow = 300 * time.Millisecond
+	defaultAccelerationEvery  = 4
+	defaultAccelerationMax    = 4
+)
+
+// AccelerationConfig makes the daemon take bigger steps when inc or
+// dec presses arrive in a burst, e.g. when a volume key is held down
+type AccelerationConfig struct {
+	// Presses closer together than this are part of
+	// the same burst. Defaults to 300ms.
+	Window time.Duration `yaml:"window"`
+
+	// Each press moves one more step than the last after this many
+	// presses in the same burst. Defaults to 4.
+	Every int `yaml:"every"`
+
+	// The most steps that one press can move. Defaults to 4.
+	Max int `yaml:"max"`
+}
+
+func (ac *AccelerationConfig) validate() error {
+	if ac.Window < 0 || ac.Every < 0 || ac.Max < 0 {
+		return fmt.Errorf("window, every and max can't be negative")
+	}
+
+	if ac.Window == 0 {
+		ac.Window = defaultAccelerationWindow
+	}
+	if ac.Every == 0 {
+		ac.Every = defaultAccelerationEvery
+	}
+	if ac.Max == 0 {
+		ac.Max = defaultAccelerationMax
+	}
+	return nil
+}
+
+// stepper coalesces rapid inc and dec presses on a device, e.g. when a
+// volume key repeats. Each press moves a level that's tracked locally
+// rather than reading the device again. Only one write is made at a
+// time, and presses that arrive in the meantime are folded into the
+// next write, so a burst of presses ends up as one or two requests.
+type stepper struct {
+	client *motu.Client
+	device *motu.Device
+	accel  *AccelerationConfig // nil if presses always move one step
+
+	// Keeps the level within any cap on it
+	limit func(float64) float64
+
+	mu      sync.Mutex
+	target  float64
+	active  bool // whether target is being tracked
+	writing bool
+
+	// The burst of presses in progress
+	lastPress time.Time
+	lastInc   bool
+	burst     int
+}
+
+func newStepper(client *motu.Client, d *motu.Device, accel *AccelerationConfig, limit func(float64) float64) *stepper {
+	return &stepper{client: client, device: d, accel: accel, limit: limit}
+}
+
+// steps returns how many steps a press made now should move, and
+// keeps track of the burst it's part of. Must be called with mu held.
+func (s *stepper) steps(inc bool) int {
+	if s.accel == nil {
+		return 1
+	}
+
+	now := time.Now()
+	if now.Sub(s.lastPress) > s.accel.Window || inc != s.lastInc {
+		s.burst = 0
+	}
+	s.lastPress = now
+	s.lastInc = inc
+
+	n := 1 + s.burst/s.accel.Every
+	s.burst++
+	return min(n, s.accel.Max)
+}
+
+// step moves the device's level up (inc = true) or down by one step, or
+// more if presses are accelerating, and returns the intended value. If
+// a write is already in flight, the new value is left for it to write,
+// and errors writing it aren't reported.
+func (s *stepper) step(ctx context.Context, inc bool) (float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if !s.active {
+		current, err := s.client.GetContext(ctx, s.device.Property)
+		if err != nil {
+			return 0, err
+		}
+		s.target = current
+		s.active = true
+	}
+
+	for range s.steps(inc) {
+		s.target = s.limit(s.device.Step(s.target, inc))
+	}
+	result := s.target
+
+	if s.writing {
+		return result, nil
+	}
+
+	// Later presses are folded into this write, so it mustn't
+	// be cancelled if the request that started it goes away
+	ctx = context.WithoutCancel(ctx)
+
+	s.writing = true
+	defer func() {
+		s.writing = false
+		s.active = false
+	}()
+
+	for {
+		v := s.target
+
+		s.mu.Unlock()
+		err := s.client.SetContext(ctx, s.device.Property, v)
+		s.mu.Lock()
+
+		if err != nil {
+			return 0, err
+		}
+
+		if s.target == v {
+			return result, nil
+		}
+	}
+}
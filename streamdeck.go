@@ -0,0 +1,291 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+const defaultStreamDeckListenAddress = "127.0.0.1:4748"
+
+// streamDeckServer is a backend for a Stream Deck plugin. The plugin
+// holds a WebSocket open, sends actions over it and receives the state
+// of every device whenever it changes, so that button icons can show
+// the current level and mute state.
+//
+// Actions are JSON objects:
+//
+//	{"id": "1", "action": "inc", "device": "main"}
+//	{"id": "2", "action": "dec", "device": "main"}
+//	{"id": "3", "action": "mute", "device": "computer"}
+//	{"id": "4", "action": "scene", "scene": "podcast"}
+//	{"id": "5", "action": "status"}
+//	{"id": "6", "action": "set", "device": "main", "percent": 40}
+//
+// and each one gets a result with the same ID:
+//
+//	{"type": "result", "id": "1", "error": ""}
+//
+// State is pushed as:
+//
+//	{"type": "state", "device": "main", "level_db": -20, "level": 0.6, "muted": false}
+type streamDeckServer struct {
+	client  *motu.Client
+	cfg     *Config
+	mirror  *motu.Mirror
+	scenes  string
+	upgrade websocket.Upgrader
+
+	// Serialises changes so that repeated presses
+	// are applied in order and don't race
+	mu sync.Mutex
+}
+
+type streamDeckAction struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Device string `json:"device"`
+	Scene  string `json:"scene"`
+
+	// For set, from a slider or dial
+	Percent *float64 `json:"percent"`
+}
+
+type streamDeckResult struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+type streamDeckState struct {
+	Type   string `json:"type"`
+	Device string `json:"device"`
+
+	// Null when the device is at zero volume
+	LevelDB *float64 `json:"level_db"`
+
+	// Position of the level between the device's Min and Max, 0 to 1
+	Level float64 `json:"level"`
+	Muted bool    `json:"muted"`
+}
+
+// streamDeckConn wraps a connection so that
+// writes from different goroutines don't interleave
+type streamDeckConn struct {
+	*websocket.Conn
+	mu sync.Mutex
+}
+
+func (c *streamDeckConn) send(v any) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.WriteJSON(v)
+}
+
+func streamDeckCommand(args []string) error {
+	flags := flag.NewFlagSet("streamdeck", flag.ContinueOnError)
+	listen := flags.String("listen", defaultStreamDeckListenAddress, "address to listen on")
+	origins := flags.String("origins", "", "comma separated origins to accept besides plugins that don't send one")
+	if err := parseFlagSet(flags, args); err != nil {
+		return err
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	dir, err := scenesDir()
+	if err != nil {
+		return fmt.Errorf("failed to find scenes directory: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	var allowed []string
+	if *origins != "" {
+		allowed = strings.Split(*origins, ",")
+	}
+
+	s := &streamDeckServer{
+		client: m,
+		cfg:    cfg,
+		mirror: m.Sync(ctx),
+		scenes: dir,
+		upgrade: websocket.Upgrader{
+			CheckOrigin: streamDeckOrigin(allowed),
+		},
+	}
+
+	srv := &http.Server{Addr: *listen, Handler: cfg.Server.requireToken(s)}
+	go func() {
+		<-ctx.Done()
+		_ = srv.Close()
+	}()
+
+	slog.Info("Listening", "address", *listen)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
+}
+
+// streamDeckOrigin returns a check that only lets a WebSocket connect
+// without an Origin or from one of the allowed origins. The plugin runs
+// inside the Stream Deck app, which doesn't send an Origin, but browsers
+// always do, so this stops any web page that happens to be open from
+// connecting and changing levels.
+func streamDeckOrigin(allowed []string) func(*http.Request) bool {
+	return func(r *http.Request) bool {
+		origin := r.Header.Get("Origin")
+		return origin == "" || slices.Contains(allowed, origin)
+	}
+}
+
+func (s *streamDeckServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ws, err := s.upgrade.Upgrade(w, r, nil)
+	if err != nil {
+		// Upgrade has already written an error response
+		return
+	}
+	defer ws.Close()
+
+	conn := &streamDeckConn{Conn: ws}
+
+	changes, unsubscribe := s.mirror.Subscribe()
+	defer unsubscribe()
+
+	// Push state until the connection closes
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		s.sendAll(conn)
+		for {
+			select {
+			case <-done:
+				return
+			case batch, ok := <-changes:
+				if !ok {
+					return
+				}
+				for name, d := range s.cfg.Devices {
+					_, levelChanged := batch[motu.Key(d.Property)]
+					_, muteChanged := batch[motu.Key(d.MuteProperty)]
+					if levelChanged || muteChanged {
+						s.sendState(conn, name, d)
+					}
+				}
+			}
+		}
+	}()
+
+	for {
+		var action streamDeckAction
+		if err := ws.ReadJSON(&action); err != nil {
+			return
+		}
+
+		result := &streamDeckResult{Type: "result", ID: action.ID}
+		if err := s.handle(conn, &action); err != nil {
+			result.Error = err.Error()
+		}
+
+		if err := conn.send(result); err != nil {
+			return
+		}
+	}
+}
+
+func (s *streamDeckServer) handle(conn *streamDeckConn, action *streamDeckAction) error {
+	if action.Action == "status" {
+		s.sendAll(conn)
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if action.Action == "scene" {
+		scene, err := loadScene(s.scenes, action.Scene)
+		if err != nil {
+			return err
+		}
+		return recallScene(s.client, s.cfg, scene, 0)
+	}
+
+	d, ok := s.cfg.Devices[action.Device]
+	if !ok {
+		return fmt.Errorf("unknown device: %s", action.Device)
+	}
+
+	switch action.Action {
+	case "inc", "dec":
+		_, err := s.client.IncDec(d, action.Action == "inc")
+		return err
+	case "mute":
+		_, err := s.client.Mute(d)
+		return err
+	case "set":
+		if action.Percent == nil {
+			return fmt.Errorf("set needs a percent")
+		}
+		_, err := s.client.SetPercent(d, *action.Percent)
+		return err
+	default:
+		return fmt.Errorf("unknown action: %s", action.Action)
+	}
+}
+
+func (s *streamDeckServer) sendAll(conn *streamDeckConn) {
+	for name, d := range s.cfg.Devices {
+		s.sendState(conn, name, d)
+	}
+}
+
+func (s *streamDeckServer) sendState(conn *streamDeckConn, name string, d *motu.Device) {
+	level, err := s.client.Level(d)
+	if err != nil {
+		slog.Error("Failed to read level", "device", name, "err", err)
+		return
+	}
+
+	state := &streamDeckState{
+		Type:   "state",
+		Device: name,
+		Level:  d.DBToPercent(level) / 100,
+	}
+	if !math.IsInf(level, 0) {
+		state.LevelDB = &level
+	}
+
+	if d.MuteProperty != "" {
+		state.Muted, err = s.client.Muted(d)
+		if err != nil {
+			slog.Error("Failed to read mute", "device", name, "err", err)
+			return
+		}
+	}
+
+	if err := conn.send(state); err != nil {
+		slog.Error("Failed to send state", "err", err)
+	}
+}
@@ -0,0 +1,241 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// MirrorConfig makes the daemon replay changes made on another
+// target onto the interface it's running against
+type MirrorConfig struct {
+	// The target to copy changes from
+	From string `yaml:"from"`
+
+	// Only copy properties under these paths, e.g. "mix".
+	// If empty, everything but the clock and hardware
+	// settings is copied.
+	Paths []string `yaml:"paths"`
+}
+
+func (mc *MirrorConfig) validate(targets map[string]yaml.Node) error {
+	if mc.From == "" {
+		return fmt.Errorf("from is required")
+	}
+
+	if _, ok := targets[mc.From]; !ok {
+		return fmt.Errorf("unknown target: %s", mc.From)
+	}
+
+	return nil
+}
+
+// syncCommand copies the datastore of one target to another
+func syncCommand(args []string) error {
+	flags := flag.NewFlagSet("sync", flag.ContinueOnError)
+	paths := flags.String("paths", "", "comma-separated paths to copy, e.g. mix,ext/obank")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 {
+		return usagef("usage: sync <src> <dst> [--paths prefix,...]")
+	}
+
+	src, err := targetClient(positional[0])
+	if err != nil {
+		return err
+	}
+
+	dst, err := targetClient(positional[1])
+	if err != nil {
+		return err
+	}
+
+	var prefixes []string
+	if *paths != "" {
+		prefixes = strings.Split(*paths, ",")
+	}
+
+	from, err := readTrees(src, prefixes)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", positional[0], err)
+	}
+
+	to, err := readTrees(dst, prefixes)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", positional[1], err)
+	}
+
+	values := copyValues(from, to, prefixes)
+	if len(values) > 0 {
+		if err := dst.SetValues(values); err != nil {
+			return fmt.Errorf("failed to update %s: %w", positional[1], err)
+		}
+	}
+
+	return printResult(map[string]any{"copied": sortedKeys(values)}, fmt.Sprintf("%d values copied", len(values)))
+}
+
+// targetClient returns a client for the named target
+func targetClient(name string) (*motu.Client, error) {
+	path, err := configPath()
+	if err != nil {
+		return nil, fmt.Errorf("failed to find config: %w", err)
+	}
+
+	cfg, err := loadConfig(path, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create client for %s: %w", name, err)
+	}
+
+	return m, nil
+}
+
+// readTrees reads every value under the prefixes,
+// or the whole datastore if there are none
+func readTrees(m *motu.Client, prefixes []string) (map[string]any, error) {
+	if len(prefixes) == 0 {
+		return m.GetTree("datastore")
+	}
+
+	result := map[string]any{}
+	for _, p := range prefixes {
+		tree, err := m.GetTree(motu.Path(p))
+		if err != nil {
+			return nil, err
+		}
+		for k, v := range tree {
+			result[k] = v
+		}
+	}
+
+	return result, nil
+}
+
+// hardwareProperties are only copied when one is given as a path by
+// itself. Changing the clock interrupts audio, and the others change
+// which channels the interface has.
+var hardwareProperties = []string{
+	"ext/clockSource",
+	"ext/sampleRate",
+	"ext/wordClockOut",
+	"ext/wordClockThru",
+	"ext/smuxPerBank",
+	"ext/maxUSBToHost",
+}
+
+// copyValues returns the values in from that are under one of the
+// prefixes, and that to has but with a different value. Properties
+// that identify the interface itself are never copied.
+func copyValues(from, to map[string]any, prefixes []string) map[string]any {
+	result := map[string]any{}
+	for k, v := range from {
+		if k == "uid" || strings.HasPrefix(k, "avb/") {
+			continue
+		}
+
+		if slices.Contains(hardwareProperties, k) && !named(k, prefixes) {
+			continue
+		}
+
+		if !underPrefix(k, prefixes) {
+			continue
+		}
+
+		current, ok := to[k]
+		if !ok || reflect.DeepEqual(current, v) {
+			continue
+		}
+
+		result[k] = v
+	}
+
+	return result
+}
+
+// underPrefix returns whether the key is under any of the
+// prefixes. Every key is under an empty list of prefixes.
+func underPrefix(key string, prefixes []string) bool {
+	if len(prefixes) == 0 {
+		return true
+	}
+
+	for _, p := range prefixes {
+		p = motu.Key(p)
+		if key == p || strings.HasPrefix(key, p+"/") {
+			return true
+		}
+	}
+
+	return false
+}
+
+// named returns whether the key is one of the prefixes itself
+func named(key string, prefixes []string) bool {
+	for _, p := range prefixes {
+		if motu.Key(p) == key {
+			return true
+		}
+	}
+	return false
+}
+
+// run replays changes from the source target onto dst until ctx is
+// cancelled. Whenever dst comes back after being unreachable, every
+// value is copied again in case changes were missed in the meantime.
+func (mc *MirrorConfig) run(ctx context.Context, dst *motu.Client, dstMirror *motu.Mirror) {
+	src, err := targetClient(mc.From)
+	if err != nil {
+		slog.Error("Failed to mirror", "from", mc.From, "err", err)
+		return
+	}
+
+	srcMirror := src.Sync(ctx)
+
+	changes, unsubscribe := srcMirror.Subscribe()
+	defer unsubscribe()
+
+	synced, unsubscribeSynced := dstMirror.SubscribeSynced()
+	defer unsubscribeSynced()
+
+	for {
+		var batch map[string]any
+		select {
+		case <-ctx.Done():
+			return
+		case batch = <-changes:
+			if !dstMirror.Synced() {
+				continue
+			}
+		case ok := <-synced:
+			if !ok || !srcMirror.Synced() {
+				continue
+			}
+			batch = srcMirror.Values()
+		}
+
+		values := copyValues(batch, dstMirror.Values(), mc.Paths)
+		if len(values) == 0 {
+			continue
+		}
+
+		if err := dst.SetValues(values); err != nil {
+			slog.Error("Failed to mirror values", "from", mc.From, "count", len(values), "err", err)
+		}
+	}
+}
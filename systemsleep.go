@@ -0,0 +1,174 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// SystemSleepConfig has the daemon quieten the interface while the
+// computer is asleep, and put things back when it wakes, e.g. so that
+// monitors don't pop. By default, every device with a mute property
+// is muted.
+type SystemSleepConfig struct {
+	// A scene to recall instead of muting
+	Scene string `yaml:"scene"`
+
+	// The devices to mute, if not every one
+	Devices []string `yaml:"devices"`
+}
+
+func (sc *SystemSleepConfig) validate(devices map[string]*motu.Device) error {
+	if sc.Scene != "" && len(sc.Devices) > 0 {
+		return fmt.Errorf("give either a scene or devices, not both")
+	}
+
+	for _, name := range sc.Devices {
+		d, ok := devices[name]
+		if !ok {
+			return fmt.Errorf("unknown device: %s", name)
+		}
+		if d.MuteProperty == "" {
+			return fmt.Errorf("device %s has no mute property", name)
+		}
+	}
+	return nil
+}
+
+// powerEvent is the computer going to sleep or waking up. The
+// computer doesn't sleep until done is closed, or a while passes.
+type powerEvent struct {
+	sleep bool
+	done  chan struct{}
+}
+
+// How long to keep trying to restore things after waking,
+// as the network can take a while to come back
+const wakeRetryTimeout = 30 * time.Second
+
+// runSystemSleep quietens the interface when the computer goes to
+// sleep and restores it on waking. What it changed is kept in the
+// state file, so that it's restored even if the daemon restarts.
+func (s *server) runSystemSleep(ctx context.Context) {
+	events := make(chan powerEvent)
+	errs := make(chan error, 1)
+	go func() { errs <- listenPower(ctx, events) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case err := <-errs:
+			if err != nil {
+				slog.Error("Failed to listen for sleep and wake", "err", err)
+			}
+			return
+		case e := <-events:
+			if e.sleep {
+				if err := s.beforeSleep(); err != nil {
+					slog.Error("Failed to quieten before sleep", "err", err)
+				}
+			} else {
+				if err := s.afterWake(ctx); err != nil {
+					slog.Error("Failed to restore after wake", "err", err)
+				}
+			}
+			close(e.done)
+		}
+	}
+}
+
+// beforeSleep saves the values that are about to
+// change, then mutes or recalls the scene
+func (s *server) beforeSleep() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	sc := s.cfg.SystemSleep
+
+	var scene *Scene
+	if sc.Scene != "" {
+		var err error
+		if scene, err = loadScene(s.scenes, sc.Scene); err != nil {
+			return err
+		}
+	} else {
+		scene = &Scene{Values: map[string]any{}}
+		names := sc.Devices
+		if len(names) == 0 {
+			names = sortedKeys(s.devices)
+		}
+		for _, name := range names {
+			if d := s.devices[name]; d.MuteProperty != "" {
+				scene.Values[d.MuteProperty] = 1.0
+			}
+		}
+	}
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+
+	// If the last wake didn't manage to restore
+	// things, those are still the values to go back to
+	if st.Asleep == nil {
+		st.Asleep = &Snapshot{Created: time.Now(), Values: map[string]any{}}
+	}
+	for p := range scene.Values {
+		key := motu.Key(p)
+		if _, ok := st.Asleep.Values[key]; ok {
+			continue
+		}
+		if st.Asleep.Values[key], err = s.client.Value(motu.Path(key)); err != nil {
+			return fmt.Errorf("failed to read %s: %w", key, err)
+		}
+	}
+
+	// Save first so that the previous values aren't
+	// lost if the computer sleeps part way through
+	if err := st.save(); err != nil {
+		return err
+	}
+
+	slog.Info("Going to sleep", "properties", len(scene.Values))
+	return recallScene(s.client, s.cfg, scene, 0)
+}
+
+// afterWake puts back the values saved by beforeSleep
+func (s *server) afterWake(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st, err := loadState()
+	if err != nil {
+		return err
+	}
+	if st.Asleep == nil {
+		return nil
+	}
+
+	deadline := time.Now().Add(wakeRetryTimeout)
+	for {
+		err = s.client.SetValuesContext(ctx, st.Asleep.Values)
+		if err == nil || time.Now().After(deadline) {
+			break
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(2 * time.Second):
+		}
+	}
+	if err != nil {
+		return fmt.Errorf("failed to restore: %w", err)
+	}
+
+	slog.Info("Woke up", "properties", len(st.Asleep.Values))
+	st.Asleep = nil
+	return st.save()
+}
@@ -0,0 +1,36 @@
+//go:build cgo
+
+#include <IOKit/pwr_mgt/IOPMLib.h>
+#include <IOKit/IOMessage.h>
+#include "_cgo_export.h"
+
+static io_connect_t rootPort;
+
+static void powerCallback(void *refcon, io_service_t service, natural_t type, void *arg) {
+	switch (type) {
+	case kIOMessageCanSystemSleep:
+		IOAllowPowerChange(rootPort, (long)arg);
+		break;
+	case kIOMessageSystemWillSleep:
+		// Sleep waits for this, so the interface is quiet first
+		goPowerEvent(1);
+		IOAllowPowerChange(rootPort, (long)arg);
+		break;
+	case kIOMessageSystemHasPoweredOn:
+		goPowerEvent(0);
+		break;
+	}
+}
+
+int runPowerNotifications(void) {
+	IONotificationPortRef port;
+	io_object_t notifier;
+	rootPort = IORegisterForSystemPower(NULL, &port, powerCallback, &notifier);
+	if (rootPort == MACH_PORT_NULL) {
+		return -1;
+	}
+
+	CFRunLoopAddSource(CFRunLoopGetCurrent(), IONotificationPortGetRunLoopSource(port), kCFRunLoopCommonModes);
+	CFRunLoopRun();
+	return 0;
+}
@@ -0,0 +1,68 @@
+//go:build cgo
+
+package main
+
+/*
+#cgo LDFLAGS: -framework IOKit -framework CoreFoundation
+
+int runPowerNotifications(void);
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"time"
+)
+
+// How long sleep can be held up while the interface is quietened
+const sleepTimeout = 20 * time.Second
+
+// The power callback calls back into goPowerEvent, which can't
+// be given a closure, so the listener's state is kept here
+var darwinPowerEvents chan<- powerEvent
+
+// listenPower sends an event when the Mac is about to
+// sleep, and another when it has woken up
+func listenPower(ctx context.Context, events chan<- powerEvent) error {
+	darwinPowerEvents = events
+
+	errs := make(chan error, 1)
+	go func() {
+		// The run loop belongs to the thread
+		runtime.LockOSThread()
+		if C.runPowerNotifications() != 0 {
+			errs <- fmt.Errorf("failed to register for sleep notifications")
+		}
+	}()
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err := <-errs:
+		return err
+	}
+}
+
+//export goPowerEvent
+func goPowerEvent(sleep C.int) {
+	e := powerEvent{sleep: sleep != 0, done: make(chan struct{})}
+
+	if !e.sleep {
+		// Nothing has to wait for waking up
+		go func() { darwinPowerEvents <- e }()
+		return
+	}
+
+	timeout := time.After(sleepTimeout)
+	select {
+	case darwinPowerEvents <- e:
+	case <-timeout:
+		return
+	}
+	select {
+	case <-e.done:
+	case <-timeout:
+	}
+}
@@ -0,0 +1,12 @@
+//go:build !(darwin && cgo)
+
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+func listenPower(ctx context.Context, events chan<- powerEvent) error {
+	return fmt.Errorf("sleep and wake can only be followed on macOS, built with cgo")
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"os/exec"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// SystemVolumeConfig has the daemon keep a device in step with the
+// operating system's output volume, so that the usual volume controls
+// change the interface too
+type SystemVolumeConfig struct {
+	// The device that follows the system volume. Defaults to "main".
+	Device string `yaml:"device"`
+
+	// Also change the system volume when the device changes
+	TwoWay bool `yaml:"two_way"`
+
+	// How often to check for changes. Defaults to 500ms.
+	Interval time.Duration `yaml:"interval"`
+}
+
+const (
+	defaultSystemVolumeDevice   = "main"
+	defaultSystemVolumeInterval = 500 * time.Millisecond
+)
+
+func (sc *SystemVolumeConfig) validate(devices map[string]*motu.Device) error {
+	if sc.Device == "" {
+		sc.Device = defaultSystemVolumeDevice
+	}
+	if _, ok := devices[sc.Device]; !ok {
+		return fmt.Errorf("unknown device: %s", sc.Device)
+	}
+
+	if sc.Interval < 0 {
+		return fmt.Errorf("interval can't be negative")
+	}
+	if sc.Interval == 0 {
+		sc.Interval = defaultSystemVolumeInterval
+	}
+	return nil
+}
+
+// volumeState is a level as a percentage and a mute state
+type volumeState struct {
+	percent float64
+	muted   bool
+}
+
+// differs returns whether v and o are far enough apart to be a
+// change. The system only has whole percentages, so anything
+// closer than that comes from rounding.
+func (v volumeState) differs(o volumeState) bool {
+	return v.muted != o.muted || math.Abs(v.percent-o.percent) >= 1
+}
+
+// runSystemVolume copies changes to the system volume onto the
+// device, and with TwoWay, changes to the device onto the system.
+// Neither is changed when it starts; only later changes are copied.
+func (s *server) runSystemVolume(ctx context.Context) {
+	sc := s.cfg.SystemVolume
+	d := s.devices[sc.Device]
+
+	// What each side was at when they were last in step
+	var lastSystem, lastDevice volumeState
+	synced := false
+
+	// Only the first of a run of errors is logged, as
+	// they'd otherwise be logged at every interval
+	failing := false
+	fail := func(msg string, err error) {
+		if !failing {
+			slog.Error(msg, "device", sc.Device, "err", err)
+		}
+		failing = true
+	}
+
+	ticker := time.NewTicker(sc.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		system, err := systemVolume()
+		if err != nil {
+			fail("Failed to get system volume", err)
+			continue
+		}
+
+		device, err := s.deviceVolume(ctx, d)
+		if err != nil {
+			fail("Failed to get device volume", err)
+			continue
+		}
+
+		// Without a mute property, the device follows the system's
+		// level but leaves muting to it
+		if d.MuteProperty == "" {
+			device.muted = system.muted
+		}
+
+		switch {
+		case !synced:
+			synced = true
+
+		case system.differs(lastSystem):
+			if err := s.setDeviceVolume(ctx, sc.Device, d, system); err != nil {
+				fail("Failed to follow system volume", err)
+				continue
+			}
+			device = system
+
+		case sc.TwoWay && device.differs(lastDevice):
+			if err := setSystemVolume(device); err != nil {
+				fail("Failed to change system volume", err)
+				continue
+			}
+			system = volumeState{percent: math.Round(device.percent), muted: device.muted}
+		}
+
+		lastSystem, lastDevice = system, device
+		failing = false
+	}
+}
+
+// deviceVolume returns the device's level and mute state
+func (s *server) deviceVolume(ctx context.Context, d *motu.Device) (volumeState, error) {
+	status, err := s.client.StatusContext(ctx, d)
+	if err != nil {
+		return volumeState{}, err
+	}
+
+	return volumeState{percent: status.Percent, muted: status.Muted}, nil
+}
+
+// setDeviceVolume changes the device's level, and its mute if it has one
+func (s *server) setDeviceVolume(ctx context.Context, name string, d *motu.Device, v volumeState) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.setPercent(ctx, name, d, v.percent); err != nil {
+		return fmt.Errorf("failed to set level: %w", err)
+	}
+
+	if d.MuteProperty != "" {
+		if err := s.client.SetMuteContext(ctx, d, v.muted); err != nil {
+			return fmt.Errorf("failed to set mute: %w", err)
+		}
+	}
+
+	return nil
+}
+
+var (
+	macVolumeRegexp   = regexp.MustCompile(`output volume:(\d+|missing value), .*output muted:(true|false|missing value)`)
+	pulseVolumeRegexp = regexp.MustCompile(`(\d+)%`)
+)
+
+// systemVolume returns the operating system's output volume. On Linux,
+// it comes from PulseAudio, or PipeWire's stand-in for it.
+func systemVolume() (volumeState, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		out, err := exec.Command("osascript", "-e", "get volume settings").Output()
+		if err != nil {
+			return volumeState{}, fmt.Errorf("failed to run osascript: %w", err)
+		}
+
+		m := macVolumeRegexp.FindStringSubmatch(string(out))
+		if m == nil {
+			return volumeState{}, fmt.Errorf("unexpected volume settings: %s", strings.TrimSpace(string(out)))
+		}
+		if m[1] == "missing value" {
+			return volumeState{}, errors.New("the system output has no volume control")
+		}
+
+		percent, _ := strconv.ParseFloat(m[1], 64)
+		return volumeState{percent: percent, muted: m[2] == "true"}, nil
+
+	case "linux":
+		out, err := exec.Command("pactl", "get-sink-volume", "@DEFAULT_SINK@").Output()
+		if err != nil {
+			return volumeState{}, fmt.Errorf("failed to run pactl: %w", err)
+		}
+
+		m := pulseVolumeRegexp.FindStringSubmatch(string(out))
+		if m == nil {
+			return volumeState{}, fmt.Errorf("unexpected sink volume: %s", strings.TrimSpace(string(out)))
+		}
+		percent, _ := strconv.ParseFloat(m[1], 64)
+
+		out, err = exec.Command("pactl", "get-sink-mute", "@DEFAULT_SINK@").Output()
+		if err != nil {
+			return volumeState{}, fmt.Errorf("failed to run pactl: %w", err)
+		}
+
+		return volumeState{percent: percent, muted: strings.Contains(string(out), "yes")}, nil
+
+	default:
+		return volumeState{}, fmt.Errorf("the system volume is not supported on %s", runtime.GOOS)
+	}
+}
+
+// setSystemVolume changes the operating system's output volume
+func setSystemVolume(v volumeState) error {
+	percent := strconv.Itoa(int(math.Round(v.percent)))
+
+	var cmds []*exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("set volume output volume %s output muted %t", percent, v.muted)
+		cmds = append(cmds, exec.Command("osascript", "-e", script))
+	case "linux":
+		cmds = append(cmds,
+			exec.Command("pactl", "set-sink-volume", "@DEFAULT_SINK@", percent+"%"),
+			exec.Command("pactl", "set-sink-mute", "@DEFAULT_SINK@", strconv.FormatBool(v.muted)),
+		)
+	default:
+		return fmt.Errorf("the system volume is not supported on %s", runtime.GOOS)
+	}
+
+	for _, cmd := range cmds {
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("failed to run %s: %w", cmd.Args[0], err)
+		}
+	}
+
+	return nil
+}
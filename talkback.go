@@ -0,0 +1,89 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// TalkbackConfig describes the talkback mic and where it is heard
+type TalkbackConfig struct {
+	// Mixer channel that the talkback mic is on
+	Channel int `yaml:"channel"`
+
+	// Aux buses (cue mixes) that talkback is sent to
+	Sends []int `yaml:"sends"`
+
+	// Send level in dB while talkback is open
+	Level float64 `yaml:"level"`
+}
+
+func talkbackCommand(args []string) error {
+	if len(args) < 1 {
+		return usagef("usage: talkback on|off|push")
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	if cfg.Talkback == nil {
+		return fmt.Errorf("no talkback configured")
+	}
+	tb := cfg.Talkback
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	switch args[0] {
+	case "on", "off":
+		open := args[0] == "on"
+		if err := tb.set(m, open); err != nil {
+			return err
+		}
+		return printResult(map[string]bool{"talkback": open}, args[0])
+	case "push":
+		ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+		defer cancel()
+
+		if err := tb.set(m, true); err != nil {
+			return err
+		}
+
+		if err := printResult(map[string]bool{"talkback": true}, "Talkback open, press Ctrl-C to release"); err != nil {
+			return err
+		}
+		<-ctx.Done()
+
+		return tb.set(m, false)
+	default:
+		return usagef("usage: talkback on|off|push")
+	}
+}
+
+// set opens or closes talkback. Opening it also sets the sends so
+// that talkback is heard even if the cue mixes have been changed.
+func (tb *TalkbackConfig) set(m *motu.Client, open bool) error {
+	values := map[string]any{}
+
+	if open {
+		values[fmt.Sprintf("mix/chan/%d/matrix/mute", tb.Channel)] = 0.0
+		for _, aux := range tb.Sends {
+			values[fmt.Sprintf("mix/chan/%d/matrix/aux/%d/send", tb.Channel, aux)] = motu.DBToAmplitude(tb.Level)
+		}
+	} else {
+		values[fmt.Sprintf("mix/chan/%d/matrix/mute", tb.Channel)] = 1.0
+	}
+
+	if err := m.SetValues(values); err != nil {
+		return fmt.Errorf("failed to update talkback: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// Trigger runs a scene and/or a command when a datastore property
+// changes. Triggers are run by the daemon.
+type Trigger struct {
+	// The property to watch, e.g. datastore/mix/chan/10/matrix/mute
+	Path string `yaml:"path"`
+
+	// Only fire when the property becomes this value.
+	// If not set, any change fires the trigger.
+	Value any `yaml:"value"`
+
+	// Scene to recall
+	Scene string `yaml:"scene"`
+
+	// Command to run with sh -c. MOTU_KEY and MOTU_VALUE are set
+	// to the property that changed and its new value.
+	Command string `yaml:"command"`
+}
+
+func (t *Trigger) validate() error {
+	if t.Path == "" {
+		return fmt.Errorf("path is required")
+	}
+
+	if t.Scene == "" && t.Command == "" {
+		return fmt.Errorf("trigger on %s does nothing", t.Path)
+	}
+
+	return nil
+}
+
+// matches returns whether the new value should fire the trigger
+func (t *Trigger) matches(v any) bool {
+	if t.Value == nil {
+		return true
+	}
+
+	want, wantNumber := toFloat(t.Value)
+	got, gotNumber := toFloat(v)
+	if wantNumber && gotNumber {
+		return want == got
+	}
+
+	return reflect.DeepEqual(t.Value, v)
+}
+
+// runTriggers fires the configured triggers until ctx is cancelled
+func (s *server) runTriggers(ctx context.Context, mirror *motu.Mirror) {
+	changes, unsubscribe := mirror.Subscribe()
+	defer unsubscribe()
+
+	// The mirror sends the whole datastore whenever it
+	// syncs, so only fire triggers for real changes
+	last := map[string]any{}
+
+	for {
+		var batch map[string]any
+		select {
+		case <-ctx.Done():
+			return
+		case batch = <-changes:
+		}
+
+		// Several triggers can watch the same property
+		changed := map[string]bool{}
+		for _, t := range s.cfg.Triggers {
+			key := motu.Key(t.Path)
+			if _, done := changed[key]; done {
+				continue
+			}
+
+			v, ok := batch[key]
+			if !ok {
+				continue
+			}
+
+			previous, seen := last[key]
+			last[key] = v
+			changed[key] = seen && !reflect.DeepEqual(previous, v)
+		}
+
+		for _, t := range s.cfg.Triggers {
+			key := motu.Key(t.Path)
+			if changed[key] && t.matches(batch[key]) {
+				go s.fire(t, key, batch[key])
+			}
+		}
+	}
+}
+
+func (s *server) fire(t *Trigger, key string, v any) {
+	slog.Info("Trigger fired", "path", t.Path)
+
+	if t.Scene != "" {
+		scene, err := loadScene(s.scenes, t.Scene)
+		if err != nil {
+			slog.Error("Failed to load scene", "scene", t.Scene, "err", err)
+		} else {
+			s.mu.Lock()
+			err = recallScene(s.client, s.cfg, scene, 0)
+			s.mu.Unlock()
+			if err != nil {
+				slog.Error("Failed to recall scene", "scene", t.Scene, "err", err)
+			}
+		}
+	}
+
+	runHook("trigger", t.Command, map[string]string{
+		"MOTU_KEY":   key,
+		"MOTU_VALUE": fmt.Sprint(v),
+	})
+}
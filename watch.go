@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jakewright/motu-tools/motu"
+)
+
+// watchChange is the JSON representation of a changed value
+type watchChange struct {
+	Time  time.Time `json:"time"`
+	Key   string    `json:"key"`
+	Value any       `json:"value"`
+}
+
+// watchCommand prints every change to the datastore as it happens,
+// optionally limited to keys under a path prefix
+func watchCommand(args []string) error {
+	flags := flag.NewFlagSet("watch", flag.ContinueOnError)
+	asJSON := flags.Bool("json", jsonOutput, "print changes as JSON lines")
+	positional, err := parseFlags(flags, args)
+	if err != nil {
+		return err
+	}
+
+	var prefix string
+	if len(positional) > 0 {
+		prefix = motu.Key(positional[0])
+	}
+
+	cfg, err := readConfig()
+	if err != nil {
+		return err
+	}
+
+	m, err := newClient(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create client: %w", err)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer cancel()
+
+	changes, unsubscribe := m.Sync(ctx).Subscribe()
+	defer unsubscribe()
+
+	enc := json.NewEncoder(os.Stdout)
+
+	// The first batch is the whole datastore, which is only
+	// used to tell real changes apart from resyncs later on
+	var last map[string]any
+
+	for {
+		var batch map[string]any
+		select {
+		case <-ctx.Done():
+			return nil
+		case batch = <-changes:
+		}
+
+		if last == nil {
+			last = batch
+			continue
+		}
+
+		var keys []string
+		for k, v := range batch {
+			if prev, ok := last[k]; ok && reflect.DeepEqual(prev, v) {
+				continue
+			}
+			last[k] = v
+
+			if prefix == "" || k == prefix || strings.HasPrefix(k, prefix+"/") {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+
+		now := time.Now()
+		for _, k := range keys {
+			if *asJSON {
+				if err := enc.Encode(&watchChange{Time: now, Key: k, Value: batch[k]}); err != nil {
+					return fmt.Errorf("failed to write change: %w", err)
+				}
+				continue
+			}
+
+			fmt.Printf("%s  %s = %v\n", now.Format("15:04:05.000"), k, batch[k])
+		}
+	}
+}